@@ -0,0 +1,227 @@
+package httpregistry
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// adminRegistration is the representation of a Registration returned by the admin API
+type adminRegistration struct {
+	ID                 int    `json:"id"`
+	Name               string `json:"name"`
+	Enabled            bool   `json:"enabled"`
+	Priority           int    `json:"priority"`
+	Calls              int    `json:"calls"`
+	RemainingResponses int    `json:"remainingResponses"`
+	// Stub is the definition of the registration if it was added from a stub, for example through the admin API
+	Stub *Stub `json:"stub,omitempty"`
+}
+
+// newAdminRegistration creates the representation of registration
+func newAdminRegistration(registration *Registration) adminRegistration {
+	return adminRegistration{
+		ID:                 registration.ID(),
+		Name:               registration.String(),
+		Enabled:            registration.IsEnabled(),
+		Priority:           registration.Priority(),
+		Calls:              registration.NumberOfCalls(),
+		RemainingResponses: registration.RemainingResponses(),
+		Stub:               registration.stubDefinition(),
+	}
+}
+
+// adminWhy is the body returned by the why endpoint of the admin API
+type adminWhy struct {
+	Why    string     `json:"why"`
+	Report MissReport `json:"report"`
+}
+
+// adminError is the body returned by the admin API when a call fails
+type adminError struct {
+	Error string `json:"error"`
+}
+
+// EnableAdminAPI exposes, under prefix, JSON endpoints that can be used to add, inspect and remove registrations while the server is running.
+// It is designed for long running mock servers where the registrations cannot be changed from the code, for example in a docker-compose stack.
+// The endpoints are
+//
+//	POST   {prefix}/stubs              adds the registration defined by the Stub in the body and returns it
+//	GET    {prefix}/stubs              returns all the registrations
+//	DELETE {prefix}/stubs              removes all the registrations and resets the registry like Reset, the journal included
+//	GET    {prefix}/stubs/{id}         returns the registration with the given ID
+//	DELETE {prefix}/stubs/{id}         removes the registration with the given ID
+//	GET    {prefix}/stubs/{id}/matches returns the requests served by the registration with the given ID
+//	GET    {prefix}/why                returns why the last request did not match, like Why and WhyReport
+//	GET    {prefix}/unconsumed         returns the registrations with unused responses, like CheckAllResponsesAreConsumed
+//
+// The calls to the admin API are not matched against the registrations and they are not recorded in the journal.
+// For example
+//
+//	reg := httpregistry.NewRegistry(t)
+//	reg.EnableAdminAPI("/__admin")
+//	server := reg.GetServer()
+//	http.Post(server.URL+"/__admin/stubs", "application/json", strings.NewReader(`{"request": {"url": "/users"}}`))
+func (reg *Registry) EnableAdminAPI(prefix string) {
+	prefix = strings.Trim(prefix, "/")
+	if prefix == "" {
+		panic("the prefix of the admin API cannot be empty")
+	}
+	prefix = "/" + prefix
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST "+prefix+"/stubs", reg.adminAddStub)
+	mux.HandleFunc("GET "+prefix+"/stubs", reg.adminListRegistrations)
+	mux.HandleFunc("DELETE "+prefix+"/stubs", reg.adminRemoveRegistrations)
+	mux.HandleFunc("GET "+prefix+"/stubs/{id}", reg.adminGetRegistration)
+	mux.HandleFunc("DELETE "+prefix+"/stubs/{id}", reg.adminRemoveRegistration)
+	mux.HandleFunc("GET "+prefix+"/stubs/{id}/matches", reg.adminGetMatches)
+	mux.HandleFunc("GET "+prefix+"/why", reg.adminWhy)
+	mux.HandleFunc("GET "+prefix+"/unconsumed", reg.adminUnconsumed)
+
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+	reg.adminPrefix = prefix
+	reg.admin = mux
+}
+
+// adminHandler returns the handler of the admin API if r is a call to it, otherwise it returns nil
+func (reg *Registry) adminHandler(r *http.Request) http.Handler {
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+
+	if reg.admin == nil {
+		return nil
+	}
+	if r.URL.Path != reg.adminPrefix && !strings.HasPrefix(r.URL.Path, reg.adminPrefix+"/") {
+		return nil
+	}
+	return reg.admin
+}
+
+// writeAdminJSON writes v encoded as JSON with the given status
+func writeAdminJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_, _ = w.Write(mustMarshalJSON(v))
+}
+
+// writeAdminError writes an error of the admin API with the given status
+func writeAdminError(w http.ResponseWriter, status int, format string, args ...any) {
+	writeAdminJSON(w, status, adminError{Error: fmt.Sprintf(format, args...)})
+}
+
+// registrationByID returns the registration with the given ID, or nil if no such registration exists
+func (reg *Registry) registrationByID(id int) *Registration {
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+
+	for _, registration := range reg.registrations {
+		if registration.id == id {
+			return registration
+		}
+	}
+	return nil
+}
+
+// adminRegistrationFromPath returns the registration identified by the id in the path of r.
+// If the registration does not exist the error is written to w and it returns nil
+func (reg *Registry) adminRegistrationFromPath(w http.ResponseWriter, r *http.Request) *Registration {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		writeAdminError(w, http.StatusBadRequest, "the id %q is not a number", r.PathValue("id"))
+		return nil
+	}
+	registration := reg.registrationByID(id)
+	if registration == nil {
+		writeAdminError(w, http.StatusNotFound, "there is no registration with id %d", id)
+		return nil
+	}
+	return registration
+}
+
+// adminAddStub serves POST {prefix}/stubs: it adds the registration defined by the Stub in the body and returns it with the status 201
+func (reg *Registry) adminAddStub(w http.ResponseWriter, r *http.Request) {
+	decoder := json.NewDecoder(bytes.NewReader(readBody(r)))
+	decoder.DisallowUnknownFields()
+
+	stub := Stub{}
+	if err := decoder.Decode(&stub); err != nil {
+		writeAdminError(w, http.StatusBadRequest, "the stub is not valid JSON: %v", err)
+		return
+	}
+	registration, err := reg.AddStub(stub)
+	if err != nil {
+		writeAdminError(w, http.StatusBadRequest, "the stub is not valid: %v", err)
+		return
+	}
+	writeAdminJSON(w, http.StatusCreated, newAdminRegistration(registration))
+}
+
+// adminListRegistrations serves GET {prefix}/stubs: it returns all the registrations in order of registration
+func (reg *Registry) adminListRegistrations(w http.ResponseWriter, _ *http.Request) {
+	registrations := []adminRegistration{}
+	for _, registration := range reg.GetRegistrations() {
+		registrations = append(registrations, newAdminRegistration(registration))
+	}
+	writeAdminJSON(w, http.StatusOK, registrations)
+}
+
+// adminRemoveRegistrations serves DELETE {prefix}/stubs: it resets the registry like Reset,
+// so together with the registrations it removes the journal, the reasons why the last request did not match and the session cookies
+func (reg *Registry) adminRemoveRegistrations(w http.ResponseWriter, _ *http.Request) {
+	reg.Reset()
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// adminGetRegistration serves GET {prefix}/stubs/{id}: it returns the registration with the given ID
+func (reg *Registry) adminGetRegistration(w http.ResponseWriter, r *http.Request) {
+	if registration := reg.adminRegistrationFromPath(w, r); registration != nil {
+		writeAdminJSON(w, http.StatusOK, newAdminRegistration(registration))
+	}
+}
+
+// adminRemoveRegistration serves DELETE {prefix}/stubs/{id}: it removes the registration with the given ID, the journal is kept
+func (reg *Registry) adminRemoveRegistration(w http.ResponseWriter, r *http.Request) {
+	if registration := reg.adminRegistrationFromPath(w, r); registration != nil {
+		registration.Remove()
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// adminGetMatches serves GET {prefix}/stubs/{id}/matches: it returns, in chronological order, the requests served by the registration with the given ID
+func (reg *Registry) adminGetMatches(w http.ResponseWriter, r *http.Request) {
+	registration := reg.adminRegistrationFromPath(w, r)
+	if registration == nil {
+		return
+	}
+
+	matches := []ReportedRequest{}
+	for _, match := range registration.Matches() {
+		matches = append(matches, newReportedRequest(match, readBody(match)))
+	}
+	writeAdminJSON(w, http.StatusOK, matches)
+}
+
+// adminWhy serves GET {prefix}/why: it returns why the last request did not match, both as text and as a MissReport
+func (reg *Registry) adminWhy(w http.ResponseWriter, _ *http.Request) {
+	reg.mu.Lock()
+	why := adminWhy{Why: missesToString(reg.misses), Report: reg.report}
+	reg.mu.Unlock()
+
+	writeAdminJSON(w, http.StatusOK, why)
+}
+
+// adminUnconsumed serves GET {prefix}/unconsumed: it returns the registrations with unused responses
+func (reg *Registry) adminUnconsumed(w http.ResponseWriter, _ *http.Request) {
+	registrations := []adminRegistration{}
+	for _, registration := range reg.GetRegistrations() {
+		if !registration.ExpectationsMet() {
+			registrations = append(registrations, newAdminRegistration(registration))
+		}
+	}
+	writeAdminJSON(w, http.StatusOK, registrations)
+}
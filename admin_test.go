@@ -0,0 +1,203 @@
+package httpregistry_test
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/dfioravanti/httpregistry"
+)
+
+// adminCall calls the admin API with method and path and returns the status code and the body of the response
+func (s *TestSuite) adminCall(method string, url string, body string) (int, string) {
+	request, err := http.NewRequest(method, url, strings.NewReader(body))
+	s.Require().NoError(err)
+	res, err := http.DefaultClient.Do(request)
+	s.Require().NoError(err)
+	defer res.Body.Close()
+	b, err := io.ReadAll(res.Body)
+	s.NoError(err)
+	return res.StatusCode, string(b)
+}
+
+func (s *TestSuite) TestAdminAPIAddAndListStubs() {
+	reg := httpregistry.NewRegistry(s.T())
+	reg.AddMethodAndURL(http.MethodGet, "/health")
+	reg.EnableAdminAPI("/__admin/")
+	url := reg.GetServer().URL
+
+	status, body := s.adminCall(http.MethodPost, url+"/__admin/stubs", `{"name": "users", "request": {"method": "GET", "url": "/users"}, "responses": [{"body": "John"}, {"body": "Jane"}]}`)
+	s.Equal(http.StatusCreated, status)
+	s.JSONEq(`{"id": 2, "name": "users", "enabled": true, "priority": 0, "calls": 0, "remainingResponses": 2, "stub": {"name": "users", "request": {"method": "GET", "url": "/users"}, "responses": [{"body": "John"}, {"body": "Jane"}]}}`, body)
+
+	status, body = s.adminCall(http.MethodGet, url+"/users", "")
+	s.Equal(http.StatusOK, status)
+	s.Equal("John", body)
+
+	status, body = s.adminCall(http.MethodGet, url+"/__admin/stubs", "")
+	s.Equal(http.StatusOK, status)
+	s.JSONEq(`[
+		{"id": 1, "name": "mock request #1", "enabled": true, "priority": 0, "calls": 0, "remainingResponses": 1},
+		{"id": 2, "name": "users", "enabled": true, "priority": 0, "calls": 1, "remainingResponses": 1, "stub": {"name": "users", "request": {"method": "GET", "url": "/users"}, "responses": [{"body": "John"}, {"body": "Jane"}]}}
+	]`, body)
+
+	status, body = s.adminCall(http.MethodGet, url+"/__admin/stubs/2", "")
+	s.Equal(http.StatusOK, status)
+	s.JSONEq(`{"id": 2, "name": "users", "enabled": true, "priority": 0, "calls": 1, "remainingResponses": 1, "stub": {"name": "users", "request": {"method": "GET", "url": "/users"}, "responses": [{"body": "John"}, {"body": "Jane"}]}}`, body)
+
+	status, body = s.adminCall(http.MethodGet, url+"/__admin/unconsumed", "")
+	s.Equal(http.StatusOK, status)
+	s.JSONEq(`[
+		{"id": 1, "name": "mock request #1", "enabled": true, "priority": 0, "calls": 0, "remainingResponses": 1},
+		{"id": 2, "name": "users", "enabled": true, "priority": 0, "calls": 1, "remainingResponses": 1, "stub": {"name": "users", "request": {"method": "GET", "url": "/users"}, "responses": [{"body": "John"}, {"body": "Jane"}]}}
+	]`, body)
+}
+
+func (s *TestSuite) TestAdminAPIKeepsEveryFieldOfTheStub() {
+	reg := httpregistry.NewRegistry(s.T())
+	reg.EnableAdminAPI("/__admin")
+	url := reg.GetServer().URL
+
+	status, _ := s.adminCall(http.MethodPost, url+"/__admin/stubs", string(mustMarshalJSON(stubWithEveryResponseField)))
+	s.Equal(http.StatusCreated, status)
+
+	status, body := s.adminCall(http.MethodGet, url+"/__admin/stubs/1", "")
+	s.Equal(http.StatusOK, status)
+	registration := struct {
+		Stub httpregistry.Stub `json:"stub"`
+	}{}
+	s.NoError(json.Unmarshal([]byte(body), &registration))
+	s.Equal(stubWithEveryResponseField, registration.Stub)
+
+	res, err := http.Get(url + "/items")
+	s.NoError(err)
+	s.checkResponseWithEveryField(res)
+}
+
+func (s *TestSuite) TestAdminAPIRejectsInvalidStubs() {
+	reg := httpregistry.NewRegistry(s.T())
+	reg.EnableAdminAPI("__admin")
+	url := reg.GetServer().URL
+
+	testCases := []struct {
+		name          string
+		body          string
+		expectedError string
+	}{
+		{
+			name:          "not JSON",
+			body:          `{`,
+			expectedError: "the stub is not valid JSON: unexpected EOF",
+		},
+		{
+			name:          "unknown field",
+			body:          `{"request": {"path": "/users"}}`,
+			expectedError: `the stub is not valid JSON: json: unknown field "path"`,
+		},
+		{
+			name:          "invalid stub",
+			body:          `{"request": {"url": "("}}`,
+			expectedError: "the stub is not valid: the URL is not a valid regex: error parsing regexp: missing closing ): `(`",
+		},
+	}
+	for _, tc := range testCases {
+		s.Run(tc.name, func() {
+			status, body := s.adminCall(http.MethodPost, url+"/__admin/stubs", tc.body)
+			s.Equal(http.StatusBadRequest, status)
+			s.JSONEq(string(mustMarshalJSON(map[string]string{"error": tc.expectedError})), body)
+		})
+	}
+}
+
+func (s *TestSuite) TestAdminAPIDeleteStubs() {
+	reg := httpregistry.NewRegistry(s.T())
+	users := reg.AddRequestWithInfiniteResponse(httpregistry.NewRequest().WithURL("/users"), httpregistry.NoContentResponse)
+	reg.AddRequestWithInfiniteResponse(httpregistry.NewRequest().WithURL("/orders"), httpregistry.NoContentResponse)
+	reg.EnableAdminAPI("/__admin")
+	url := reg.GetServer().URL
+
+	status, _ := s.adminCall(http.MethodDelete, url+"/__admin/stubs/1", "")
+	s.Equal(http.StatusNoContent, status)
+	status, body := s.adminCall(http.MethodGet, url+"/__admin/stubs/1", "")
+	s.Equal(http.StatusNotFound, status)
+	s.JSONEq(`{"error": "there is no registration with id 1"}`, body)
+	s.Equal(1, users.ID())
+
+	status, body = s.adminCall(http.MethodDelete, url+"/__admin/stubs/users", "")
+	s.Equal(http.StatusBadRequest, status)
+	s.JSONEq(`{"error": "the id \"users\" is not a number"}`, body)
+
+	status, _ = s.adminCall(http.MethodDelete, url+"/__admin/stubs", "")
+	s.Equal(http.StatusNoContent, status)
+	status, body = s.adminCall(http.MethodGet, url+"/__admin/stubs", "")
+	s.Equal(http.StatusOK, status)
+	s.JSONEq(`[]`, body)
+}
+
+func (s *TestSuite) TestAdminAPIDeleteStubsResetsTheRegistry() {
+	t := httpregistry.NewMockTestingT()
+	reg := httpregistry.NewRegistry(t)
+	reg.AddMethodAndURL(http.MethodGet, "/users")
+	reg.EnableAdminAPI("/__admin")
+	url := reg.GetServer().URL
+
+	status, _ := s.adminCall(http.MethodPost, url+"/orders", "")
+	s.Equal(http.StatusInternalServerError, status)
+	s.Len(reg.GetJournal(httpregistry.NewJournalQuery()), 1)
+	s.NotEmpty(reg.Why())
+
+	status, _ = s.adminCall(http.MethodDelete, url+"/__admin/stubs", "")
+	s.Equal(http.StatusNoContent, status)
+
+	s.Empty(reg.GetRegistrations())
+	s.Empty(reg.GetJournal(httpregistry.NewJournalQuery()))
+	s.Empty(reg.Why())
+	s.Equal(httpregistry.MissReport{}, reg.WhyReport())
+	status, body := s.adminCall(http.MethodGet, url+"/__admin/why", "")
+	s.Equal(http.StatusOK, status)
+	s.JSONEq(string(mustMarshalJSON(map[string]any{"why": "", "report": httpregistry.MissReport{}})), body)
+}
+
+func (s *TestSuite) TestAdminAPIMatchesAndWhy() {
+	t := httpregistry.NewMockTestingT()
+	reg := httpregistry.NewRegistry(t)
+	reg.AddRequest(httpregistry.NewRequest().WithMethod(http.MethodPost).WithURL("/users"))
+	reg.EnableAdminAPI("/__admin")
+	url := reg.GetServer().URL
+
+	status, _ := s.adminCall(http.MethodPost, url+"/users", `{"name":"John"}`)
+	s.Equal(http.StatusOK, status)
+
+	status, body := s.adminCall(http.MethodGet, url+"/__admin/stubs/1/matches", "")
+	s.Equal(http.StatusOK, status)
+	matches := []httpregistry.ReportedRequest{}
+	s.NoError(json.Unmarshal([]byte(body), &matches))
+	s.Len(matches, 1)
+	s.Equal(http.MethodPost, matches[0].Method)
+	s.Equal("/users", matches[0].URL)
+	s.Equal(`{"name":"John"}`, matches[0].Body)
+
+	status, _ = s.adminCall(http.MethodGet, url+"/orders", "")
+	s.Equal(http.StatusInternalServerError, status)
+	s.True(t.HasFailed)
+
+	status, body = s.adminCall(http.MethodGet, url+"/__admin/why", "")
+	s.Equal(http.StatusOK, status)
+	why := struct {
+		Why    string                  `json:"why"`
+		Report httpregistry.MissReport `json:"report"`
+	}{}
+	s.NoError(json.Unmarshal([]byte(body), &why))
+	s.Contains(why.Why, "mock request #1 missed because")
+	s.Equal("/orders", why.Report.Request.URL)
+	s.Len(why.Report.Registrations, 1)
+
+	// the calls to the admin API are not recorded in the journal
+	s.Len(reg.GetJournal(httpregistry.NewJournalQuery()), 2)
+}
+
+func (s *TestSuite) TestEnableAdminAPIPanicsOnEmptyPrefix() {
+	reg := httpregistry.NewRegistry(s.T())
+	s.PanicsWithValue("the prefix of the admin API cannot be empty", func() { reg.EnableAdminAPI("/") })
+}
@@ -0,0 +1,145 @@
+package httpregistry
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// AssertCalledWithJSONBody checks that at least one of requests has a JSON body equal to expected.
+// The comparison is semantic, so the order of the keys and the whitespace do not matter.
+// If no request matches, the test is failed with a diff between expected and the body of each request.
+//
+//	reg.AddRequest(request)
+//	...
+//	httpregistry.AssertCalledWithJSONBody(t, reg.GetMatchesForRequest(request), map[string]any{"name": "John"})
+func AssertCalledWithJSONBody(t TestingT, requests []*http.Request, expected any) bool {
+	expectedJSON, err := normalizeJSON(expected)
+	if err != nil {
+		t.Errorf("expected body cannot be converted to JSON: %v", err)
+		return false
+	}
+
+	diffs := []string{}
+	for i, r := range requests {
+		diff := diffJSONBody(expectedJSON, readBody(r))
+		if len(diff) == 0 {
+			return true
+		}
+		diffs = append(diffs, fmt.Sprintf("call %d:\n\t%s", i, strings.Join(diff, "\n\t")))
+	}
+
+	t.Errorf("no call has a JSON body equal to %s, the differences are:\n%s", compactJSON(expectedJSON), strings.Join(diffs, "\n"))
+	return false
+}
+
+// AssertNthCallHasJSONBody checks that the nth request of requests, counting from 0, has a JSON body equal to expected.
+// The comparison is semantic, so the order of the keys and the whitespace do not matter.
+// If the body is different, the test is failed with a diff between expected and the body.
+func AssertNthCallHasJSONBody(t TestingT, requests []*http.Request, n int, expected any) bool {
+	if !checkNthCallExists(t, requests, n) {
+		return false
+	}
+
+	expectedJSON, err := normalizeJSON(expected)
+	if err != nil {
+		t.Errorf("expected body cannot be converted to JSON: %v", err)
+		return false
+	}
+
+	diff := diffJSONBody(expectedJSON, readBody(requests[n]))
+	if len(diff) > 0 {
+		t.Errorf("call %d does not have a JSON body equal to %s, the differences are:\n\t%s", n, compactJSON(expectedJSON), strings.Join(diff, "\n\t"))
+		return false
+	}
+	return true
+}
+
+// AssertCalledWithHeader checks that at least one of requests has the header header with value value.
+// If the header is repeated, it is enough that one of its values is equal to value.
+// If no request matches, the test is failed with the values that each request had.
+func AssertCalledWithHeader(t TestingT, requests []*http.Request, header string, value string) bool {
+	actuals := []string{}
+	for i, r := range requests {
+		values := r.Header.Values(header)
+		for _, v := range values {
+			if v == value {
+				return true
+			}
+		}
+		actuals = append(actuals, fmt.Sprintf("call %d: %q", i, values))
+	}
+
+	t.Errorf("no call has header %q with value %q, the values are:\n\t%s", header, value, strings.Join(actuals, "\n\t"))
+	return false
+}
+
+// AssertNthCallHasHeader checks that the nth request of requests, counting from 0, has the header header with value value.
+// If the header is repeated, it is enough that one of its values is equal to value.
+func AssertNthCallHasHeader(t TestingT, requests []*http.Request, n int, header string, value string) bool {
+	if !checkNthCallExists(t, requests, n) {
+		return false
+	}
+
+	values := requests[n].Header.Values(header)
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	t.Errorf("call %d does not have header %q with value %q, expected %q got %q", n, header, value, value, values)
+	return false
+}
+
+// AssertNthCallHasQueryParam checks that the nth request of requests, counting from 0, has the query parameter param with value value.
+// If the parameter is repeated, it is enough that one of its values is equal to value.
+func AssertNthCallHasQueryParam(t TestingT, requests []*http.Request, n int, param string, value string) bool {
+	if !checkNthCallExists(t, requests, n) {
+		return false
+	}
+
+	values := requests[n].URL.Query()[param]
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	t.Errorf("call %d does not have query parameter %q with value %q, expected %q got %q", n, param, value, value, values)
+	return false
+}
+
+// checkNthCallExists fails the test if requests does not have a nth element
+func checkNthCallExists(t TestingT, requests []*http.Request, n int) bool {
+	if n < 0 || n >= len(requests) {
+		t.Errorf("call %d does not exist, there were %d calls", n, len(requests))
+		return false
+	}
+	return true
+}
+
+// normalizeJSON converts v into the generic representation of JSON produced by json.Unmarshal so that it can be compared with jsonDiff
+func normalizeJSON(v any) (any, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	var normalized any
+	if err := json.Unmarshal(b, &normalized); err != nil {
+		return nil, err
+	}
+	return normalized, nil
+}
+
+// diffJSONBody returns the differences between expected, in the representation returned by normalizeJSON, and body.
+// If body is not valid JSON a single line that reports it is returned
+func diffJSONBody(expected any, body []byte) []string {
+	var actual any
+	if err := json.Unmarshal(body, &actual); err != nil {
+		return []string{fmt.Sprintf("the body %q is not valid JSON: %v", truncate(string(body)), err)}
+	}
+	return jsonDiff("$", expected, actual)
+}
@@ -0,0 +1,95 @@
+package httpregistry_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	"github.com/dfioravanti/httpregistry"
+)
+
+func newCapturedRequests() []*http.Request {
+	first := httptest.NewRequest(http.MethodPost, "/users?page=1", strings.NewReader(`{"name": "John", "age": 42}`))
+	first.Header.Add("Accept", "application/json")
+	second := httptest.NewRequest(http.MethodPost, "/users?page=2&page=3", strings.NewReader(`{"name": "Jane", "tags": ["a"]}`))
+	second.Header.Add("Accept", "text/html")
+	second.Header.Add("Accept", "application/xml")
+	return []*http.Request{first, second}
+}
+
+func (s *TestSuite) TestAssertionsThatSucceed() {
+	mockT := httpregistry.NewMockTestingT()
+	requests := newCapturedRequests()
+
+	s.True(httpregistry.AssertCalledWithJSONBody(mockT, requests, map[string]any{"tags": []string{"a"}, "name": "Jane"}))
+	s.True(httpregistry.AssertNthCallHasJSONBody(mockT, requests, 0, map[string]any{"age": 42, "name": "John"}))
+	s.True(httpregistry.AssertCalledWithHeader(mockT, requests, "Accept", "application/xml"))
+	s.True(httpregistry.AssertNthCallHasHeader(mockT, requests, 1, "Accept", "application/xml"))
+	s.True(httpregistry.AssertNthCallHasQueryParam(mockT, requests, 1, "page", "3"))
+
+	// the bodies are not consumed by the assertions
+	s.True(httpregistry.AssertNthCallHasJSONBody(mockT, requests, 0, map[string]any{"age": 42, "name": "John"}))
+
+	s.False(mockT.HasFailed)
+}
+
+func (s *TestSuite) TestAssertionsThatFailReportADiff() {
+	testCases := []struct {
+		name            string
+		assert          func(t httpregistry.TestingT, requests []*http.Request) bool
+		expectedMessage string
+	}{
+		{
+			name: "called with JSON body",
+			assert: func(t httpregistry.TestingT, requests []*http.Request) bool {
+				return httpregistry.AssertCalledWithJSONBody(t, requests, map[string]any{"name": "John", "age": 43})
+			},
+			expectedMessage: "no call has a JSON body equal to {\"age\":43,\"name\":\"John\"}, the differences are:\n" +
+				"call 0:\n\t$.age: expected 43 got 42\n" +
+				"call 1:\n\t$.age: expected 43 but it is missing\n\t$.name: expected \"John\" got \"Jane\"\n\t$.tags: unexpected [\"a\"]",
+		},
+		{
+			name: "nth call has JSON body",
+			assert: func(t httpregistry.TestingT, requests []*http.Request) bool {
+				return httpregistry.AssertNthCallHasJSONBody(t, requests, 1, map[string]any{"name": "Jane", "tags": []string{"b"}})
+			},
+			expectedMessage: "call 1 does not have a JSON body equal to {\"name\":\"Jane\",\"tags\":[\"b\"]}, the differences are:\n\t$.tags[0]: expected \"b\" got \"a\"",
+		},
+		{
+			name: "called with header",
+			assert: func(t httpregistry.TestingT, requests []*http.Request) bool {
+				return httpregistry.AssertCalledWithHeader(t, requests, "Accept", "text/plain")
+			},
+			expectedMessage: "no call has header \"Accept\" with value \"text/plain\", the values are:\n\tcall 0: [\"application/json\"]\n\tcall 1: [\"text/html\" \"application/xml\"]",
+		},
+		{
+			name: "nth call has header",
+			assert: func(t httpregistry.TestingT, requests []*http.Request) bool {
+				return httpregistry.AssertNthCallHasHeader(t, requests, 0, "Accept", "text/plain")
+			},
+			expectedMessage: "call 0 does not have header \"Accept\" with value \"text/plain\", expected \"text/plain\" got [\"application/json\"]",
+		},
+		{
+			name: "nth call has query param",
+			assert: func(t httpregistry.TestingT, requests []*http.Request) bool {
+				return httpregistry.AssertNthCallHasQueryParam(t, requests, 0, "page", "2")
+			},
+			expectedMessage: "call 0 does not have query parameter \"page\" with value \"2\", expected \"2\" got [\"1\"]",
+		},
+		{
+			name: "nth call does not exist",
+			assert: func(t httpregistry.TestingT, requests []*http.Request) bool {
+				return httpregistry.AssertNthCallHasQueryParam(t, requests, 2, "page", "2")
+			},
+			expectedMessage: "call 2 does not exist, there were 2 calls",
+		},
+	}
+	for _, tc := range testCases {
+		s.Run(tc.name, func() {
+			mockT := httpregistry.NewMockTestingT()
+
+			s.False(tc.assert(mockT, newCapturedRequests()))
+			s.Equal([]string{tc.expectedMessage}, mockT.Messages)
+		})
+	}
+}
@@ -0,0 +1,83 @@
+package httpregistry
+
+import (
+	"net/http"
+	"strings"
+	"time"
+)
+
+// WithETag returns a new response with the header `ETag` set to etag, the quotes are added if etag is not already quoted.
+// A GET or HEAD request whose `If-None-Match` header contains etag is answered with a 304 without body
+//
+//	httpregistry.OkResponse.WithETag("v1")
+//	httpregistry.OkResponse.WithETag(`W/"v1"`)
+func (res Response) WithETag(etag string) Response {
+	if !strings.HasPrefix(etag, `"`) && !strings.HasPrefix(etag, `W/"`) {
+		etag = `"` + etag + `"`
+	}
+	res = res.WithHeader("ETag", etag)
+	res.conditional = true
+	return res
+}
+
+// WithLastModified returns a new response with the header `Last-Modified` set to lastModified.
+// A GET or HEAD request whose `If-Modified-Since` header is not before lastModified is answered with a 304 without body,
+// unless the request also sends `If-None-Match`, which takes precedence
+func (res Response) WithLastModified(lastModified time.Time) Response {
+	res = res.WithHeader("Last-Modified", lastModified.UTC().Format(http.TimeFormat))
+	res.conditional = true
+	return res
+}
+
+// WithCacheControl returns a new response with the header `Cache-Control` set to directives
+//
+//	httpregistry.OkResponse.WithCacheControl("max-age=60, must-revalidate")
+func (res Response) WithCacheControl(directives string) Response {
+	return res.WithHeader("Cache-Control", directives)
+}
+
+// isConditionalRequest returns true if r asks to be answered only if the resource changed
+func isConditionalRequest(r *http.Request) bool {
+	return r.Header.Get("If-None-Match") != "" || r.Header.Get("If-Modified-Since") != ""
+}
+
+// isNotModified returns true if r is a conditional request that is satisfied by the validators in headers,
+// so that it can be answered with a 304
+func isNotModified(r *http.Request, headers http.Header) bool {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return false
+	}
+
+	if ifNoneMatch := r.Header.Get("If-None-Match"); ifNoneMatch != "" {
+		etag := headers.Get("ETag")
+		if etag == "" {
+			return false
+		}
+		for _, candidate := range strings.Split(ifNoneMatch, ",") {
+			candidate = strings.TrimSpace(candidate)
+			if candidate == "*" || weakETag(candidate) == weakETag(etag) {
+				return true
+			}
+		}
+		return false
+	}
+
+	if ifModifiedSince := r.Header.Get("If-Modified-Since"); ifModifiedSince != "" {
+		lastModified, err := http.ParseTime(headers.Get("Last-Modified"))
+		if err != nil {
+			return false
+		}
+		since, err := http.ParseTime(ifModifiedSince)
+		if err != nil {
+			return false
+		}
+		return !lastModified.After(since)
+	}
+
+	return false
+}
+
+// weakETag returns etag without the weak prefix, since If-None-Match uses the weak comparison
+func weakETag(etag string) string {
+	return strings.TrimPrefix(etag, "W/")
+}
@@ -0,0 +1,121 @@
+package httpregistry_test
+
+import (
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/dfioravanti/httpregistry"
+)
+
+// conditionalGet calls url with method and the header header set to value and returns the response and its body
+func (s *TestSuite) conditionalGet(method string, url string, header string, value string) (*http.Response, string) {
+	request, err := http.NewRequest(method, url, nil)
+	s.Require().NoError(err)
+	if header != "" {
+		request.Header.Set(header, value)
+	}
+	res, err := http.DefaultClient.Do(request)
+	s.Require().NoError(err)
+	defer res.Body.Close()
+	body, err := io.ReadAll(res.Body)
+	s.Require().NoError(err)
+	return res, string(body)
+}
+
+func (s *TestSuite) TestETag() {
+	reg := httpregistry.NewRegistry(s.T())
+	reg.AddInfiniteResponse(
+		httpregistry.OkResponse.
+			WithJSONBody(map[string]int{"id": 1}).
+			WithETag("v1").
+			WithCacheControl("max-age=60"),
+	)
+	url := reg.GetServer().URL
+
+	res, body := s.conditionalGet(http.MethodGet, url, "", "")
+	s.Equal(http.StatusOK, res.StatusCode)
+	s.Equal(`"v1"`, res.Header.Get("ETag"))
+	s.Equal("max-age=60", res.Header.Get("Cache-Control"))
+	s.JSONEq(`{"id": 1}`, body)
+
+	for _, ifNoneMatch := range []string{`"v1"`, `W/"v1"`, `"v0", "v1"`, "*"} {
+		res, body = s.conditionalGet(http.MethodGet, url, "If-None-Match", ifNoneMatch)
+		s.Equal(http.StatusNotModified, res.StatusCode, ifNoneMatch)
+		s.Equal(`"v1"`, res.Header.Get("ETag"))
+		s.Equal("max-age=60", res.Header.Get("Cache-Control"))
+		s.Empty(body)
+	}
+
+	res, body = s.conditionalGet(http.MethodGet, url, "If-None-Match", `"v2"`)
+	s.Equal(http.StatusOK, res.StatusCode)
+	s.JSONEq(`{"id": 1}`, body)
+
+	res, _ = s.conditionalGet(http.MethodPost, url, "If-None-Match", `"v1"`)
+	s.Equal(http.StatusOK, res.StatusCode)
+}
+
+func (s *TestSuite) TestLastModified() {
+	lastModified := time.Date(2024, time.January, 1, 12, 0, 0, 0, time.UTC)
+	reg := httpregistry.NewRegistry(s.T())
+	reg.AddInfiniteResponse(httpregistry.OkResponse.WithBody([]byte("hello")).WithLastModified(lastModified))
+	url := reg.GetServer().URL
+
+	res, body := s.conditionalGet(http.MethodGet, url, "", "")
+	s.Equal(http.StatusOK, res.StatusCode)
+	s.Equal("Mon, 01 Jan 2024 12:00:00 GMT", res.Header.Get("Last-Modified"))
+	s.Equal("hello", body)
+
+	res, body = s.conditionalGet(http.MethodGet, url, "If-Modified-Since", "Mon, 01 Jan 2024 12:00:00 GMT")
+	s.Equal(http.StatusNotModified, res.StatusCode)
+	s.Empty(body)
+
+	res, _ = s.conditionalGet(http.MethodGet, url, "If-Modified-Since", "Mon, 01 Jan 2024 11:59:59 GMT")
+	s.Equal(http.StatusOK, res.StatusCode)
+
+	res, _ = s.conditionalGet(http.MethodGet, url, "If-Modified-Since", "yesterday")
+	s.Equal(http.StatusOK, res.StatusCode)
+}
+
+func (s *TestSuite) TestIfNoneMatchTakesPrecedenceOverIfModifiedSince() {
+	reg := httpregistry.NewRegistry(s.T())
+	reg.AddInfiniteResponse(
+		httpregistry.OkResponse.
+			WithETag("v2").
+			WithLastModified(time.Date(2024, time.January, 1, 12, 0, 0, 0, time.UTC)),
+	)
+
+	request, err := http.NewRequest(http.MethodGet, reg.GetServer().URL, nil)
+	s.NoError(err)
+	request.Header.Set("If-None-Match", `"v1"`)
+	request.Header.Set("If-Modified-Since", "Tue, 02 Jan 2024 12:00:00 GMT")
+	res, err := http.DefaultClient.Do(request)
+	s.NoError(err)
+	s.Equal(http.StatusOK, res.StatusCode)
+}
+
+func (s *TestSuite) TestResponsesWithoutValidatorsIgnoreConditionalRequests() {
+	reg := httpregistry.NewRegistry(s.T())
+	reg.AddResponse(httpregistry.OkResponse.WithHeader("ETag", `"v1"`))
+
+	res, _ := s.conditionalGet(http.MethodGet, reg.GetServer().URL, "If-None-Match", `"v1"`)
+	s.Equal(http.StatusOK, res.StatusCode)
+}
+
+func (s *TestSuite) TestJournalRecordsConditionalRequests() {
+	reg := httpregistry.NewRegistry(s.T())
+	reg.AddInfiniteResponse(httpregistry.OkResponse.WithETag("v1"))
+	url := reg.GetServer().URL
+
+	s.conditionalGet(http.MethodGet, url, "", "")
+	s.conditionalGet(http.MethodGet, url, "If-None-Match", `"v1"`)
+	s.conditionalGet(http.MethodGet, url, "If-Modified-Since", "Mon, 01 Jan 2024 12:00:00 GMT")
+
+	journal := reg.GetJournal(httpregistry.NewJournalQuery())
+	s.Len(journal, 3)
+	s.False(journal[0].Conditional)
+	s.True(journal[1].Conditional)
+	s.True(journal[2].Conditional)
+	s.Len(reg.GetJournal(httpregistry.NewJournalQuery().OnlyConditional()), 2)
+	s.Len(reg.GetJournal(httpregistry.NewJournalQuery().OnlyUnconditional()), 1)
+}
@@ -0,0 +1,174 @@
+package httpregistry
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+)
+
+// DecodeJSONBodies decodes the JSON body of each request in requests into a T.
+// It is designed to be used with the requests returned by functions like Registry.GetMatchesForRequest
+//
+//	type user struct {
+//		Name string `json:"name"`
+//	}
+//	users := httpregistry.DecodeJSONBodies[user](t, reg.GetMatchesForRequest(request))
+//
+// If a body cannot be decoded the test is failed and the corresponding element is the zero value of T,
+// so that the i-th element always refers to the i-th request.
+// The bodies of the requests are not consumed, so they can be accessed again.
+func DecodeJSONBodies[T any](t TestingT, requests []*http.Request) []T {
+	return decodeBodies[T](t, requests, "JSON", json.Unmarshal)
+}
+
+// DecodeXMLBodies decodes the XML body of each request in requests into a T.
+// It is designed to be used with the requests returned by functions like Registry.GetMatchesForRequest
+//
+// If a body cannot be decoded the test is failed and the corresponding element is the zero value of T,
+// so that the i-th element always refers to the i-th request.
+// The bodies of the requests are not consumed, so they can be accessed again.
+func DecodeXMLBodies[T any](t TestingT, requests []*http.Request) []T {
+	return decodeBodies[T](t, requests, "XML", xml.Unmarshal)
+}
+
+// DecodeFormBodies decodes the application/x-www-form-urlencoded body of each request in requests into a T.
+// T can be url.Values, map[string]string, map[string][]string or a struct.
+// The fields of a struct are filled with the value of the form field with the same name as the `form` tag, or as the field if no tag is present.
+// Fields can be strings, booleans, numbers or slices of them
+//
+//	type login struct {
+//		User     string `form:"user"`
+//		Remember bool   `form:"remember"`
+//	}
+//	logins := httpregistry.DecodeFormBodies[login](t, reg.GetMatchesForRequest(request))
+//
+// If a body cannot be decoded the test is failed and the corresponding element is the zero value of T,
+// so that the i-th element always refers to the i-th request.
+// The bodies of the requests are not consumed, so they can be accessed again.
+func DecodeFormBodies[T any](t TestingT, requests []*http.Request) []T {
+	return decodeBodies[T](t, requests, "form", func(body []byte, v any) error {
+		values, err := url.ParseQuery(string(body))
+		if err != nil {
+			return err
+		}
+		return decodeForm(values, v)
+	})
+}
+
+// decodeBodies decodes the body of each request in requests into a T using unmarshal, format is used in error messages.
+// The bodies that cannot be decoded are left to the zero value of T, even if unmarshal filled them partially
+func decodeBodies[T any](t TestingT, requests []*http.Request, format string, unmarshal func([]byte, any) error) []T {
+	decoded := make([]T, len(requests))
+	for i, r := range requests {
+		var v T
+		if err := unmarshal(readBody(r), &v); err != nil {
+			t.Errorf("the body of call %d to %v %v cannot be decoded as %v: %v", i, r.Method, r.URL, format, err)
+			continue
+		}
+		decoded[i] = v
+	}
+	return decoded
+}
+
+// decodeForm stores values in the value pointed by v
+func decodeForm(values url.Values, v any) error {
+	switch target := v.(type) {
+	case *url.Values:
+		*target = values
+		return nil
+	case *map[string][]string:
+		*target = values
+		return nil
+	case *map[string]string:
+		*target = make(map[string]string, len(values))
+		for k := range values {
+			(*target)[k] = values.Get(k)
+		}
+		return nil
+	}
+
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("cannot decode a form into %T", v)
+	}
+
+	rv = rv.Elem()
+	for i := 0; i < rv.NumField(); i++ {
+		field := rv.Type().Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name := field.Name
+		if tag, ok := field.Tag.Lookup("form"); ok {
+			name = tag
+		}
+		if name == "-" {
+			continue
+		}
+
+		formValues, found := values[name]
+		if !found || len(formValues) == 0 {
+			continue
+		}
+
+		if err := setFormField(rv.Field(i), formValues); err != nil {
+			return fmt.Errorf("cannot decode form field %q: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// setFormField stores formValues into field.
+// If field is a slice all the values are stored, otherwise only the first one is
+func setFormField(field reflect.Value, formValues []string) error {
+	if field.Kind() == reflect.Slice {
+		slice := reflect.MakeSlice(field.Type(), len(formValues), len(formValues))
+		for i, formValue := range formValues {
+			if err := setFormValue(slice.Index(i), formValue); err != nil {
+				return err
+			}
+		}
+		field.Set(slice)
+		return nil
+	}
+	return setFormValue(field, formValues[0])
+}
+
+// setFormValue parses formValue according to the kind of field and stores it into field
+func setFormValue(field reflect.Value, formValue string) error {
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(formValue)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(formValue)
+		if err != nil {
+			return err
+		}
+		field.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(formValue, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(formValue, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(formValue, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetFloat(f)
+	default:
+		return fmt.Errorf("unsupported type %v", field.Type())
+	}
+	return nil
+}
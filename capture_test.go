@@ -0,0 +1,91 @@
+package httpregistry_test
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+
+	"github.com/dfioravanti/httpregistry"
+)
+
+type capturedUser struct {
+	Name  string   `json:"name" xml:"name" form:"name"`
+	Age   int      `json:"age" xml:"age" form:"age"`
+	Admin bool     `json:"admin" xml:"admin" form:"admin"`
+	Tags  []string `json:"tags" xml:"tag" form:"tag"`
+}
+
+func (s *TestSuite) TestDecodeBodiesOfMatchedRequests() {
+	request := httpregistry.NewRequest().WithMethod(http.MethodPost).WithURL("/users")
+	registry := httpregistry.NewRegistry(s.T())
+	registry.AddRequestWithInfiniteResponse(request, httpregistry.CreatedResponse)
+
+	server := registry.GetServer()
+	defer server.Close()
+
+	for _, body := range []string{`{"name": "John", "age": 42}`, `{"name": "Jane", "admin": true, "tags": ["a"]}`} {
+		res, err := http.Post(server.URL+"/users", "application/json", bytes.NewBufferString(body))
+		s.NoError(err)
+		s.Equal(http.StatusCreated, res.StatusCode)
+	}
+
+	users := httpregistry.DecodeJSONBodies[capturedUser](s.T(), registry.GetMatchesForRequest(request))
+	s.Equal([]capturedUser{
+		{Name: "John", Age: 42},
+		{Name: "Jane", Admin: true, Tags: []string{"a"}},
+	}, users)
+}
+
+func (s *TestSuite) TestDecodeBodies() {
+	newRequest := func(body string) *http.Request {
+		return httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(body))
+	}
+	expected := []capturedUser{{Name: "John", Age: 42, Admin: true, Tags: []string{"a", "b"}}}
+
+	s.Run("xml", func() {
+		requests := []*http.Request{newRequest(`<user><name>John</name><age>42</age><admin>true</admin><tag>a</tag><tag>b</tag></user>`)}
+		s.Equal(expected, httpregistry.DecodeXMLBodies[capturedUser](s.T(), requests))
+	})
+
+	s.Run("form into struct", func() {
+		requests := []*http.Request{newRequest(`name=John&age=42&admin=true&tag=a&tag=b`)}
+		s.Equal(expected, httpregistry.DecodeFormBodies[capturedUser](s.T(), requests))
+	})
+
+	s.Run("form into maps", func() {
+		requests := []*http.Request{newRequest(`name=John&tag=a&tag=b`)}
+		s.Equal(
+			[]url.Values{{"name": {"John"}, "tag": {"a", "b"}}},
+			httpregistry.DecodeFormBodies[url.Values](s.T(), requests),
+		)
+		s.Equal(
+			[]map[string]string{{"name": "John", "tag": "a"}},
+			httpregistry.DecodeFormBodies[map[string]string](s.T(), requests),
+		)
+	})
+
+	s.Run("invalid bodies fail the test", func() {
+		mockT := httpregistry.NewMockTestingT()
+		requests := []*http.Request{newRequest(`{"name": "Jane", "age": `), newRequest(`{"name": "John"}`)}
+
+		users := httpregistry.DecodeJSONBodies[capturedUser](mockT, requests)
+
+		// the body that cannot be decoded keeps its index, and the fields decoded before the error are dropped
+		s.Equal([]capturedUser{{}, {Name: "John"}}, users)
+		s.Equal(1, len(mockT.Messages))
+		s.Contains(mockT.Messages[0], "the body of call 0 to POST /users cannot be decoded as JSON")
+	})
+
+	s.Run("invalid form values fail the test", func() {
+		mockT := httpregistry.NewMockTestingT()
+		requests := []*http.Request{newRequest(`age=old`)}
+
+		users := httpregistry.DecodeFormBodies[capturedUser](mockT, requests)
+
+		s.Equal([]capturedUser{{}}, users)
+		s.Equal(1, len(mockT.Messages))
+		s.Contains(mockT.Messages[0], `cannot decode form field "age"`)
+	})
+}
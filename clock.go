@@ -0,0 +1,147 @@
+package httpregistry
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Clock is the source of time of a Registry.
+// It can be replaced with SetClock so that the time dependent behaviour of the registry is deterministic in tests
+type Clock interface {
+	// Now returns the current time
+	Now() time.Time
+	// After waits for the duration d to elapse and then sends the current time on the returned channel
+	After(d time.Duration) <-chan time.Time
+}
+
+// realClock is the default Clock of a Registry, it returns the real time
+type realClock struct{}
+
+// Now returns time.Now()
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+// After returns time.After(d)
+func (realClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
+// SetClock sets the source of time of the registry, by default the registry uses the real time.
+// The clock is used for the timestamps of the journal and of the retry sequences, the windows of the rate limits,
+// the expiration of the session cookies and by responses that depend on time, like the ones created with
+// Response.WithDelay, Response.WithDateHeader and Response.WithExpires
+//
+//	clock := httpregistry.NewFakeClock(time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC))
+//	reg := httpregistry.NewRegistry(t)
+//	reg.SetClock(clock)
+func (reg *Registry) SetClock(clock Clock) {
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+	reg.clock = clock
+}
+
+// FakeClock is a Clock that only moves when it is told to, it is designed to replace real sleeps in tests.
+// The zero value is not usable, use NewFakeClock
+type FakeClock struct {
+	mu      sync.Mutex
+	changed *sync.Cond
+	now     time.Time
+	waiters []fakeClockWaiter
+}
+
+// fakeClockWaiter is a call to FakeClock.After that is waiting for the clock to reach deadline
+type fakeClockWaiter struct {
+	deadline time.Time
+	c        chan time.Time
+}
+
+// NewFakeClock creates a FakeClock that starts at now
+func NewFakeClock(now time.Time) *FakeClock {
+	c := &FakeClock{now: now}
+	c.changed = sync.NewCond(&c.mu)
+	return c
+}
+
+// Now returns the current time of the clock
+func (c *FakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// After returns a channel that receives the time of the clock once the clock is advanced by at least d
+func (c *FakeClock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	ch := make(chan time.Time, 1)
+	if d <= 0 {
+		ch <- c.now
+		return ch
+	}
+	c.waiters = append(c.waiters, fakeClockWaiter{deadline: c.now.Add(d), c: ch})
+	c.changed.Broadcast()
+	return ch
+}
+
+// Advance moves the clock forward by d and wakes up the calls to After whose duration has elapsed
+func (c *FakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.set(c.now.Add(d))
+}
+
+// Set moves the clock to now, which can also be in the past, and wakes up the calls to After whose duration has elapsed
+func (c *FakeClock) Set(now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.set(now)
+}
+
+// set implements Advance and Set, it must be called while holding the lock
+func (c *FakeClock) set(now time.Time) {
+	c.now = now
+	waiting := c.waiters[:0]
+	for _, waiter := range c.waiters {
+		if waiter.deadline.After(now) {
+			waiting = append(waiting, waiter)
+			continue
+		}
+		waiter.c <- now
+	}
+	c.waiters = waiting
+	c.changed.Broadcast()
+}
+
+// BlockUntilWaiting blocks until at least n calls to After are waiting for the clock to advance.
+// It is used to make sure that, for example, a response created with WithDelay started waiting before advancing the clock
+//
+//	go http.Get(server.URL)
+//	clock.BlockUntilWaiting(1)
+//	clock.Advance(time.Second)
+func (c *FakeClock) BlockUntilWaiting(n int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for len(c.waiters) < n {
+		c.changed.Wait()
+	}
+}
+
+// clockContextKey is the key of the context of a request that contains the clock of the registry that serves it
+type clockContextKey struct{}
+
+// withClock returns a shallow copy of r whose context contains clock, so that the responses can access it
+func withClock(r *http.Request, clock Clock) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), clockContextKey{}, clock))
+}
+
+// clockFromRequest returns the clock of the registry that serves r, or the real clock if r is not served by a registry
+func clockFromRequest(r *http.Request) Clock {
+	if clock, ok := r.Context().Value(clockContextKey{}).(Clock); ok {
+		return clock
+	}
+	return realClock{}
+}
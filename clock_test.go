@@ -0,0 +1,121 @@
+package httpregistry_test
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/dfioravanti/httpregistry"
+)
+
+var fakeNow = time.Date(2024, time.January, 1, 12, 0, 0, 0, time.UTC)
+
+func (s *TestSuite) TestFakeClock() {
+	clock := httpregistry.NewFakeClock(fakeNow)
+	s.Equal(fakeNow, clock.Now())
+
+	immediate := clock.After(0)
+	s.Equal(fakeNow, <-immediate)
+
+	later := clock.After(time.Minute)
+	clock.Advance(30 * time.Second)
+	select {
+	case <-later:
+		s.Fail("the channel fired before the duration elapsed")
+	default:
+	}
+
+	clock.Advance(30 * time.Second)
+	s.Equal(fakeNow.Add(time.Minute), <-later)
+
+	clock.Set(fakeNow)
+	s.Equal(fakeNow, clock.Now())
+}
+
+func (s *TestSuite) TestWithDelayFollowsTheClock() {
+	clock := httpregistry.NewFakeClock(fakeNow)
+	reg := httpregistry.NewRegistry(s.T())
+	reg.SetClock(clock)
+	reg.AddResponse(httpregistry.NoContentResponse.WithDelay(time.Hour))
+	url := reg.GetServer().URL
+
+	statuses := make(chan int, 1)
+	go func() {
+		res, err := http.Get(url)
+		if err != nil {
+			statuses <- 0
+			return
+		}
+		res.Body.Close()
+		statuses <- res.StatusCode
+	}()
+
+	clock.BlockUntilWaiting(1)
+	select {
+	case <-statuses:
+		s.Fail("the response was emitted before the delay elapsed")
+	default:
+	}
+
+	clock.Advance(time.Hour)
+	s.Equal(http.StatusNoContent, <-statuses)
+}
+
+func (s *TestSuite) TestDateAndExpiresHeadersFollowTheClock() {
+	clock := httpregistry.NewFakeClock(fakeNow)
+	reg := httpregistry.NewRegistry(s.T())
+	reg.SetClock(clock)
+	reg.AddInfiniteResponse(httpregistry.NewResponse().WithDateHeader().WithExpires(time.Hour))
+	url := reg.GetServer().URL
+
+	res, err := http.Get(url)
+	s.NoError(err)
+	s.Equal("Mon, 01 Jan 2024 12:00:00 GMT", res.Header.Get("Date"))
+	s.Equal("Mon, 01 Jan 2024 13:00:00 GMT", res.Header.Get("Expires"))
+
+	clock.Advance(24 * time.Hour)
+	res, err = http.Get(url)
+	s.NoError(err)
+	s.Equal("Tue, 02 Jan 2024 12:00:00 GMT", res.Header.Get("Date"))
+	s.Equal("Tue, 02 Jan 2024 13:00:00 GMT", res.Header.Get("Expires"))
+}
+
+func (s *TestSuite) TestJournalAndRetriesFollowTheClock() {
+	clock := httpregistry.NewFakeClock(fakeNow)
+	reg := httpregistry.NewRegistry(s.T())
+	reg.SetClock(clock)
+	retries := reg.AddRetrySequence(
+		httpregistry.NewRequest().WithURL("/users"),
+		1, httpregistry.ServiceUnavailableResponse.WithRetryAfter(time.Minute),
+		httpregistry.OkResponse,
+	)
+	url := reg.GetServer().URL
+
+	status, _ := s.getBody(url + "/users")
+	s.Equal(http.StatusServiceUnavailable, status)
+	clock.Advance(time.Minute)
+	status, _ = s.getBody(url + "/users")
+	s.Equal(http.StatusOK, status)
+
+	s.Equal([]time.Time{fakeNow, fakeNow.Add(time.Minute)}, retries.Attempts())
+	s.True(retries.AssertRetryAfterHonoured(s.T()))
+
+	journal := reg.GetJournal(httpregistry.NewJournalQuery())
+	s.Len(journal, 2)
+	s.Equal(fakeNow, journal[0].Timestamp)
+	s.Equal(fakeNow.Add(time.Minute), journal[1].Timestamp)
+}
+
+func (s *TestSuite) TestSessionCookiesExpireOnTheClock() {
+	clock := httpregistry.NewFakeClock(fakeNow)
+	reg := httpregistry.NewRegistry(s.T())
+	reg.SetClock(clock)
+	reg.EnableSessions()
+	reg.AddRequestWithResponse(
+		httpregistry.NewRequest().WithURL("/login"),
+		httpregistry.NewResponse().WithCookie(&http.Cookie{Name: "session", Value: "abc", Expires: fakeNow.Add(time.Hour)}),
+	)
+
+	status, _ := s.getBody(reg.GetServer().URL + "/login")
+	s.Equal(http.StatusOK, status)
+	s.Len(reg.GetSessionCookies(), 1)
+}
@@ -0,0 +1,85 @@
+// Command httpregistry starts a mock HTTP server that serves the stubs defined in a JSON file.
+// The file is reloaded every time it changes and, on shutdown, a summary of the calls received is printed.
+//
+//	httpregistry -addr :8080 -stubs stubs.json -admin /__admin
+//
+// The format of the stub file is described by httpregistry.StubFile.
+// If -admin is set the registrations can be inspected and changed at runtime with the admin API, see Registry.EnableAdminAPI.
+// The registrations added with the admin API are discarded when the stub file is reloaded.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// shutdownTimeout is how long the server waits for the requests in flight when it is stopped
+const shutdownTimeout = 5 * time.Second
+
+func main() {
+	if err := run(os.Args[1:]); err != nil {
+		fmt.Fprintf(os.Stderr, "httpregistry: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// run parses args, serves the stubs until the process is interrupted and prints the summary of the calls
+func run(args []string) error {
+	flags := flag.NewFlagSet("httpregistry", flag.ContinueOnError)
+	addr := flags.String("addr", ":8080", "the address the server listens on")
+	path := flags.String("stubs", "", "the path of the JSON file that defines the stubs")
+	admin := flags.String("admin", "", "if set, the prefix under which the admin API is served, for example /__admin")
+	poll := flags.Duration("poll", time.Second, "how often the stub file is checked for changes")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *path == "" {
+		return errors.New("the flag -stubs is required")
+	}
+	if *poll <= 0 {
+		return errors.New("the flag -poll must be positive")
+	}
+
+	logger := log.New(os.Stderr, "httpregistry: ", log.LstdFlags)
+	server, err := newStubServer(*path, *admin, logger)
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go server.watch(ctx, *poll)
+
+	httpServer := &http.Server{
+		Addr:              *addr,
+		Handler:           server,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	errs := make(chan error, 1)
+	go func() {
+		logger.Printf("serving %d stubs from %s on %s", server.numberOfStubs(), *path, *addr)
+		errs <- httpServer.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errs:
+		return err
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := httpServer.Shutdown(shutdownCtx); err != nil {
+		return err
+	}
+	server.writeSummary(os.Stdout)
+	return nil
+}
@@ -0,0 +1,271 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"maps"
+	"net/http"
+	"os"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/dfioravanti/httpregistry"
+)
+
+// maxUnmatchedInSummary is the maximum number of unmatched calls listed in the summary, the others are only counted
+const maxUnmatchedInSummary = 100
+
+// journalLimit is the number of requests kept by the registries of the server, so that its memory does not grow with every request
+const journalLimit = 1000
+
+// loadedStubs is a registry created from one version of the stub file
+type loadedStubs struct {
+	registry *httpregistry.Registry
+	stubs    []httpregistry.Stub
+	// registrations are all the registrations that the registry had, including the ones removed through the admin API,
+	// so that their calls are part of the summary
+	registrations []*httpregistry.Registration
+}
+
+// callSummary aggregates the calls served by the stubs and the calls that did not match any stub,
+// it is used instead of the journal of the registries that only contains the last requests
+type callSummary struct {
+	// names are the names of the stubs in the order in which they were first seen
+	names []string
+	calls map[string]int
+	// unmatched contains the method and the URL of the first maxUnmatchedInSummary calls that did not match any stub
+	unmatched      []string
+	unmatchedCalls int
+}
+
+// newCallSummary creates an empty summary
+func newCallSummary() *callSummary {
+	return &callSummary{calls: map[string]int{}}
+}
+
+// clone returns a copy of c that can be modified without affecting c
+func (c *callSummary) clone() *callSummary {
+	return &callSummary{
+		names:          slices.Clone(c.names),
+		calls:          maps.Clone(c.calls),
+		unmatched:      slices.Clone(c.unmatched),
+		unmatchedCalls: c.unmatchedCalls,
+	}
+}
+
+// addName adds name to the stubs of the summary if it is not there already
+func (c *callSummary) addName(name string) {
+	if _, found := c.calls[name]; !found {
+		c.names = append(c.names, name)
+		c.calls[name] = 0
+	}
+}
+
+// addCalls adds to c the calls served by registrations, the calls of stubs with the same name are summed
+func (c *callSummary) addCalls(registrations []*httpregistry.Registration) {
+	for _, registration := range registrations {
+		name := registration.String()
+		c.addName(name)
+		c.calls[name] += registration.NumberOfCalls()
+	}
+}
+
+// addUnmatched adds to c the call r that did not match any stub
+func (c *callSummary) addUnmatched(r *http.Request) {
+	c.unmatchedCalls++
+	if len(c.unmatched) < maxUnmatchedInSummary {
+		c.unmatched = append(c.unmatched, r.Method+" "+r.URL.String())
+	}
+}
+
+// stubServer serves the stubs defined in a file and replaces them every time the file changes
+type stubServer struct {
+	path string
+	// adminPrefix is the prefix of the admin API, if it is empty the admin API is disabled
+	adminPrefix string
+	logger      *log.Logger
+
+	mu      sync.Mutex
+	current *loadedStubs
+	// summary aggregates the calls that did not match any stub and the calls served by the stubs that were replaced by a reload
+	summary *callSummary
+	modTime time.Time
+	size    int64
+}
+
+// logTestingT is the httpregistry.TestingT used by the registries of the server.
+// There is no test to fail, so the failures are only logged
+type logTestingT struct {
+	logger *log.Logger
+}
+
+// Fail does nothing, the server keeps running
+func (t logTestingT) Fail() {}
+
+// Errorf logs the failure
+func (t logTestingT) Errorf(format string, args ...any) {
+	t.logger.Printf(format, args...)
+}
+
+// Logf logs the message
+func (t logTestingT) Logf(format string, args ...any) {
+	t.logger.Printf(format, args...)
+}
+
+// newStubServer creates a server for the stubs defined in the file at path, with the admin API under adminPrefix if it is not empty.
+// It returns an error if the file cannot be read or if it does not contain valid stubs
+func newStubServer(path string, adminPrefix string, logger *log.Logger) (*stubServer, error) {
+	s := &stubServer{path: path, adminPrefix: adminPrefix, logger: logger, summary: newCallSummary()}
+	if _, err := s.reload(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// reload loads again the stub file if it changed since the last time it was loaded.
+// It returns true if the stubs were replaced. If the new file is not valid the previous stubs are kept
+func (s *stubServer) reload() (bool, error) {
+	info, err := os.Stat(s.path)
+	if err != nil {
+		return false, fmt.Errorf("cannot read the stub file: %w", err)
+	}
+
+	s.mu.Lock()
+	changed := s.current == nil || !info.ModTime().Equal(s.modTime) || info.Size() != s.size
+	s.mu.Unlock()
+	if !changed {
+		return false, nil
+	}
+
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		return false, fmt.Errorf("cannot read the stub file: %w", err)
+	}
+	loaded, err := s.load(data)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	// the file is not read again until it changes, even if it is not valid
+	s.modTime, s.size = info.ModTime(), info.Size()
+	if err != nil {
+		return false, err
+	}
+	if s.current != nil {
+		s.summary.addCalls(s.current.rememberRegistrations())
+	}
+	s.current = loaded
+	return true, nil
+}
+
+// load creates a new registry with the stubs defined in data
+func (s *stubServer) load(data []byte) (*loadedStubs, error) {
+	stubs, err := httpregistry.ParseStubs(data)
+	if err != nil {
+		return nil, fmt.Errorf("the stub file %s is not valid: %w", s.path, err)
+	}
+
+	loaded := &loadedStubs{
+		registry: httpregistry.NewRegistry(logTestingT{logger: s.logger}),
+		stubs:    stubs,
+	}
+	loaded.registry.SetJournalLimit(journalLimit)
+	loaded.registry.SetMissHandler(s.serveMiss)
+	if s.adminPrefix != "" {
+		loaded.registry.EnableAdminAPI(s.adminPrefix)
+	}
+	for _, stub := range stubs {
+		if _, err := loaded.registry.AddStub(stub); err != nil {
+			return nil, fmt.Errorf("the stub file %s is not valid: %w", s.path, err)
+		}
+	}
+	loaded.rememberRegistrations()
+	return loaded, nil
+}
+
+// rememberRegistrations adds the current registrations of the registry to the ones of l and returns all of them.
+// It must be called before the registrations can be removed, that is after every call to the admin API
+func (l *loadedStubs) rememberRegistrations() []*httpregistry.Registration {
+	for _, registration := range l.registry.GetRegistrations() {
+		if !slices.Contains(l.registrations, registration) {
+			l.registrations = append(l.registrations, registration)
+		}
+	}
+	return l.registrations
+}
+
+// serveMiss counts the call r that did not match any stub and answers it like the registry does by default
+func (s *stubServer) serveMiss(w http.ResponseWriter, r *http.Request, report httpregistry.MissReport) {
+	s.mu.Lock()
+	s.summary.addUnmatched(r)
+	s.mu.Unlock()
+
+	httpregistry.ServeMissReport(w, r, report)
+}
+
+// watch checks every interval if the stub file changed and reloads it, it returns when ctx is done
+func (s *stubServer) watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			reloaded, err := s.reload()
+			switch {
+			case err != nil:
+				s.logger.Printf("%v, the previous stubs are still served", err)
+			case reloaded:
+				s.logger.Printf("reloaded %d stubs from %s", s.numberOfStubs(), s.path)
+			}
+		}
+	}
+}
+
+// numberOfStubs returns the number of stubs currently served
+func (s *stubServer) numberOfStubs() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.current.stubs)
+}
+
+// ServeHTTP serves r with the stubs currently loaded
+func (s *stubServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	current := s.current
+	s.mu.Unlock()
+
+	current.registry.Handler().ServeHTTP(w, r)
+
+	// the admin API can add and remove registrations, the removed ones must still be part of the summary
+	if s.adminPrefix != "" {
+		s.mu.Lock()
+		current.rememberRegistrations()
+		s.mu.Unlock()
+	}
+}
+
+// writeSummary writes to w how many times each stub was called and the requests that did not match any stub.
+// The calls of stubs with the same name are summed across reloads
+func (s *stubServer) writeSummary(w io.Writer) {
+	s.mu.Lock()
+	summary := s.summary.clone()
+	summary.addCalls(s.current.rememberRegistrations())
+	s.mu.Unlock()
+
+	fmt.Fprintln(w, "calls per stub:")
+	for _, name := range summary.names {
+		fmt.Fprintf(w, "\t%s: %d\n", name, summary.calls[name])
+	}
+	fmt.Fprintf(w, "unmatched calls: %d\n", summary.unmatchedCalls)
+	for _, call := range summary.unmatched {
+		fmt.Fprintf(w, "\t%s\n", call)
+	}
+	if summary.unmatchedCalls > len(summary.unmatched) {
+		fmt.Fprintf(w, "\t... and %d more\n", summary.unmatchedCalls-len(summary.unmatched))
+	}
+}
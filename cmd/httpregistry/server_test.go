@@ -0,0 +1,232 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dfioravanti/httpregistry"
+	"github.com/stretchr/testify/suite"
+)
+
+type TestSuite struct {
+	suite.Suite
+	path string
+	logs *bytes.Buffer
+}
+
+func TestRunSuite(t *testing.T) {
+	suite.Run(t, new(TestSuite))
+}
+
+func (s *TestSuite) SetupTest() {
+	s.path = filepath.Join(s.T().TempDir(), "stubs.json")
+	s.logs = &bytes.Buffer{}
+}
+
+// writeStubs writes data to the stub file, the modification time is moved forward so that the change is always noticed
+func (s *TestSuite) writeStubs(data string) {
+	s.NoError(os.WriteFile(s.path, []byte(data), 0o600))
+	info, err := os.Stat(s.path)
+	s.NoError(err)
+	later := info.ModTime().Add(time.Second)
+	s.NoError(os.Chtimes(s.path, later, later))
+}
+
+func (s *TestSuite) newServer() (*stubServer, *httptest.Server) {
+	server, err := newStubServer(s.path, "", log.New(s.logs, "", 0))
+	s.Require().NoError(err)
+	httpServer := httptest.NewServer(server)
+	s.T().Cleanup(httpServer.Close)
+	return server, httpServer
+}
+
+func (s *TestSuite) get(url string) (int, string) {
+	res, err := http.Get(url)
+	s.Require().NoError(err)
+	defer res.Body.Close()
+	body, err := io.ReadAll(res.Body)
+	s.NoError(err)
+	return res.StatusCode, string(body)
+}
+
+func (s *TestSuite) TestServesStubs() {
+	s.writeStubs(`{"stubs": [{"request": {"method": "GET", "url": "/users"}, "responses": [{"body": "John"}], "infinite": true}]}`)
+	_, httpServer := s.newServer()
+
+	status, body := s.get(httpServer.URL + "/users")
+	s.Equal(http.StatusOK, status)
+	s.Equal("John", body)
+}
+
+func (s *TestSuite) TestInvalidFileFailsOnStart() {
+	s.writeStubs(`{"stubs": [{"request": {"url": "("}}]}`)
+	_, err := newStubServer(s.path, "", log.New(s.logs, "", 0))
+	s.ErrorContains(err, "the URL is not a valid regex")
+
+	_, err = newStubServer(filepath.Join(s.T().TempDir(), "missing.json"), "", log.New(s.logs, "", 0))
+	s.ErrorContains(err, "cannot read the stub file")
+}
+
+func (s *TestSuite) TestReloadsWhenTheFileChanges() {
+	s.writeStubs(`{"stubs": [{"request": {"url": "/users"}, "responses": [{"body": "John"}], "infinite": true}]}`)
+	server, httpServer := s.newServer()
+
+	reloaded, err := server.reload()
+	s.NoError(err)
+	s.False(reloaded)
+
+	s.writeStubs(`{"stubs": [{"request": {"url": "/users"}, "responses": [{"body": "Jane"}], "infinite": true}]}`)
+	reloaded, err = server.reload()
+	s.NoError(err)
+	s.True(reloaded)
+
+	_, body := s.get(httpServer.URL + "/users")
+	s.Equal("Jane", body)
+}
+
+func (s *TestSuite) TestInvalidFileKeepsThePreviousStubs() {
+	s.writeStubs(`{"stubs": [{"request": {"url": "/users"}, "responses": [{"body": "John"}], "infinite": true}]}`)
+	server, httpServer := s.newServer()
+
+	s.writeStubs(`{"stubs": [`)
+	reloaded, err := server.reload()
+	s.ErrorContains(err, "the stubs are not valid JSON")
+	s.False(reloaded)
+
+	_, body := s.get(httpServer.URL + "/users")
+	s.Equal("John", body)
+}
+
+func (s *TestSuite) TestWatchReloadsTheStubs() {
+	s.writeStubs(`{"stubs": [{"request": {"url": "/users"}, "responses": [{"body": "John"}], "infinite": true}]}`)
+	server, httpServer := s.newServer()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go server.watch(ctx, 10*time.Millisecond)
+
+	s.writeStubs(`{"stubs": [{"request": {"url": "/users"}, "responses": [{"body": "Jane"}], "infinite": true}]}`)
+	s.Eventually(func() bool {
+		_, body := s.get(httpServer.URL + "/users")
+		return body == "Jane"
+	}, time.Second, 10*time.Millisecond)
+}
+
+func (s *TestSuite) TestSummary() {
+	s.writeStubs(`{"stubs": [
+		{"name": "users", "request": {"url": "/users"}, "infinite": true},
+		{"request": {"method": "GET", "url": "/orders"}, "infinite": true}
+	]}`)
+	server, httpServer := s.newServer()
+
+	s.get(httpServer.URL + "/users")
+	s.get(httpServer.URL + "/users")
+
+	s.writeStubs(`{"stubs": [{"name": "users", "request": {"url": "/users"}, "infinite": true}]}`)
+	_, err := server.reload()
+	s.NoError(err)
+
+	s.get(httpServer.URL + "/users")
+	status, _ := s.get(httpServer.URL + "/orders")
+	s.Equal(http.StatusInternalServerError, status)
+
+	summary := &bytes.Buffer{}
+	server.writeSummary(summary)
+	s.Equal("calls per stub:\n\tusers: 3\n\tGET /orders: 0\nunmatched calls: 1\n\tGET /orders\n", summary.String())
+	s.Contains(s.logs.String(), "no registered request matched")
+}
+
+func (s *TestSuite) TestAdminAPI() {
+	s.writeStubs(`{"stubs": []}`)
+	server, err := newStubServer(s.path, "/__admin", log.New(s.logs, "", 0))
+	s.Require().NoError(err)
+	httpServer := httptest.NewServer(server)
+	defer httpServer.Close()
+
+	res, err := http.Post(httpServer.URL+"/__admin/stubs", "application/json", strings.NewReader(`{"request": {"url": "/users"}, "responses": [{"body": "John"}]}`))
+	s.NoError(err)
+	s.Equal(http.StatusCreated, res.StatusCode)
+
+	_, body := s.get(httpServer.URL + "/users")
+	s.Equal("John", body)
+}
+
+func (s *TestSuite) TestSummaryContainsTheStubsAddedWithTheAdminAPI() {
+	s.writeStubs(`{"stubs": [{"name": "users", "request": {"url": "/users"}, "infinite": true}]}`)
+	server, err := newStubServer(s.path, "/__admin", log.New(s.logs, "", 0))
+	s.Require().NoError(err)
+	httpServer := httptest.NewServer(server)
+	defer httpServer.Close()
+
+	for _, stub := range []string{
+		`{"name": "orders", "request": {"url": "/orders"}, "infinite": true}`,
+		`{"name": "unused", "request": {"url": "/unused"}}`,
+	} {
+		res, err := http.Post(httpServer.URL+"/__admin/stubs", "application/json", strings.NewReader(stub))
+		s.NoError(err)
+		s.Equal(http.StatusCreated, res.StatusCode)
+	}
+	s.get(httpServer.URL + "/orders")
+	s.get(httpServer.URL + "/orders")
+
+	// the calls of a removed stub are still counted
+	request, err := http.NewRequest(http.MethodDelete, httpServer.URL+"/__admin/stubs/2", nil)
+	s.NoError(err)
+	res, err := http.DefaultClient.Do(request)
+	s.NoError(err)
+	s.Equal(http.StatusNoContent, res.StatusCode)
+
+	summary := &bytes.Buffer{}
+	server.writeSummary(summary)
+	s.Equal("calls per stub:\n\tusers: 0\n\torders: 2\n\tunused: 0\nunmatched calls: 0\n", summary.String())
+}
+
+func (s *TestSuite) TestSummaryListsALimitedNumberOfUnmatchedCalls() {
+	s.writeStubs(`{"stubs": []}`)
+	server, httpServer := s.newServer()
+
+	for range maxUnmatchedInSummary + 2 {
+		s.get(httpServer.URL + "/missing")
+	}
+	s.writeStubs(`{"stubs": [{"request": {"url": "/users"}}]}`)
+	_, err := server.reload()
+	s.NoError(err)
+	s.get(httpServer.URL + "/missing")
+
+	summary := &bytes.Buffer{}
+	server.writeSummary(summary)
+	s.Equal(
+		"calls per stub:\n\t/users: 0\nunmatched calls: 103\n"+
+			strings.Repeat("\tGET /missing\n", maxUnmatchedInSummary)+
+			"\t... and 3 more\n",
+		summary.String(),
+	)
+}
+
+func (s *TestSuite) TestTheRequestsKeptByTheServerAreLimited() {
+	s.writeStubs(`{"stubs": [{"name": "users", "request": {"url": "/users"}, "infinite": true}]}`)
+	server, httpServer := s.newServer()
+
+	for range journalLimit + 1 {
+		s.get(httpServer.URL + "/users")
+	}
+	s.get(httpServer.URL + "/missing")
+
+	registry := server.current.registry
+	s.Len(registry.GetJournal(httpregistry.NewJournalQuery()), journalLimit)
+	s.Len(registry.GetRegistrations()[0].Matches(), journalLimit)
+
+	summary := &bytes.Buffer{}
+	server.writeSummary(summary)
+	s.Equal(fmt.Sprintf("calls per stub:\n\tusers: %d\nunmatched calls: 1\n\tGET /missing\n", journalLimit+1), summary.String())
+}
@@ -0,0 +1,255 @@
+package httpregistry
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// cookieRule defines how the value of a cookie of an incoming request is compared with the expected value
+type cookieRule string
+
+// These constants are the rules that can be used to match a cookie, they are also used to describe the expected value in the reports
+const (
+	cookieEquals  = cookieRule("equal to")
+	cookieMatches = cookieRule("matching")
+	cookiePresent = cookieRule("present")
+)
+
+// cookieCriterion is a condition that a registered Request places on a cookie of an incoming request.
+// If the cookie is sent multiple times, the criterion is satisfied if any of the values does.
+type cookieCriterion struct {
+	name  string
+	rule  cookieRule
+	value string
+	// regex is the compiled version of value for cookieMatches, it is compiled once when the criterion is created
+	regex *regexp.Regexp
+	// fromSession is true if the criterion was added by the session store and not by the user
+	fromSession bool
+}
+
+// expected returns the human readable version of what the criterion expects
+func (c cookieCriterion) expected() string {
+	switch c.rule {
+	case cookieEquals:
+		return c.value
+	case cookiePresent:
+		return string(c.rule)
+	default:
+		return fmt.Sprintf("%s %q", c.rule, c.value)
+	}
+}
+
+// failure returns the human readable explanation of why the criterion is not satisfied
+func (c cookieCriterion) failure() string {
+	switch c.rule {
+	case cookieEquals:
+		return fmt.Sprintf("cookie %q is not equal to %q", c.name, c.value)
+	case cookiePresent:
+		return fmt.Sprintf("cookie %q is not present", c.name)
+	default:
+		return fmt.Sprintf("cookie %q does not match %q", c.name, c.value)
+	}
+}
+
+// evaluate checks the criterion against the cookies of the incoming request
+func (c cookieCriterion) evaluate(cookies []*http.Cookie) criterionResult {
+	values := []string{}
+	for _, cookie := range cookies {
+		if cookie.Name == c.name {
+			values = append(values, cookie.Value)
+		}
+	}
+
+	matched := false
+	switch c.rule {
+	case cookiePresent:
+		matched = len(values) > 0
+	case cookieEquals:
+		for _, v := range values {
+			matched = matched || v == c.value
+		}
+	case cookieMatches:
+		for _, v := range values {
+			matched = matched || c.regex.MatchString(v)
+		}
+	}
+
+	name, why := fmt.Sprintf("cookie %q", c.name), cookieDoesNotMatch
+	if c.fromSession {
+		name, why = fmt.Sprintf("session cookie %q", c.name), sessionCookieNotSent
+	}
+	result := newCriterionResult(name, c.expected(), strings.Join(values, ", "), matched, why)
+	result.missDetail = c.failure()
+	return result
+}
+
+// withCookieCriterion returns a copy of criteria with criterion added, the criteria with the same cookie name and rule are replaced.
+// The criteria are kept sorted so that two requests with the same criteria are equal independently of the order in which they were added
+func withCookieCriterion(criteria []cookieCriterion, criterion cookieCriterion) []cookieCriterion {
+	newCriteria := make([]cookieCriterion, 0, len(criteria)+1)
+	for _, c := range criteria {
+		if c.name == criterion.name && c.rule == criterion.rule {
+			continue
+		}
+		newCriteria = append(newCriteria, c)
+	}
+	newCriteria = append(newCriteria, criterion)
+
+	sort.SliceStable(newCriteria, func(i, j int) bool {
+		if newCriteria[i].name != newCriteria[j].name {
+			return newCriteria[i].name < newCriteria[j].name
+		}
+		return newCriteria[i].rule < newCriteria[j].rule
+	})
+	return newCriteria
+}
+
+// sessionStore simulates the cookie jar of a client.
+// It records the cookies set by the responses served by the registry and
+// it requires the following requests to send them back.
+type sessionStore struct {
+	cookies map[string]http.Cookie
+}
+
+// newSessionStore creates an empty sessionStore
+func newSessionStore() *sessionStore {
+	return &sessionStore{cookies: map[string]http.Cookie{}}
+}
+
+// record stores the cookies set via Set-Cookie in header.
+// Cookies that are expired at now, or that have a negative Max-Age, are removed from the store
+func (s *sessionStore) record(header http.Header, now time.Time) {
+	if s == nil {
+		return
+	}
+
+	response := http.Response{Header: header}
+	for _, cookie := range response.Cookies() {
+		expired := cookie.MaxAge < 0 || (!cookie.Expires.IsZero() && cookie.Expires.Before(now))
+		if expired {
+			delete(s.cookies, cookie.Name)
+			continue
+		}
+		s.cookies[cookie.Name] = *cookie
+	}
+}
+
+// apply returns request with an additional criterion for each cookie in the store.
+// Cookies on which the request already places a criterion, for example via WithCookie, are skipped so that the criterion of the request wins.
+// If the store is nil or the request opted out of the session, request is returned unchanged
+func (s *sessionStore) apply(request Request) Request {
+	if s == nil || request.withoutSession {
+		return request
+	}
+
+	for _, cookie := range s.cookies {
+		if hasCookieCriterion(request.cookies, cookie.Name) {
+			continue
+		}
+		request.cookies = withCookieCriterion(request.cookies, cookieCriterion{
+			name:        cookie.Name,
+			rule:        cookieEquals,
+			value:       cookie.Value,
+			fromSession: true,
+		})
+	}
+	return request
+}
+
+// hasCookieCriterion returns true if criteria contain a criterion on the cookie name
+func hasCookieCriterion(criteria []cookieCriterion, name string) bool {
+	for _, c := range criteria {
+		if c.name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// sessionRecorder is a http.ResponseWriter that records in the session store of the registry the cookies set by a response.
+// The cookies are recorded when the header is written so that they are already in the store when the client receives the response
+type sessionRecorder struct {
+	http.ResponseWriter
+	reg         *Registry
+	wroteHeader bool
+}
+
+// WriteHeader records the cookies in the header and then writes it
+func (s *sessionRecorder) WriteHeader(statusCode int) {
+	if !s.wroteHeader {
+		s.wroteHeader = true
+		s.reg.mu.Lock()
+		s.reg.sessions.record(s.Header(), s.reg.clock.Now())
+		s.reg.mu.Unlock()
+	}
+	s.ResponseWriter.WriteHeader(statusCode)
+}
+
+// Write writes b making sure that the cookies are recorded first
+func (s *sessionRecorder) Write(b []byte) (int, error) {
+	if !s.wroteHeader {
+		s.WriteHeader(http.StatusOK)
+	}
+	return s.ResponseWriter.Write(b)
+}
+
+// Flush sends any buffered data to the client, if the original http.ResponseWriter supports it
+func (s *sessionRecorder) Flush() {
+	if !s.wroteHeader {
+		s.WriteHeader(http.StatusOK)
+	}
+	if flusher, ok := s.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Unwrap returns the original http.ResponseWriter so that http.ResponseController can access it
+func (s *sessionRecorder) Unwrap() http.ResponseWriter {
+	return s.ResponseWriter
+}
+
+// EnableSessions makes the registry simulate the cookie jar of a client.
+// Once a served response sets a cookie, every following request must send that cookie back with the same value in order to match,
+// until a response deletes the cookie with a negative Max-Age or an expiration date in the past.
+// Requests that must match independently of the session, for example a login, can opt out via Request.WithoutSession
+//
+//	reg := httpregistry.NewRegistry(t)
+//	reg.EnableSessions()
+//	reg.AddRequestWithResponse(
+//		httpregistry.NewRequest().WithMethod(http.MethodPost).WithURL("/login").WithoutSession(),
+//		httpregistry.NewResponse().WithCookie(&http.Cookie{Name: "session", Value: "abc"}),
+//	)
+//	reg.AddMethodAndURL(http.MethodGet, "/profile")
+//
+// will create a http server where GET "/profile" matches only after the login and only if the cookie session=abc is sent.
+// If a request places its own criterion on a cookie of the session, for example with WithCookie, that criterion is used instead of the value in the session
+func (reg *Registry) EnableSessions() {
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+	if reg.sessions == nil {
+		reg.sessions = newSessionStore()
+	}
+}
+
+// GetSessionCookies returns the cookies that are currently in the session store, sorted by name.
+// If sessions are not enabled it returns an empty slice
+func (reg *Registry) GetSessionCookies() []http.Cookie {
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+
+	cookies := []http.Cookie{}
+	if reg.sessions == nil {
+		return cookies
+	}
+	for _, cookie := range reg.sessions.cookies {
+		cookies = append(cookies, cookie)
+	}
+	sort.Slice(cookies, func(i, j int) bool {
+		return cookies[i].Name < cookies[j].Name
+	})
+	return cookies
+}
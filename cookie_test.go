@@ -0,0 +1,246 @@
+package httpregistry_test
+
+import (
+	"net/http"
+	"net/http/cookiejar"
+	"strings"
+
+	"github.com/dfioravanti/httpregistry"
+)
+
+func (s *TestSuite) TestCookieRules() {
+	testCases := []struct {
+		name        string
+		request     httpregistry.Request
+		cookies     []*http.Cookie
+		shouldMatch bool
+		expectedWhy string
+	}{
+		{
+			name:        "exact",
+			request:     httpregistry.NewRequest().WithCookie("session", "abc"),
+			cookies:     []*http.Cookie{{Name: "other", Value: "x"}, {Name: "session", Value: "abc"}},
+			shouldMatch: true,
+		},
+		{
+			name:        "exact fails",
+			request:     httpregistry.NewRequest().WithCookie("session", "abc"),
+			cookies:     []*http.Cookie{{Name: "session", Value: "xyz"}},
+			expectedWhy: `mock request #1 missed because the cookie does not match: cookie "session" is not equal to "abc"`,
+		},
+		{
+			name:        "regex",
+			request:     httpregistry.NewRequest().WithCookieMatching("session", "^[a-c]+$"),
+			cookies:     []*http.Cookie{{Name: "session", Value: "abc"}},
+			shouldMatch: true,
+		},
+		{
+			name:        "regex fails",
+			request:     httpregistry.NewRequest().WithCookieMatching("session", "^[a-c]+$"),
+			cookies:     []*http.Cookie{{Name: "session", Value: "xyz"}},
+			expectedWhy: `mock request #1 missed because the cookie does not match: cookie "session" does not match "^[a-c]+$"`,
+		},
+		{
+			name:        "present",
+			request:     httpregistry.NewRequest().WithCookiePresent("session"),
+			cookies:     []*http.Cookie{{Name: "session", Value: ""}},
+			shouldMatch: true,
+		},
+		{
+			name:        "present fails",
+			request:     httpregistry.NewRequest().WithCookiePresent("session"),
+			cookies:     []*http.Cookie{},
+			expectedWhy: `mock request #1 missed because the cookie does not match: cookie "session" is not present`,
+		},
+	}
+	for _, tc := range testCases {
+		s.Run(tc.name, func() {
+			mockT := httpregistry.NewMockTestingT()
+			registry := httpregistry.NewRegistry(mockT)
+			registry.AddRequest(tc.request)
+
+			server := registry.GetServer()
+			defer server.Close()
+
+			request, err := http.NewRequest(http.MethodGet, server.URL, nil)
+			s.NoError(err)
+			for _, cookie := range tc.cookies {
+				request.AddCookie(cookie)
+			}
+
+			res, err := http.DefaultClient.Do(request)
+			s.NoError(err)
+
+			if tc.shouldMatch {
+				s.Equal(http.StatusOK, res.StatusCode)
+				s.False(mockT.HasFailed)
+				return
+			}
+			s.Equal(http.StatusInternalServerError, res.StatusCode)
+			s.Equal(tc.expectedWhy, registry.Why())
+		})
+	}
+}
+
+func (s *TestSuite) TestSessionRequiresTheCookiesSetByPreviousResponses() {
+	mockT := httpregistry.NewMockTestingT()
+	registry := httpregistry.NewRegistry(mockT)
+	registry.EnableSessions()
+	registry.AddRequestWithResponse(
+		httpregistry.NewRequest().WithMethod(http.MethodPost).WithURL("/login").WithoutSession(),
+		httpregistry.NewResponse().WithCookie(&http.Cookie{Name: "session", Value: "abc", Path: "/"}),
+	)
+	registry.AddMethodAndURL(http.MethodGet, "/profile")
+	registry.AddMethodAndURL(http.MethodGet, "/profile")
+
+	server := registry.GetServer()
+	defer server.Close()
+
+	jar, err := cookiejar.New(nil)
+	s.NoError(err)
+	client := &http.Client{Jar: jar}
+
+	res, err := client.Post(server.URL+"/login", "text/plain", nil)
+	s.NoError(err)
+	s.Equal(http.StatusOK, res.StatusCode)
+	s.Equal([]http.Cookie{{Name: "session", Value: "abc", Path: "/", Raw: "session=abc; Path=/"}}, registry.GetSessionCookies())
+
+	res, err = client.Get(server.URL + "/profile")
+	s.NoError(err)
+	s.Equal(http.StatusOK, res.StatusCode)
+	s.False(mockT.HasFailed)
+
+	// a client without the cookie is not part of the session
+	res, err = http.Get(server.URL + "/profile")
+	s.NoError(err)
+	s.Equal(http.StatusInternalServerError, res.StatusCode)
+	s.Contains(registry.Why(), `missed because the session cookie was not sent: cookie "session" is not equal to "abc"`)
+}
+
+func (s *TestSuite) TestSessionCookieCanBeDeleted() {
+	mockT := httpregistry.NewMockTestingT()
+	registry := httpregistry.NewRegistry(mockT)
+	registry.EnableSessions()
+	registry.AddRequestWithResponse(
+		httpregistry.NewRequest().WithURL("/login").WithoutSession(),
+		httpregistry.NewResponse().WithCookie(&http.Cookie{Name: "session", Value: "abc"}),
+	)
+	registry.AddRequestWithResponse(
+		httpregistry.NewRequest().WithURL("/logout"),
+		httpregistry.NewResponse().WithCookie(&http.Cookie{Name: "session", MaxAge: -1}),
+	)
+	registry.AddRequest(httpregistry.NewRequest().WithURL("/public"))
+
+	server := registry.GetServer()
+	defer server.Close()
+
+	_, err := http.Get(server.URL + "/login")
+	s.NoError(err)
+	s.Len(registry.GetSessionCookies(), 1)
+
+	request, err := http.NewRequest(http.MethodGet, server.URL+"/logout", nil)
+	s.NoError(err)
+	request.AddCookie(&http.Cookie{Name: "session", Value: "abc"})
+	res, err := http.DefaultClient.Do(request)
+	s.NoError(err)
+	s.Equal(http.StatusOK, res.StatusCode)
+	s.Empty(registry.GetSessionCookies())
+
+	res, err = http.Get(server.URL + "/public")
+	s.NoError(err)
+	s.Equal(http.StatusOK, res.StatusCode)
+	s.False(mockT.HasFailed)
+}
+
+func (s *TestSuite) TestSessionsAreDisabledByDefault() {
+	mockT := httpregistry.NewMockTestingT()
+	registry := httpregistry.NewRegistry(mockT)
+	registry.AddRequestWithResponse(
+		httpregistry.NewRequest().WithURL("/login"),
+		httpregistry.NewResponse().WithCookie(&http.Cookie{Name: "session", Value: "abc"}),
+	)
+	registry.AddRequest(httpregistry.NewRequest().WithURL("/profile"))
+
+	server := registry.GetServer()
+	defer server.Close()
+
+	_, err := http.Get(server.URL + "/login")
+	s.NoError(err)
+	res, err := http.Get(server.URL + "/profile")
+	s.NoError(err)
+	s.Equal(http.StatusOK, res.StatusCode)
+	s.Empty(registry.GetSessionCookies())
+	s.False(mockT.HasFailed)
+}
+
+func (s *TestSuite) TestSessionCookiesSetInAJSONRPCBatchAreSentToTheClient() {
+	mockT := httpregistry.NewMockTestingT()
+	registry := httpregistry.NewRegistry(mockT)
+	registry.EnableSessions()
+	registry.AddRequestWithResponse(
+		httpregistry.NewRequest().WithJSONRPCMethod("login").WithoutSession(),
+		httpregistry.NewResponse().WithJSONRPCResult(true).WithCookie(&http.Cookie{Name: "session", Value: "abc", Path: "/"}),
+	)
+	registry.AddRequestWithResponse(
+		httpregistry.NewRequest().WithJSONRPCMethod("ping").WithoutSession(),
+		httpregistry.NewResponse().WithJSONRPCResult("pong").WithCookie(&http.Cookie{Name: "theme", Value: "dark", Path: "/"}),
+	)
+	registry.AddRequestWithResponse(
+		httpregistry.NewRequest().WithJSONRPCMethod("profile"),
+		httpregistry.NewResponse().WithJSONRPCResult("John"),
+	)
+
+	server := registry.GetServer()
+	defer server.Close()
+
+	jar, err := cookiejar.New(nil)
+	s.NoError(err)
+	client := &http.Client{Jar: jar}
+
+	batch := `[{"jsonrpc": "2.0", "method": "login", "id": 1}, {"jsonrpc": "2.0", "method": "ping", "id": 2}]`
+	res, err := client.Post(server.URL, "application/json", strings.NewReader(batch))
+	s.NoError(err)
+	s.Equal(http.StatusOK, res.StatusCode)
+	s.Equal([]string{"session=abc; Path=/", "theme=dark; Path=/"}, res.Header.Values("Set-Cookie"))
+	s.Len(registry.GetSessionCookies(), 2)
+
+	res, err = client.Post(server.URL, "application/json", strings.NewReader(`{"jsonrpc": "2.0", "method": "profile", "id": 3}`))
+	s.NoError(err)
+	s.Equal(http.StatusOK, res.StatusCode)
+	s.False(mockT.HasFailed)
+}
+
+func (s *TestSuite) TestExplicitCookieCriteriaWinOverTheSession() {
+	mockT := httpregistry.NewMockTestingT()
+	registry := httpregistry.NewRegistry(mockT)
+	registry.EnableSessions()
+	registry.AddRequestWithResponse(
+		httpregistry.NewRequest().WithURL("/login").WithoutSession(),
+		httpregistry.NewResponse().WithCookie(&http.Cookie{Name: "session", Value: "abc"}),
+	)
+	stale := registry.AddRequest(httpregistry.NewRequest().WithURL("/profile").WithCookie("session", "expired"))
+
+	server := registry.GetServer()
+	defer server.Close()
+
+	_, err := http.Get(server.URL + "/login")
+	s.NoError(err)
+
+	call := func(value string) int {
+		request, err := http.NewRequest(http.MethodGet, server.URL+"/profile", nil)
+		s.NoError(err)
+		request.AddCookie(&http.Cookie{Name: "session", Value: value})
+		res, err := http.DefaultClient.Do(request)
+		s.NoError(err)
+		return res.StatusCode
+	}
+
+	s.Equal(http.StatusInternalServerError, call("abc"))
+	s.Equal(
+		"mock request #1 missed because the path does not match\n"+
+			`mock request #2 missed because the cookie does not match: cookie "session" is not equal to "expired"`,
+		registry.Why(),
+	)
+	s.Equal(http.StatusOK, call("expired"))
+	s.Equal(1, stale.NumberOfCalls())
+}
@@ -0,0 +1,135 @@
+package httpregistry
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// criterionResult represents the outcome of checking one of the conditions that a registered Request places on an incoming request.
+// For example if the registered request is
+//
+//	NewRequest().WithMethod(http.MethodGet).WithURL("/users")
+//
+// then an incoming request is evaluated against two criteria, one for the method and one for the path.
+type criterionResult struct {
+	// name identifies the criterion, for example "method" or `header "Accept"`
+	name string
+	// expected is the human readable version of what the registered request expects
+	expected string
+	// actual is the human readable version of what the incoming request contains
+	actual string
+	// matched is true if the incoming request satisfies the criterion
+	matched bool
+	// why is the reason reported in a miss if the criterion is not matched
+	why whyMissed
+	// similarity is a number between 0 and 1 that expresses how close actual is to expected, 1 means identical
+	similarity float64
+	// details contains additional lines that explain the difference, for example a diff of JSON bodies
+	details []string
+	// missDetail is added to the miss reported when the criterion is not matched to explain which part of the request did not match
+	missDetail string
+}
+
+// newCriterionResult creates a criterionResult and computes the similarity between expected and actual
+func newCriterionResult(name string, expected string, actual string, matched bool, why whyMissed) criterionResult {
+	similarity := 1.0
+	if !matched {
+		similarity = stringSimilarity(expected, actual)
+	}
+	return criterionResult{
+		name:       name,
+		expected:   expected,
+		actual:     actual,
+		matched:    matched,
+		why:        why,
+		similarity: similarity,
+	}
+}
+
+// evaluateRequest checks the incoming request r, whose body was already read into body, against all the criteria defined by request.
+// Criteria that are left to their default value are not evaluated, so the default request produces no criteria at all
+func evaluateRequest(request Request, r *http.Request, body []byte) []criterionResult {
+	results := []criterionResult{}
+
+	// if the request contains the default values then there is no point in saying that something was missed
+	if request.url != "" {
+		actual := r.URL.String()
+		results = append(results, newCriterionResult(
+			"path", request.url, actual, request.urlAsRegex.MatchString(actual), pathDoesNotMatch,
+		))
+	}
+
+	if request.method != "" {
+		results = append(results, newCriterionResult(
+			"method", request.method, r.Method, request.method == r.Method, methodDoesNotMatch,
+		))
+	}
+
+	if len(request.body) > 0 {
+		result := newCriterionResult(
+			"body", string(request.body), string(body), bytes.Equal(request.body, body), bodyDoesNotMatch,
+		)
+		if !result.matched {
+			result.details = bodyDiff(request.body, body)
+		}
+		results = append(results, result)
+	}
+
+	for _, matcher := range request.bodyMatchers {
+		results = append(results, evaluateBodyMatcher(matcher, r, body))
+	}
+
+	for _, criterion := range request.jsonrpc {
+		results = append(results, criterion.evaluate(body))
+	}
+
+	for _, criterion := range request.graphql {
+		results = append(results, criterion.evaluate(r, body))
+	}
+
+	for _, criterion := range request.xml {
+		results = append(results, criterion.evaluate(r, body))
+	}
+
+	for _, field := range request.form {
+		results = append(results, field.evaluate(r, body))
+	}
+
+	for _, header := range request.headers {
+		results = append(results, header.evaluate(r.Header))
+	}
+
+	if len(request.cookies) > 0 {
+		cookies := r.Cookies()
+		for _, cookie := range request.cookies {
+			results = append(results, cookie.evaluate(cookies))
+		}
+	}
+
+	return results
+}
+
+// allCriteriaMatched returns true if every criterion in results is matched
+func allCriteriaMatched(results []criterionResult) bool {
+	for _, result := range results {
+		if !result.matched {
+			return false
+		}
+	}
+	return true
+}
+
+// readBody reads the body of r and replaces it with a copy so that it can be read again
+func readBody(r *http.Request) []byte {
+	if r.Body == nil {
+		return []byte{}
+	}
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		panic(fmt.Errorf("cannot read the body of the request: %w", err))
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	return body
+}
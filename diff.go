@@ -0,0 +1,263 @@
+package httpregistry
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// maxLengthForSimilarity is the maximum length of the strings that are compared character by character,
+// longer strings are considered completely different to avoid spending too much time on huge bodies
+const maxLengthForSimilarity = 1024
+
+// maxClosestRegistrations is the maximum number of registered requests that are compared field by field with an unmatched request
+const maxClosestRegistrations = 3
+
+// maxLengthInDiff is the maximum number of characters of a value that is shown in a diff
+const maxLengthInDiff = 200
+
+// registrationDiff contains how a registered request compares to an incoming request
+type registrationDiff struct {
+	request        Request
+	results        []criterionResult
+	outOfResponses bool
+	score          float64
+}
+
+// newRegistrationDiff evaluates r against the request of m, with the criteria of the session store sessions, and computes how similar they are
+func newRegistrationDiff(m match, r *http.Request, body []byte, sessions *sessionStore) registrationDiff {
+	results := evaluateRequest(sessions.apply(m.Request()), r, body)
+
+	score := 1.0
+	if len(results) > 0 {
+		total := 0.0
+		for _, result := range results {
+			total += result.similarity
+		}
+		score = total / float64(len(results))
+	}
+
+	return registrationDiff{
+		request: m.Request(),
+		results: results,
+		// if all the criteria match but the request was not served it means that the responses were exhausted
+		outOfResponses: allCriteriaMatched(results),
+		score:          score,
+	}
+}
+
+// String returns a human readable, field by field, comparison between the registered request and the incoming one
+func (d registrationDiff) String() string {
+	if d.outOfResponses {
+		return fmt.Sprintf("%v: all criteria match but %v", d.request, outOfResponses)
+	}
+
+	matched := 0
+	for _, result := range d.results {
+		if result.matched {
+			matched++
+		}
+	}
+
+	lines := []string{fmt.Sprintf("%v: %d of %d criteria match", d.request, matched, len(d.results))}
+	for _, result := range d.results {
+		if result.matched {
+			lines = append(lines, fmt.Sprintf("\t%s: matches %q", result.name, truncate(result.actual)))
+			continue
+		}
+
+		if len(result.details) > 0 {
+			lines = append(lines, fmt.Sprintf("\t%s: differs", result.name))
+			for _, detail := range result.details {
+				lines = append(lines, "\t\t"+detail)
+			}
+			continue
+		}
+
+		lines = append(lines, fmt.Sprintf("\t%s: expected %q got %q", result.name, truncate(result.expected), truncate(result.actual)))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// compareWithRegistrations compares r, whose body was already read into body, with the request of each match in matches
+// taking into account the session store sessions
+func compareWithRegistrations(matches []match, r *http.Request, body []byte, sessions *sessionStore) []registrationDiff {
+	diffs := make([]registrationDiff, 0, len(matches))
+	for _, m := range matches {
+		diffs = append(diffs, newRegistrationDiff(m, r, body, sessions))
+	}
+	return diffs
+}
+
+// closestRegistrations ranks diffs by similarity and returns at most n of them, the closest first
+func closestRegistrations(diffs []registrationDiff, n int) []registrationDiff {
+	closest := slices.Clone(diffs)
+
+	// the sort is stable so that between equally similar requests the first registered wins, like it happens when matching
+	sort.SliceStable(closest, func(i, j int) bool {
+		return closest[i].score > closest[j].score
+	})
+
+	if len(closest) > n {
+		closest = closest[:n]
+	}
+	return closest
+}
+
+// bodyDiff explains how the expected body differs from the actual one.
+// If both bodies are valid JSON the difference is computed field by field,
+// otherwise the two bodies are reported as they are.
+func bodyDiff(expected []byte, actual []byte) []string {
+	var expectedJSON, actualJSON any
+	if json.Unmarshal(expected, &expectedJSON) == nil && json.Unmarshal(actual, &actualJSON) == nil {
+		return jsonDiff("$", expectedJSON, actualJSON)
+	}
+	return []string{fmt.Sprintf("expected %q got %q", truncate(string(expected)), truncate(string(actual)))}
+}
+
+// jsonDiff returns one line for each difference between two decoded JSON values.
+// Each line starts with the path of the value that differs, for example $.users[1].name
+func jsonDiff(path string, expected any, actual any) []string {
+	return compareJSON(path, expected, actual, false)
+}
+
+// jsonSubsetDiff is like jsonDiff but the fields of the objects in actual that are not in expected are not considered a difference,
+// so it returns no lines if expected is a subset of actual
+func jsonSubsetDiff(path string, expected any, actual any) []string {
+	return compareJSON(path, expected, actual, true)
+}
+
+// compareJSON implements jsonDiff and, if subset is true, jsonSubsetDiff
+func compareJSON(path string, expected any, actual any, subset bool) []string {
+	switch e := expected.(type) {
+	case map[string]any:
+		a, ok := actual.(map[string]any)
+		if !ok {
+			break
+		}
+
+		keys := []string{}
+		for k := range e {
+			keys = append(keys, k)
+		}
+		for k := range a {
+			if _, found := e[k]; !found {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+
+		diffs := []string{}
+		for _, k := range keys {
+			subPath := path + "." + k
+			expectedValue, isExpected := e[k]
+			actualValue, isActual := a[k]
+			switch {
+			case !isActual:
+				diffs = append(diffs, fmt.Sprintf("%s: expected %s but it is missing", subPath, compactJSON(expectedValue)))
+			case !isExpected:
+				if !subset {
+					diffs = append(diffs, fmt.Sprintf("%s: unexpected %s", subPath, compactJSON(actualValue)))
+				}
+			default:
+				diffs = append(diffs, compareJSON(subPath, expectedValue, actualValue, subset)...)
+			}
+		}
+		return diffs
+	case []any:
+		a, ok := actual.([]any)
+		if !ok {
+			break
+		}
+
+		diffs := []string{}
+		for i := 0; i < len(e) || i < len(a); i++ {
+			subPath := fmt.Sprintf("%s[%d]", path, i)
+			switch {
+			case i >= len(a):
+				diffs = append(diffs, fmt.Sprintf("%s: expected %s but it is missing", subPath, compactJSON(e[i])))
+			case i >= len(e):
+				diffs = append(diffs, fmt.Sprintf("%s: unexpected %s", subPath, compactJSON(a[i])))
+			default:
+				diffs = append(diffs, compareJSON(subPath, e[i], a[i], subset)...)
+			}
+		}
+		return diffs
+	}
+
+	if reflect.DeepEqual(expected, actual) {
+		return []string{}
+	}
+	return []string{fmt.Sprintf("%s: expected %s got %s", path, compactJSON(expected), compactJSON(actual))}
+}
+
+// compactJSON returns the truncated JSON representation of v
+func compactJSON(v any) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+	return truncate(string(b))
+}
+
+// truncate shortens s so that it can be shown in a diff without flooding the output
+func truncate(s string) string {
+	if len(s) <= maxLengthInDiff {
+		return s
+	}
+	return s[:maxLengthInDiff] + "..."
+}
+
+// stringSimilarity returns a number between 0 and 1 that expresses how similar a and b are, 1 means identical.
+// The similarity is computed from the Levenshtein distance between the two strings.
+func stringSimilarity(a string, b string) float64 {
+	if a == b {
+		return 1
+	}
+	ra, rb := []rune(a), []rune(b)
+	if len(ra) > maxLengthForSimilarity || len(rb) > maxLengthForSimilarity {
+		return 0
+	}
+
+	longest := max(len(ra), len(rb))
+	return 1 - float64(levenshtein(ra, rb))/float64(longest)
+}
+
+// levenshtein computes the minimum number of single character edits needed to change a into b
+func levenshtein(a []rune, b []rune) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(b)]
+}
+
+// closestToString returns the human readable version of closest ready to be appended to an error message
+func closestToString(closest []registrationDiff) string {
+	if len(closest) == 0 {
+		return ""
+	}
+
+	lines := []string{"\n The closest registered requests are:"}
+	for _, d := range closest {
+		lines = append(lines, d.String())
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,59 @@
+package httpregistry
+
+func (s *TestSuite) TestJSONBodyDiff() {
+	testCases := []struct {
+		name     string
+		expected string
+		actual   string
+		diffs    []string
+	}{
+		{
+			name:     "identical bodies",
+			expected: `{"name": "John", "age": 42}`,
+			actual:   `{"age":42,"name":"John"}`,
+			diffs:    []string{},
+		},
+		{
+			name:     "different value",
+			expected: `{"name": "John"}`,
+			actual:   `{"name": "Jon"}`,
+			diffs:    []string{`$.name: expected "John" got "Jon"`},
+		},
+		{
+			name:     "missing and unexpected fields",
+			expected: `{"name": "John", "age": 42}`,
+			actual:   `{"name": "John", "agee": 42}`,
+			diffs:    []string{`$.age: expected 42 but it is missing`, `$.agee: unexpected 42`},
+		},
+		{
+			name:     "nested arrays",
+			expected: `{"users": [{"id": 1}, {"id": 2}]}`,
+			actual:   `{"users": [{"id": 1}, {"id": 3}, {"id": 4}]}`,
+			diffs:    []string{`$.users[1].id: expected 2 got 3`, `$.users[2]: unexpected {"id":4}`},
+		},
+		{
+			name:     "different types",
+			expected: `{"id": [1]}`,
+			actual:   `{"id": 1}`,
+			diffs:    []string{`$.id: expected [1] got 1`},
+		},
+		{
+			name:     "not JSON",
+			expected: `hello`,
+			actual:   `hallo`,
+			diffs:    []string{`expected "hello" got "hallo"`},
+		},
+	}
+	for _, tc := range testCases {
+		s.Run(tc.name, func() {
+			s.Equal(tc.diffs, bodyDiff([]byte(tc.expected), []byte(tc.actual)))
+		})
+	}
+}
+
+func (s *TestSuite) TestStringSimilarity() {
+	s.Equal(1.0, stringSimilarity("/users", "/users"))
+	s.Equal(0.0, stringSimilarity("abc", "xyz"))
+	s.InDelta(5.0/6.0, stringSimilarity("/users", "/user"), 0.001)
+	s.Greater(stringSimilarity("/users/1", "/user/1"), stringSimilarity("/users/1", "/orders/1"))
+}
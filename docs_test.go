@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"io"
 	"net/http"
+	"reflect"
 	"regexp"
 	"slices"
 	"testing"
@@ -240,13 +241,36 @@ func TestWeCanInvestigateWhyATestFails(t *testing.T) {
 		t.Errorf("mockT.HasFailed should be true, but it was %t", mockT.HasFailed)
 	}
 
-	// 5. The body of the call tells us why it failed
-	bodyBytes, err := io.ReadAll(secondResponse.Body)
-	if err != nil {
+	// 5. The registry tells us why it failed
+	why := registry.Why()
+	if why != "mock request #1 missed because the route matches but there was no response available" {
+		t.Errorf("was expecting \"mock request #1 missed because the route matches but there was no response available\", got: %s", why)
+	}
+
+	// 6. The body of the call contains the same information in JSON
+	var report httpregistry.MissReport
+	if err := json.NewDecoder(secondResponse.Body).Decode(&report); err != nil {
 		t.Errorf("Decoding second response body failed: %s", err)
 	}
-	body := string(bodyBytes)
-	if body != "mock request #1 missed because the route matches but there was no response available" {
-		t.Errorf("was expecting \"mock request #1 missed because the route matches but there was no response available\", got: %s", body)
+	if len(report.Registrations) != 1 {
+		t.Fatalf("The report should contain 1 registration but I found %d", len(report.Registrations))
+	}
+	registration := report.Registrations[0]
+	if registration.Name != "mock request #1" {
+		t.Errorf("was expecting the registration \"mock request #1\" in the report, got: %s", registration.Name)
+	}
+	misses := []httpregistry.CriterionReport{}
+	for _, criterion := range registration.Criteria {
+		if !criterion.Matched {
+			misses = append(misses, criterion)
+		}
+	}
+	expectedMiss := httpregistry.CriterionReport{
+		Criterion: "responses",
+		Expected:  "at least one response available",
+		Actual:    "no response available",
+	}
+	if len(misses) != 1 || !reflect.DeepEqual(misses[0], expectedMiss) {
+		t.Errorf("was expecting only the criterion %+v to miss, got: %+v", expectedMiss, misses)
 	}
 }
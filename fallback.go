@@ -0,0 +1,111 @@
+package httpregistry
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// Mode defines how a Registry behaves when an incoming request does not match any registered request
+type Mode int
+
+const (
+	// StrictMode fails the test as soon as a request does not match and returns a 500 with a MissReport as body.
+	// This is the default mode of a Registry
+	StrictMode Mode = iota
+	// LenientMode does not fail the test when a request does not match, the request is only logged and it is served by the fallback.
+	// By default the fallback returns a 404 with a MissReport as body.
+	// Use Registry.CheckNoUnexpectedCalls to assert at the end of the test that all the calls matched
+	LenientMode
+)
+
+// String returns the human readable name of the mode
+func (m Mode) String() string {
+	switch m {
+	case StrictMode:
+		return "strict"
+	case LenientMode:
+		return "lenient"
+	default:
+		return fmt.Sprintf("Mode(%d)", int(m))
+	}
+}
+
+// SetMode sets how the registry behaves when an incoming request does not match any registered request
+//
+//	reg := httpregistry.NewRegistry(t)
+//	reg.SetMode(httpregistry.LenientMode)
+//	reg.AddMethodAndURL(http.MethodGet, "/foo")
+//	reg.GetServer()
+//
+// will create a http server that returns 200 on calling GET "/foo" and 404 on anything else without failing the test
+func (reg *Registry) SetMode(mode Mode) {
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+	reg.mode = mode
+}
+
+// SetFallbackHandler sets the handler that serves the requests that do not match when the registry is in LenientMode.
+// This can be used for example to proxy the unmatched requests to a real server
+//
+//	reg := httpregistry.NewRegistry(t)
+//	reg.SetMode(httpregistry.LenientMode)
+//	reg.SetFallbackHandler(httputil.NewSingleHostReverseProxy(upstreamURL))
+func (reg *Registry) SetFallbackHandler(handler http.Handler) {
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+	reg.fallback = handler
+}
+
+// SetFallbackResponse sets the response that is returned for the requests that do not match when the registry is in LenientMode.
+// The response is never consumed
+//
+//	reg := httpregistry.NewRegistry(t)
+//	reg.SetMode(httpregistry.LenientMode)
+//	reg.SetFallbackResponse(
+//		httpregistry.NotFoundResponse.WithJSONBody(map[string]string{"error": "not found"}),
+//	)
+func (reg *Registry) SetFallbackResponse(response mockResponse) {
+	response = reg.ifNeededSetDefaultNameToMockResponse(response)
+	reg.SetFallbackHandler(http.HandlerFunc(response.serveResponse))
+}
+
+// CheckNoUnexpectedCalls fails the test for each request that reached the server and did not match any registered request.
+// This is mostly useful in LenientMode, where unmatched requests do not fail the test when they happen.
+func (reg *Registry) CheckNoUnexpectedCalls() {
+	for _, entry := range reg.GetJournal(NewJournalQuery().OnlyUnmatched()) {
+		reg.t.Errorf("unexpected call %v %v, the reasons why it did not match are:\n%v", entry.Request.Method, entry.Request.URL, entry.Why())
+	}
+}
+
+// MissHandler answers a request that did not match any registered request, report explains why it did not match
+type MissHandler func(w http.ResponseWriter, r *http.Request, report MissReport)
+
+// SetMissHandler sets the handler that answers the requests that do not match any registered request when the registry is in StrictMode.
+// The test is failed independently of the handler, which only decides what the client receives.
+// This is useful when the client does not understand the default answer, for example a gRPC client expects the error in the trailers.
+// Passing nil restores the default handler, ServeMissReport
+func (reg *Registry) SetMissHandler(handler MissHandler) {
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+	reg.missHandler = handler
+}
+
+// ServeMissReport is the default MissHandler, it answers with a 500 whose body is report encoded as JSON
+func ServeMissReport(w http.ResponseWriter, r *http.Request, report MissReport) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusInternalServerError)
+	_, _ = w.Write(mustMarshalJSON(report))
+}
+
+// serveFallback serves r with the fallback handler if set or
+// with a 404 that contains report as body if no handler was set
+func serveFallback(fallback http.Handler, w http.ResponseWriter, r *http.Request, report MissReport) {
+	if fallback != nil {
+		fallback.ServeHTTP(w, r)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusNotFound)
+	_, _ = w.Write(mustMarshalJSON(report))
+}
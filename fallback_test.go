@@ -0,0 +1,157 @@
+package httpregistry_test
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/dfioravanti/httpregistry"
+)
+
+func (s *TestSuite) TestLenientModeReturnsNotFoundByDefault() {
+	mockT := httpregistry.NewMockTestingT()
+	registry := httpregistry.NewRegistry(mockT)
+	registry.SetMode(httpregistry.LenientMode)
+	registry.AddMethodAndURL(http.MethodGet, "/foo")
+
+	server := registry.GetServer()
+	defer server.Close()
+
+	res, err := http.Get(server.URL + "/bar")
+	s.NoError(err)
+
+	s.Equal(http.StatusNotFound, res.StatusCode)
+	s.Equal("application/json", res.Header.Get("Content-Type"))
+	var report httpregistry.MissReport
+	s.NoError(json.NewDecoder(res.Body).Decode(&report))
+	s.Equal("/bar", report.Request.URL)
+
+	s.False(mockT.HasFailed)
+	s.Equal(1, len(mockT.Logs))
+	s.Contains(mockT.Logs[0], "no registered request matched GET /bar")
+
+	res, err = http.Get(server.URL + "/foo")
+	s.NoError(err)
+	s.Equal(http.StatusOK, res.StatusCode)
+	s.False(mockT.HasFailed)
+}
+
+func (s *TestSuite) TestLenientModeServesTheFallback() {
+	testCases := []struct {
+		name         string
+		setFallback  func(registry *httpregistry.Registry)
+		expectedCode int
+		expectedBody string
+	}{
+		{
+			name: "fallback response",
+			setFallback: func(registry *httpregistry.Registry) {
+				registry.SetFallbackResponse(
+					httpregistry.NewResponse().
+						WithStatus(http.StatusNotFound).
+						WithJSONBody(map[string]string{"error": "not found"}),
+				)
+			},
+			expectedCode: http.StatusNotFound,
+			expectedBody: `{"error":"not found"}`,
+		},
+		{
+			name: "fallback handler",
+			setFallback: func(registry *httpregistry.Registry) {
+				registry.SetFallbackHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					w.WriteHeader(http.StatusTeapot)
+					_, _ = w.Write([]byte("proxied " + r.URL.Path))
+				}))
+			},
+			expectedCode: http.StatusTeapot,
+			expectedBody: "proxied /bar",
+		},
+	}
+	for _, tc := range testCases {
+		s.Run(tc.name, func() {
+			mockT := httpregistry.NewMockTestingT()
+			registry := httpregistry.NewRegistry(mockT)
+			registry.SetMode(httpregistry.LenientMode)
+			tc.setFallback(registry)
+
+			server := registry.GetServer()
+			defer server.Close()
+
+			for range 2 {
+				res, err := http.Get(server.URL + "/bar")
+				s.NoError(err)
+				body, err := io.ReadAll(res.Body)
+				s.NoError(err)
+
+				s.Equal(tc.expectedCode, res.StatusCode)
+				s.Equal(tc.expectedBody, string(body))
+			}
+			s.False(mockT.HasFailed)
+		})
+	}
+}
+
+func (s *TestSuite) TestCheckNoUnexpectedCalls() {
+	mockT := httpregistry.NewMockTestingT()
+	registry := httpregistry.NewRegistry(mockT)
+	registry.SetMode(httpregistry.LenientMode)
+	registry.AddMethodAndURL(http.MethodGet, "/foo")
+
+	server := registry.GetServer()
+	defer server.Close()
+
+	_, err := http.Get(server.URL + "/foo")
+	s.NoError(err)
+	registry.CheckNoUnexpectedCalls()
+	s.False(mockT.HasFailed)
+
+	_, err = http.Get(server.URL + "/bar")
+	s.NoError(err)
+	registry.CheckNoUnexpectedCalls()
+	s.True(mockT.HasFailed)
+	s.Equal(1, len(mockT.Messages))
+	s.Contains(mockT.Messages[0], "unexpected call GET /bar")
+	s.Contains(mockT.Messages[0], "mock request #1 missed because the path does not match")
+}
+
+func (s *TestSuite) TestStrictModeIsTheDefault() {
+	mockT := httpregistry.NewMockTestingT()
+	registry := httpregistry.NewRegistry(mockT)
+	registry.SetFallbackResponse(httpregistry.NotFoundResponse)
+
+	server := registry.GetServer()
+	defer server.Close()
+
+	res, err := http.Get(server.URL + "/bar")
+	s.NoError(err)
+
+	s.Equal(http.StatusInternalServerError, res.StatusCode)
+	s.True(mockT.HasFailed)
+}
+
+func (s *TestSuite) TestMissHandler() {
+	mockT := httpregistry.NewMockTestingT()
+	registry := httpregistry.NewRegistry(mockT)
+	registry.AddMethodAndURL(http.MethodGet, "/foo")
+	registry.SetMissHandler(func(w http.ResponseWriter, r *http.Request, report httpregistry.MissReport) {
+		w.WriteHeader(http.StatusTeapot)
+		_, _ = w.Write([]byte(report.Request.URL))
+	})
+
+	server := registry.GetServer()
+	defer server.Close()
+
+	res, err := http.Get(server.URL + "/bar")
+	s.NoError(err)
+	body, err := io.ReadAll(res.Body)
+	s.NoError(err)
+	s.Equal(http.StatusTeapot, res.StatusCode)
+	s.Equal("/bar", string(body))
+	s.True(mockT.HasFailed)
+
+	registry.SetMissHandler(nil)
+	res, err = http.Get(server.URL + "/bar")
+	s.NoError(err)
+	s.Equal(http.StatusInternalServerError, res.StatusCode)
+	s.Equal("application/json", res.Header.Get("Content-Type"))
+}
@@ -0,0 +1,237 @@
+package httpregistry
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// formRule defines which property of a form field or of a multipart part is compared with the expected value
+type formRule string
+
+// These constants are the rules that can be used to match the fields of a form, they are also used to describe the expected value in the reports
+const (
+	formFieldEquals      = formRule("equal to")
+	partValueEquals      = formRule("with value")
+	partFileNameEquals   = formRule("with file name")
+	partContentTypeEqual = formRule("with content type")
+	partContentEquals    = formRule("with content")
+	partChecksumEquals   = formRule("with SHA-256 checksum")
+)
+
+// MultipartPart is a part of a multipart/form-data body sent by an incoming request
+type MultipartPart struct {
+	// FieldName is the name of the form field that the part represents
+	FieldName string
+	// FileName is the name of the uploaded file, it is empty if the part is not a file
+	FileName string
+	// ContentType is the Content-Type of the part, it is empty if the part does not declare one
+	ContentType string
+	// Header contains all the headers of the part
+	Header textproto.MIMEHeader
+	// Content is the content of the part
+	Content []byte
+}
+
+// Checksum returns the hex encoded SHA-256 checksum of the content of the part
+func (p MultipartPart) Checksum() string {
+	sum := sha256.Sum256(p.Content)
+	return hex.EncodeToString(sum[:])
+}
+
+// parseMultipart parses body as a multipart/form-data body using the boundary declared in header.
+// It returns an error if the Content-Type in header is not multipart or if body is malformed
+func parseMultipart(header http.Header, body []byte) ([]MultipartPart, error) {
+	mediaType, params, err := mime.ParseMediaType(header.Get("Content-Type"))
+	if err != nil {
+		return nil, fmt.Errorf("the Content-Type is not valid: %w", err)
+	}
+	if !strings.HasPrefix(mediaType, "multipart/") || params["boundary"] == "" {
+		return nil, fmt.Errorf("the Content-Type %q is not multipart", mediaType)
+	}
+
+	parts := []MultipartPart{}
+	reader := multipart.NewReader(bytes.NewReader(body), params["boundary"])
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			return parts, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("the body is not valid multipart: %w", err)
+		}
+		content, err := io.ReadAll(part)
+		if err != nil {
+			return nil, fmt.Errorf("the body is not valid multipart: %w", err)
+		}
+		parts = append(parts, MultipartPart{
+			FieldName:   part.FormName(),
+			FileName:    part.FileName(),
+			ContentType: part.Header.Get("Content-Type"),
+			Header:      part.Header,
+			Content:     content,
+		})
+	}
+}
+
+// formCriterion is a condition that a registered Request places on a field of an application/x-www-form-urlencoded body
+// or on a part of a multipart/form-data body.
+// If the field is sent multiple times, the criterion is satisfied if any of them does.
+type formCriterion struct {
+	multipart bool
+	field     string
+	rule      formRule
+	value     string
+}
+
+// kind returns the human readable name of the element of the body that the criterion inspects
+func (c formCriterion) kind() string {
+	if c.multipart {
+		return "multipart part"
+	}
+	return "form field"
+}
+
+// expected returns the human readable version of what the criterion expects
+func (c formCriterion) expected() string {
+	if c.rule == formFieldEquals {
+		return c.value
+	}
+	return fmt.Sprintf("%s %q", c.rule, truncate(c.value))
+}
+
+// failure returns the human readable explanation of why the criterion is not satisfied
+func (c formCriterion) failure() string {
+	if c.rule == formFieldEquals {
+		return fmt.Sprintf("%s %q is not equal to %q", c.kind(), c.field, c.value)
+	}
+	return fmt.Sprintf("%s %q does not have %s %q", c.kind(), c.field, strings.TrimPrefix(string(c.rule), "with "), truncate(c.value))
+}
+
+// property returns the property of part that the criterion compares with its value
+func (c formCriterion) property(part MultipartPart) string {
+	switch c.rule {
+	case partFileNameEquals:
+		return part.FileName
+	case partContentTypeEqual:
+		return part.ContentType
+	case partChecksumEquals:
+		return part.Checksum()
+	default:
+		return string(part.Content)
+	}
+}
+
+// values returns the values of the field that the criterion inspects, taken from the incoming request r whose body was already read into body.
+// If the body cannot be parsed it returns an error that describes why
+func (c formCriterion) values(r *http.Request, body []byte) ([]string, error) {
+	if !c.multipart {
+		form, err := url.ParseQuery(string(body))
+		if err != nil {
+			return nil, fmt.Errorf("the body is not a valid form: %w", err)
+		}
+		return form[c.field], nil
+	}
+
+	parts, err := parseMultipart(r.Header, body)
+	if err != nil {
+		return nil, err
+	}
+	values := []string{}
+	for _, part := range parts {
+		if part.FieldName == c.field {
+			values = append(values, c.property(part))
+		}
+	}
+	return values, nil
+}
+
+// evaluate checks the criterion against the incoming request r whose body was already read into body
+func (c formCriterion) evaluate(r *http.Request, body []byte) criterionResult {
+	name := fmt.Sprintf("%s %q", c.kind(), c.field)
+	why := formFieldDoesNotMatch
+	if c.multipart {
+		why = multipartPartDoesNotMatch
+	}
+
+	values, err := c.values(r, body)
+	if err != nil {
+		result := newCriterionResult(name, c.expected(), err.Error(), false, why)
+		result.missDetail = err.Error()
+		return result
+	}
+
+	matched := false
+	for _, v := range values {
+		matched = matched || v == c.value
+	}
+	actual := make([]string, 0, len(values))
+	for _, v := range values {
+		actual = append(actual, truncate(v))
+	}
+	result := newCriterionResult(name, c.expected(), strings.Join(actual, ", "), matched, why)
+	result.missDetail = c.failure()
+	return result
+}
+
+// withFormCriterion returns a copy of criteria with criterion added, the criteria on the same field with the same rule are replaced.
+// The criteria are kept sorted so that two requests with the same criteria are equal independently of the order in which they were added
+func withFormCriterion(criteria []formCriterion, criterion formCriterion) []formCriterion {
+	newCriteria := make([]formCriterion, 0, len(criteria)+1)
+	for _, c := range criteria {
+		if c.multipart == criterion.multipart && c.field == criterion.field && c.rule == criterion.rule {
+			continue
+		}
+		newCriteria = append(newCriteria, c)
+	}
+	newCriteria = append(newCriteria, criterion)
+
+	sort.SliceStable(newCriteria, func(i, j int) bool {
+		if newCriteria[i].multipart != newCriteria[j].multipart {
+			return !newCriteria[i].multipart
+		}
+		if newCriteria[i].field != newCriteria[j].field {
+			return newCriteria[i].field < newCriteria[j].field
+		}
+		return newCriteria[i].rule < newCriteria[j].rule
+	})
+	return newCriteria
+}
+
+// DecodeMultipartParts parses the multipart/form-data body of each request in requests.
+// It is designed to be used with the requests returned by functions like Registry.GetMatchesForRequest
+//
+//	parts := httpregistry.DecodeMultipartParts(t, reg.GetMatchesForRequest(upload))
+//	avatar := parts[0][0]
+//	avatar.FileName // "me.png"
+//
+// If a body cannot be parsed the test is failed and the corresponding element is nil,
+// so that the i-th element always refers to the i-th request.
+// The bodies of the requests are not consumed, so they can be accessed again.
+func DecodeMultipartParts(t TestingT, requests []*http.Request) [][]MultipartPart {
+	decoded := make([][]MultipartPart, len(requests))
+	for i, r := range requests {
+		parts, err := parseMultipart(r.Header, readBody(r))
+		if err != nil {
+			t.Errorf("the body of call %d to %v %v cannot be decoded as multipart: %v", i, r.Method, r.URL, err)
+			continue
+		}
+		decoded[i] = parts
+	}
+	return decoded
+}
+
+// GetMultipartPartsForRequest returns the parts of the multipart/form-data bodies of the http.Requests that matched a specific Request.
+// The parts of each call are returned in the order in which they were sent
+func (reg *Registry) GetMultipartPartsForRequest(r Request) [][]MultipartPart {
+	return DecodeMultipartParts(reg.t, reg.GetMatchesForRequest(r))
+}
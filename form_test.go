@@ -0,0 +1,218 @@
+package httpregistry_test
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"strings"
+
+	"github.com/dfioravanti/httpregistry"
+)
+
+// multipartBody builds a multipart/form-data body with a text field and a file, it returns the body and its Content-Type
+func multipartBody(s *TestSuite, fields map[string]string, fileField string, fileName string, fileContentType string, fileContent []byte) (*bytes.Buffer, string) {
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	for name, value := range fields {
+		s.NoError(writer.WriteField(name, value))
+	}
+	if fileField != "" {
+		header := textproto.MIMEHeader{}
+		header.Set("Content-Disposition", `form-data; name="`+fileField+`"; filename="`+fileName+`"`)
+		header.Set("Content-Type", fileContentType)
+		part, err := writer.CreatePart(header)
+		s.NoError(err)
+		_, err = part.Write(fileContent)
+		s.NoError(err)
+	}
+	s.NoError(writer.Close())
+	return body, writer.FormDataContentType()
+}
+
+func (s *TestSuite) TestFormFieldMatching() {
+	testCases := []struct {
+		name        string
+		request     httpregistry.Request
+		form        url.Values
+		shouldMatch bool
+		expectedWhy string
+	}{
+		{
+			name:        "field matches",
+			request:     httpregistry.NewRequest().WithFormField("user", "john").WithFormField("remember", "true"),
+			form:        url.Values{"user": {"john"}, "remember": {"true"}, "other": {"x"}},
+			shouldMatch: true,
+		},
+		{
+			name:        "repeated field matches any value",
+			request:     httpregistry.NewRequest().WithFormField("tag", "b"),
+			form:        url.Values{"tag": {"a", "b"}},
+			shouldMatch: true,
+		},
+		{
+			name:        "field does not match",
+			request:     httpregistry.NewRequest().WithFormField("user", "john"),
+			form:        url.Values{"user": {"jane"}},
+			expectedWhy: `mock request #1 missed because the form field does not match: form field "user" is not equal to "john"`,
+		},
+		{
+			name:        "field is missing",
+			request:     httpregistry.NewRequest().WithFormField("user", "john"),
+			form:        url.Values{},
+			expectedWhy: `mock request #1 missed because the form field does not match: form field "user" is not equal to "john"`,
+		},
+	}
+	for _, tc := range testCases {
+		s.Run(tc.name, func() {
+			mockT := httpregistry.NewMockTestingT()
+			registry := httpregistry.NewRegistry(mockT)
+			registry.AddRequest(tc.request)
+
+			server := registry.GetServer()
+			defer server.Close()
+
+			res, err := http.PostForm(server.URL, tc.form)
+			s.NoError(err)
+
+			if tc.shouldMatch {
+				s.Equal(http.StatusOK, res.StatusCode)
+				s.False(mockT.HasFailed)
+				return
+			}
+			s.Equal(http.StatusInternalServerError, res.StatusCode)
+			s.Equal(tc.expectedWhy, registry.Why())
+		})
+	}
+}
+
+func (s *TestSuite) TestMultipartMatching() {
+	content := []byte("\x89PNG fake image")
+	checksum := sha256.Sum256(content)
+
+	testCases := []struct {
+		name        string
+		request     httpregistry.Request
+		shouldMatch bool
+		expectedWhy string
+	}{
+		{
+			name: "all the properties of the parts match",
+			request: httpregistry.NewRequest().
+				WithMultipartField("title", "holidays").
+				WithMultipartFile("image", "beach.png").
+				WithMultipartContentType("image", "image/png").
+				WithMultipartFileContent("image", content).
+				WithMultipartFileChecksum("image", hex.EncodeToString(checksum[:])),
+			shouldMatch: true,
+		},
+		{
+			name:        "field value does not match",
+			request:     httpregistry.NewRequest().WithMultipartField("title", "work"),
+			expectedWhy: `mock request #1 missed because the multipart part does not match: multipart part "title" does not have value "work"`,
+		},
+		{
+			name:        "file name does not match",
+			request:     httpregistry.NewRequest().WithMultipartFile("image", "mountain.png"),
+			expectedWhy: `mock request #1 missed because the multipart part does not match: multipart part "image" does not have file name "mountain.png"`,
+		},
+		{
+			name:        "content type does not match",
+			request:     httpregistry.NewRequest().WithMultipartContentType("image", "image/jpeg"),
+			expectedWhy: `mock request #1 missed because the multipart part does not match: multipart part "image" does not have content type "image/jpeg"`,
+		},
+		{
+			name:        "checksum does not match",
+			request:     httpregistry.NewRequest().WithMultipartFileChecksum("image", "00"),
+			expectedWhy: `mock request #1 missed because the multipart part does not match: multipart part "image" does not have SHA-256 checksum "00"`,
+		},
+		{
+			name:        "part is missing",
+			request:     httpregistry.NewRequest().WithMultipartFile("document", "cv.pdf"),
+			expectedWhy: `mock request #1 missed because the multipart part does not match: multipart part "document" does not have file name "cv.pdf"`,
+		},
+	}
+	for _, tc := range testCases {
+		s.Run(tc.name, func() {
+			mockT := httpregistry.NewMockTestingT()
+			registry := httpregistry.NewRegistry(mockT)
+			registry.AddRequest(tc.request)
+
+			server := registry.GetServer()
+			defer server.Close()
+
+			body, contentType := multipartBody(s, map[string]string{"title": "holidays"}, "image", "beach.png", "image/png", content)
+			res, err := http.Post(server.URL, contentType, body)
+			s.NoError(err)
+
+			if tc.shouldMatch {
+				s.Equal(http.StatusOK, res.StatusCode)
+				s.False(mockT.HasFailed)
+				return
+			}
+			s.Equal(http.StatusInternalServerError, res.StatusCode)
+			s.Equal(tc.expectedWhy, registry.Why())
+		})
+	}
+}
+
+func (s *TestSuite) TestMultipartCriteriaFailOnBodiesThatAreNotMultipart() {
+	mockT := httpregistry.NewMockTestingT()
+	registry := httpregistry.NewRegistry(mockT)
+	registry.AddRequest(httpregistry.NewRequest().WithMultipartField("title", "holidays"))
+
+	server := registry.GetServer()
+	defer server.Close()
+
+	res, err := http.Post(server.URL, "text/plain", strings.NewReader("title=holidays"))
+	s.NoError(err)
+	s.Equal(http.StatusInternalServerError, res.StatusCode)
+	s.Equal(`mock request #1 missed because the multipart part does not match: the Content-Type "text/plain" is not multipart`, registry.Why())
+}
+
+func (s *TestSuite) TestMultipartPartsCanBeRetrieved() {
+	registry := httpregistry.NewRegistry(s.T())
+	upload := httpregistry.NewRequest().WithMethod(http.MethodPost).WithURL("/upload")
+	registry.AddRequest(upload)
+
+	server := registry.GetServer()
+	defer server.Close()
+
+	body, contentType := multipartBody(s, map[string]string{"title": "holidays"}, "image", "beach.png", "image/png", []byte("image"))
+	_, err := http.Post(server.URL+"/upload", contentType, body)
+	s.NoError(err)
+
+	parts := registry.GetMultipartPartsForRequest(upload)
+	s.Len(parts, 1)
+	s.Len(parts[0], 2)
+	s.Equal("title", parts[0][0].FieldName)
+	s.Equal("holidays", string(parts[0][0].Content))
+	s.Equal("image", parts[0][1].FieldName)
+	s.Equal("beach.png", parts[0][1].FileName)
+	s.Equal("image/png", parts[0][1].ContentType)
+	s.Equal([]byte("image"), parts[0][1].Content)
+
+	// the bodies are not consumed so they can be parsed again
+	s.Equal(parts, httpregistry.DecodeMultipartParts(s.T(), registry.GetMatchesForRequest(upload)))
+}
+
+func (s *TestSuite) TestDecodeMultipartPartsFailsOnInvalidBodies() {
+	mockT := httpregistry.NewMockTestingT()
+	invalid, err := http.NewRequest(http.MethodPost, "/upload", strings.NewReader("{}"))
+	s.NoError(err)
+	invalid.Header.Set("Content-Type", "application/json")
+	body, contentType := multipartBody(s, map[string]string{"title": "holidays"}, "", "", "", nil)
+	valid, err := http.NewRequest(http.MethodPost, "/upload", body)
+	s.NoError(err)
+	valid.Header.Set("Content-Type", contentType)
+
+	parts := httpregistry.DecodeMultipartParts(mockT, []*http.Request{invalid, valid})
+	s.Len(parts, 2)
+	s.Nil(parts[0])
+	s.Equal("holidays", string(parts[1][0].Content))
+	s.Equal(1, len(mockT.Messages))
+	s.Contains(mockT.Messages[0], "the body of call 0 to POST /upload cannot be decoded as multipart")
+}
@@ -0,0 +1,427 @@
+package httpregistry
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"unicode"
+)
+
+// graphqlRequest is the GraphQL operation sent by an incoming request
+type graphqlRequest struct {
+	Query         string         `json:"query"`
+	OperationName string         `json:"operationName"`
+	Variables     map[string]any `json:"variables"`
+}
+
+// parseGraphQLRequest extracts the GraphQL operation from r, whose body was already read into body.
+// GET requests carry the operation in the query string, the other requests in a JSON body
+func parseGraphQLRequest(r *http.Request, body []byte) (graphqlRequest, error) {
+	operation := graphqlRequest{}
+	if r.Method == http.MethodGet {
+		query := r.URL.Query()
+		operation.Query = query.Get("query")
+		operation.OperationName = query.Get("operationName")
+		if variables := query.Get("variables"); variables != "" {
+			if err := json.Unmarshal([]byte(variables), &operation.Variables); err != nil {
+				return graphqlRequest{}, fmt.Errorf("the variables are not a JSON object: %w", err)
+			}
+		}
+	} else if err := json.Unmarshal(body, &operation); err != nil {
+		return graphqlRequest{}, fmt.Errorf("the body is not a GraphQL request: %w", err)
+	}
+
+	if operation.Query == "" {
+		return graphqlRequest{}, errors.New("the request does not contain a GraphQL query")
+	}
+	if operation.Variables == nil {
+		operation.Variables = map[string]any{}
+	}
+	return operation, nil
+}
+
+// graphqlOperation is an operation defined in a GraphQL document
+type graphqlOperation struct {
+	operationType string
+	name          string
+}
+
+// selectedOperation returns the operation of the document that is executed by the request.
+// It is the one called OperationName or, if OperationName is empty, the only operation of the document
+func (g graphqlRequest) selectedOperation() (graphqlOperation, error) {
+	operations := graphqlOperations(graphqlTokens(g.Query))
+	for _, operation := range operations {
+		if (g.OperationName == "" && len(operations) == 1) || (g.OperationName != "" && operation.name == g.OperationName) {
+			return operation, nil
+		}
+	}
+	if g.OperationName != "" {
+		return graphqlOperation{}, fmt.Errorf("the document does not define the operation %q", g.OperationName)
+	}
+	return graphqlOperation{}, fmt.Errorf("the document defines %d operations and no operation name was sent", len(operations))
+}
+
+// graphqlTokens splits a GraphQL document in its lexical tokens.
+// Whitespace, commas and comments are insignificant in GraphQL so they are dropped
+func graphqlTokens(document string) []string {
+	tokens := []string{}
+	runes := []rune(document)
+	for i := 0; i < len(runes); {
+		c := runes[i]
+		switch {
+		case unicode.IsSpace(c) || c == ',' || c == '\uFEFF':
+			i++
+		case c == '#':
+			for i < len(runes) && runes[i] != '\n' && runes[i] != '\r' {
+				i++
+			}
+		case strings.HasPrefix(string(runes[i:min(i+3, len(runes))]), `"""`):
+			end := i + 3
+			for end < len(runes) && !(strings.HasPrefix(string(runes[end:min(end+3, len(runes))]), `"""`) && runes[end-1] != '\\') {
+				end++
+			}
+			end = min(end+3, len(runes))
+			tokens = append(tokens, string(runes[i:end]))
+			i = end
+		case c == '"':
+			end := i + 1
+			for end < len(runes) && runes[end] != '"' && runes[end] != '\n' {
+				if runes[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			end = min(end+1, len(runes))
+			tokens = append(tokens, string(runes[i:end]))
+			i = end
+		case c == '.' && strings.HasPrefix(string(runes[i:min(i+3, len(runes))]), "..."):
+			tokens = append(tokens, "...")
+			i += 3
+		case isGraphQLNameStart(c):
+			end := i + 1
+			for end < len(runes) && (isGraphQLNameStart(runes[end]) || isASCIIDigit(runes[end])) {
+				end++
+			}
+			tokens = append(tokens, string(runes[i:end]))
+			i = end
+		case c == '-' || isASCIIDigit(c):
+			end := graphqlNumberEnd(runes, i)
+			tokens = append(tokens, string(runes[i:end]))
+			i = end
+		default:
+			tokens = append(tokens, string(c))
+			i++
+		}
+	}
+	return tokens
+}
+
+// isGraphQLNameStart returns true if c can start a GraphQL name, names match /[_A-Za-z][_0-9A-Za-z]*/
+func isGraphQLNameStart(c rune) bool {
+	return c == '_' || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z')
+}
+
+// isASCIIDigit returns true if c is one of the digits 0-9
+func isASCIIDigit(c rune) bool {
+	return '0' <= c && c <= '9'
+}
+
+// graphqlNumberEnd returns the position after the end of the number that starts at start in runes.
+// Numbers match /-?[0-9]+(\.[0-9]+)?([eE][+-]?[0-9]+)?/, a lone - is returned as a token of its own
+func graphqlNumberEnd(runes []rune, start int) int {
+	digits := func(i int) int {
+		for i < len(runes) && isASCIIDigit(runes[i]) {
+			i++
+		}
+		return i
+	}
+
+	end := start
+	if runes[end] == '-' {
+		end++
+	}
+	end = digits(end)
+	if end < len(runes) && runes[end] == '.' && end+1 < len(runes) && isASCIIDigit(runes[end+1]) {
+		end = digits(end + 1)
+	}
+	if end < len(runes) && (runes[end] == 'e' || runes[end] == 'E') {
+		exponent := end + 1
+		if exponent < len(runes) && (runes[exponent] == '+' || runes[exponent] == '-') {
+			exponent++
+		}
+		if exponent < len(runes) && isASCIIDigit(runes[exponent]) {
+			end = digits(exponent)
+		}
+	}
+	return max(end, start+1)
+}
+
+// normalizeGraphQL returns a representation of document that does not depend on whitespace, commas and comments
+func normalizeGraphQL(document string) string {
+	return strings.Join(graphqlTokens(document), " ")
+}
+
+// graphqlOperations returns the operations defined by a tokenized document, fragments are skipped
+func graphqlOperations(tokens []string) []graphqlOperation {
+	operations := []graphqlOperation{}
+	depth := 0
+	// parentheses counts the open parentheses, the keywords inside them are names of variables or arguments
+	parentheses := 0
+	for i, token := range tokens {
+		switch token {
+		case "{":
+			// a selection set at the top level without a keyword is an anonymous query
+			if depth == 0 && parentheses == 0 && (i == 0 || tokens[i-1] == "}") {
+				operations = append(operations, graphqlOperation{operationType: "query"})
+			}
+			depth++
+		case "}":
+			depth--
+		case "(":
+			parentheses++
+		case ")":
+			parentheses--
+		case "query", "mutation", "subscription":
+			// a keyword preceded by $ is the name of a variable
+			if depth != 0 || parentheses != 0 || (i > 0 && tokens[i-1] == "$") {
+				continue
+			}
+			operation := graphqlOperation{operationType: token}
+			if i+1 < len(tokens) && isGraphQLName(tokens[i+1]) {
+				operation.name = tokens[i+1]
+			}
+			operations = append(operations, operation)
+		}
+	}
+	return operations
+}
+
+// isGraphQLName returns true if token is a GraphQL name, that is if it matches /[_A-Za-z][_0-9A-Za-z]*/
+func isGraphQLName(token string) bool {
+	for i, c := range token {
+		if !(isGraphQLNameStart(c) || (i > 0 && isASCIIDigit(c))) {
+			return false
+		}
+	}
+	return token != ""
+}
+
+// graphqlRule defines which part of a GraphQL operation is compared with the expected value
+type graphqlRule string
+
+// These constants are the rules that can be used to match a GraphQL operation, they are also used as names of the criteria in the reports
+const (
+	graphqlOperationName   = graphqlRule("GraphQL operation name")
+	graphqlOperationType   = graphqlRule("GraphQL operation type")
+	graphqlQuery           = graphqlRule("GraphQL query")
+	graphqlVariables       = graphqlRule("GraphQL variables")
+	graphqlVariablesSubset = graphqlRule("GraphQL variables subset")
+)
+
+// graphqlCriterion is a condition that a registered Request places on the GraphQL operation sent by an incoming request
+type graphqlCriterion struct {
+	rule  graphqlRule
+	value string
+	// variables are the expected variables, decoded from JSON so that they can be compared with the ones of the incoming request
+	variables map[string]any
+}
+
+// expected returns the human readable version of what the criterion expects
+func (c graphqlCriterion) expected() string {
+	switch c.rule {
+	case graphqlVariables:
+		return compactJSON(c.variables)
+	case graphqlVariablesSubset:
+		return "containing " + compactJSON(c.variables)
+	default:
+		return c.value
+	}
+}
+
+// failure returns the human readable explanation of why the criterion is not satisfied
+func (c graphqlCriterion) failure() string {
+	switch c.rule {
+	case graphqlVariables:
+		return fmt.Sprintf("the GraphQL variables are not equal to %s", compactJSON(c.variables))
+	case graphqlVariablesSubset:
+		return fmt.Sprintf("the GraphQL variables do not contain %s", compactJSON(c.variables))
+	case graphqlQuery:
+		return fmt.Sprintf("the GraphQL query is not %q", truncate(c.value))
+	default:
+		return fmt.Sprintf("the %s is not %q", c.rule, c.value)
+	}
+}
+
+// evaluate checks the criterion against the incoming request r whose body was already read into body
+func (c graphqlCriterion) evaluate(r *http.Request, body []byte) criterionResult {
+	name := string(c.rule)
+	if c.rule == graphqlVariablesSubset {
+		name = string(graphqlVariables)
+	}
+
+	operation, err := parseGraphQLRequest(r, body)
+	if err != nil {
+		result := newCriterionResult(name, c.expected(), truncate(string(body)), false, graphqlDoesNotMatch)
+		result.missDetail = err.Error()
+		return result
+	}
+
+	var actual string
+	var details []string
+	switch c.rule {
+	case graphqlOperationName, graphqlOperationType:
+		selected, err := operation.selectedOperation()
+		if err != nil {
+			result := newCriterionResult(name, c.expected(), truncate(operation.Query), false, graphqlDoesNotMatch)
+			result.missDetail = err.Error()
+			return result
+		}
+		actual = selected.operationType
+		if c.rule == graphqlOperationName {
+			actual = selected.name
+		}
+	case graphqlQuery:
+		actual = normalizeGraphQL(operation.Query)
+	case graphqlVariables:
+		actual = compactJSON(operation.Variables)
+		details = jsonDiff("$", c.variables, normalizeJSONValue(operation.Variables))
+	case graphqlVariablesSubset:
+		actual = compactJSON(operation.Variables)
+		details = jsonSubsetDiff("$", c.variables, normalizeJSONValue(operation.Variables))
+	}
+
+	matched := len(details) == 0 && (c.rule == graphqlVariables || c.rule == graphqlVariablesSubset || actual == c.value)
+	result := newCriterionResult(name, c.expected(), actual, matched, graphqlDoesNotMatch)
+	if !matched {
+		result.details = details
+	}
+	result.missDetail = c.failure()
+	return result
+}
+
+// normalizeJSONValue encodes v to JSON and decodes it again so that it contains only the types produced by encoding/json.
+// This method panics if v cannot be converted to JSON
+func normalizeJSONValue(v any) map[string]any {
+	normalized := map[string]any{}
+	if err := json.Unmarshal(mustMarshalJSON(v), &normalized); err != nil {
+		panic(fmt.Sprintf("value is not a JSON object: %s", err))
+	}
+	return normalized
+}
+
+// withGraphQLCriterion returns a copy of criteria with criterion added, the criteria with the same rule are replaced.
+// Since there can be only one criterion per rule, the criteria are kept in a fixed order
+func withGraphQLCriterion(criteria []graphqlCriterion, criterion graphqlCriterion) []graphqlCriterion {
+	newCriteria := make([]graphqlCriterion, 0, len(criteria)+1)
+	for _, rule := range []graphqlRule{graphqlOperationName, graphqlOperationType, graphqlQuery, graphqlVariables, graphqlVariablesSubset} {
+		if rule == criterion.rule {
+			newCriteria = append(newCriteria, criterion)
+			continue
+		}
+		for _, c := range criteria {
+			if c.rule == rule {
+				newCriteria = append(newCriteria, c)
+			}
+		}
+	}
+	return newCriteria
+}
+
+// WithGraphQLOperationName returns a new request that matches only if the incoming request executes the GraphQL operation called name.
+// The name is the operationName sent with the request or, if that is missing, the name of the only operation in the document
+//
+//	NewRequest().
+//		WithMethod(http.MethodPost).
+//		WithURL("/graphql").
+//		WithGraphQLOperationName("GetUser").
+//		WithGraphQLVariablesSubset(map[string]any{"id": 1})
+func (r Request) WithGraphQLOperationName(name string) Request {
+	r.graphql = withGraphQLCriterion(r.graphql, graphqlCriterion{rule: graphqlOperationName, value: name})
+	return r
+}
+
+// WithGraphQLOperationType returns a new request that matches only if the incoming request executes a GraphQL operation of type
+// operationType, that is "query", "mutation" or "subscription"
+func (r Request) WithGraphQLOperationType(operationType string) Request {
+	r.graphql = withGraphQLCriterion(r.graphql, graphqlCriterion{rule: graphqlOperationType, value: operationType})
+	return r
+}
+
+// WithGraphQLQuery returns a new request that matches only if the incoming request sends the GraphQL document query.
+// The documents are compared after removing whitespace, commas and comments, since they are not significant in GraphQL
+func (r Request) WithGraphQLQuery(query string) Request {
+	r.graphql = withGraphQLCriterion(r.graphql, graphqlCriterion{rule: graphqlQuery, value: normalizeGraphQL(query)})
+	return r
+}
+
+// WithGraphQLVariables returns a new request that matches only if the incoming request sends exactly the GraphQL variables variables.
+// variables are compared with the ones of the request after being encoded to JSON, so it can be a map or a struct.
+// This method panics if variables cannot be converted to a JSON object
+func (r Request) WithGraphQLVariables(variables any) Request {
+	r.graphql = withGraphQLCriterion(r.graphql, graphqlCriterion{rule: graphqlVariables, variables: normalizeJSONValue(variables)})
+	return r
+}
+
+// WithGraphQLVariablesSubset returns a new request that matches only if the GraphQL variables of the incoming request contain variables,
+// the variables and the fields of nested objects that are not in variables are ignored.
+// This method panics if variables cannot be converted to a JSON object
+func (r Request) WithGraphQLVariablesSubset(variables any) Request {
+	r.graphql = withGraphQLCriterion(r.graphql, graphqlCriterion{rule: graphqlVariablesSubset, variables: normalizeJSONValue(variables)})
+	return r
+}
+
+// GraphQLError is an error returned in the errors field of a GraphQL response
+type GraphQLError struct {
+	Message string `json:"message"`
+	// Path is the path of the field that caused the error, for example []any{"user", "friends", 1, "name"}
+	Path       []any             `json:"path,omitempty"`
+	Locations  []GraphQLLocation `json:"locations,omitempty"`
+	Extensions map[string]any    `json:"extensions,omitempty"`
+}
+
+// GraphQLLocation is a position in a GraphQL document
+type GraphQLLocation struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+// graphqlResponse is the envelope of a GraphQL response.
+// Data is the encoded data, it is nil if the data field must be omitted and the JSON null if the data is null
+type graphqlResponse struct {
+	Data   json.RawMessage `json:"data,omitempty"`
+	Errors []GraphQLError  `json:"errors,omitempty"`
+}
+
+// WithGraphQLData returns a new response whose body is the GraphQL envelope {"data": data}
+// and whose header `Content-Type` is set to `application/json`.
+// If data is nil the body is {"data": null}.
+// This method panics if data cannot be converted to JSON
+func (res Response) WithGraphQLData(data any) Response {
+	return res.WithJSONBody(graphqlResponse{Data: mustMarshalJSON(data)})
+}
+
+// WithGraphQLErrors returns a new response whose body is the GraphQL envelope {"errors": errors}, without any data,
+// and whose header `Content-Type` is set to `application/json`
+func (res Response) WithGraphQLErrors(errors ...GraphQLError) Response {
+	return res.WithGraphQLBody(nil, errors...)
+}
+
+// WithGraphQLBody returns a new response whose body is the GraphQL envelope {"data": data, "errors": errors}
+// and whose header `Content-Type` is set to `application/json`.
+// It can be used to return partial results, where the paths of the errors point to the fields that could not be resolved.
+// The data field is omitted if data is nil, and the errors field if there are no errors.
+// This method panics if data cannot be converted to JSON
+//
+//	NewResponse().WithGraphQLBody(
+//		map[string]any{"user": map[string]any{"name": "John", "avatar": nil}},
+//		httpregistry.GraphQLError{Message: "avatar service unavailable", Path: []any{"user", "avatar"}},
+//	)
+func (res Response) WithGraphQLBody(data any, errors ...GraphQLError) Response {
+	response := graphqlResponse{Errors: errors}
+	if data != nil {
+		response.Data = mustMarshalJSON(data)
+	}
+	return res.WithJSONBody(response)
+}
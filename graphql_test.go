@@ -0,0 +1,244 @@
+package httpregistry_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+
+	"github.com/dfioravanti/httpregistry"
+)
+
+const getUserQuery = `
+	# fetch a user and its friends
+	query GetUser($id: ID!, $first: Int = 10) {
+		user(id: $id) {
+			name,
+			friends(first: $first) { name }
+		}
+	}`
+
+func (s *TestSuite) TestGraphQLMatching() {
+	testCases := []struct {
+		name        string
+		request     httpregistry.Request
+		shouldMatch bool
+		expectedWhy string
+	}{
+		{
+			name:        "operation name",
+			request:     httpregistry.NewRequest().WithGraphQLOperationName("GetUser"),
+			shouldMatch: true,
+		},
+		{
+			name:        "different operation name",
+			request:     httpregistry.NewRequest().WithGraphQLOperationName("GetUsers"),
+			expectedWhy: `mock request #1 missed because the GraphQL operation does not match: the GraphQL operation name is not "GetUsers"`,
+		},
+		{
+			name:        "operation type",
+			request:     httpregistry.NewRequest().WithGraphQLOperationType("query"),
+			shouldMatch: true,
+		},
+		{
+			name:        "different operation type",
+			request:     httpregistry.NewRequest().WithGraphQLOperationType("mutation"),
+			expectedWhy: `mock request #1 missed because the GraphQL operation does not match: the GraphQL operation type is not "mutation"`,
+		},
+		{
+			name: "query with different formatting",
+			request: httpregistry.NewRequest().WithGraphQLQuery(
+				`query GetUser($id: ID!, $first: Int = 10) { user(id: $id) { name friends(first: $first) { name } } }`,
+			),
+			shouldMatch: true,
+		},
+		{
+			name:        "different query",
+			request:     httpregistry.NewRequest().WithGraphQLQuery(`query GetUser($id: ID!) { user(id: $id) { name } }`),
+			expectedWhy: `mock request #1 missed because the GraphQL operation does not match: the GraphQL query is not "query GetUser ( $ id : ID ! ) { user ( id : $ id ) { name } }"`,
+		},
+		{
+			name:        "exact variables",
+			request:     httpregistry.NewRequest().WithGraphQLVariables(map[string]any{"id": "42", "filter": map[string]any{"active": true}}),
+			shouldMatch: true,
+		},
+		{
+			name:        "variables are not exact",
+			request:     httpregistry.NewRequest().WithGraphQLVariables(map[string]any{"id": "42"}),
+			expectedWhy: `mock request #1 missed because the GraphQL operation does not match: the GraphQL variables are not equal to {"id":"42"}`,
+		},
+		{
+			name:        "variables subset",
+			request:     httpregistry.NewRequest().WithGraphQLVariablesSubset(map[string]any{"filter": map[string]any{"active": true}}),
+			shouldMatch: true,
+		},
+		{
+			name:        "variables are not a subset",
+			request:     httpregistry.NewRequest().WithGraphQLVariablesSubset(map[string]any{"id": "43"}),
+			expectedWhy: `mock request #1 missed because the GraphQL operation does not match: the GraphQL variables do not contain {"id":"43"}`,
+		},
+	}
+	for _, tc := range testCases {
+		s.Run(tc.name, func() {
+			mockT := httpregistry.NewMockTestingT()
+			registry := httpregistry.NewRegistry(mockT)
+			registry.AddRequest(tc.request.WithMethod(http.MethodPost).WithURL("/graphql"))
+
+			server := registry.GetServer()
+			defer server.Close()
+
+			body := mustMarshalJSON(map[string]any{
+				"query":     getUserQuery,
+				"variables": map[string]any{"id": "42", "filter": map[string]any{"active": true}},
+			})
+			res, err := http.Post(server.URL+"/graphql", "application/json", bytes.NewReader(body))
+			s.NoError(err)
+
+			if tc.shouldMatch {
+				s.Equal(http.StatusOK, res.StatusCode)
+				s.False(mockT.HasFailed)
+				return
+			}
+			s.Equal(http.StatusInternalServerError, res.StatusCode)
+			s.Equal(tc.expectedWhy, registry.Why())
+		})
+	}
+}
+
+func (s *TestSuite) TestGraphQLRegistrationsOnTheSameURLAreDistinguished() {
+	registry := httpregistry.NewRegistry(s.T())
+	registry.AddRequestWithResponse(
+		httpregistry.NewRequest().WithURL("/graphql").WithGraphQLOperationType("mutation"),
+		httpregistry.NewResponse().WithGraphQLData(map[string]any{"createUser": map[string]any{"id": "1"}}),
+	)
+	registry.AddRequestWithResponse(
+		httpregistry.NewRequest().WithURL("/graphql").WithGraphQLOperationName("GetUser"),
+		httpregistry.NewResponse().WithGraphQLData(map[string]any{"user": map[string]any{"name": "John"}}),
+	)
+
+	server := registry.GetServer()
+	defer server.Close()
+
+	// the document defines two operations, the one that is executed is selected by operationName
+	document := `query GetUser { user { name } } mutation CreateUser { createUser { id } }`
+	for operationName, expected := range map[string]string{
+		"GetUser":    `{"data":{"user":{"name":"John"}}}`,
+		"CreateUser": `{"data":{"createUser":{"id":"1"}}}`,
+	} {
+		body := mustMarshalJSON(map[string]any{"query": document, "operationName": operationName})
+		res, err := http.Post(server.URL+"/graphql", "application/json", bytes.NewReader(body))
+		s.NoError(err)
+		responseBody, err := io.ReadAll(res.Body)
+		s.NoError(err)
+		s.JSONEq(expected, string(responseBody))
+	}
+}
+
+func (s *TestSuite) TestGraphQLOverGET() {
+	mockT := httpregistry.NewMockTestingT()
+	registry := httpregistry.NewRegistry(mockT)
+	registry.AddRequest(
+		httpregistry.NewRequest().
+			WithGraphQLOperationName("GetUser").
+			WithGraphQLVariables(map[string]any{"id": "42"}),
+	)
+
+	server := registry.GetServer()
+	defer server.Close()
+
+	query := url.Values{"query": {getUserQuery}, "variables": {`{"id": "42"}`}}
+	res, err := http.Get(server.URL + "/graphql?" + query.Encode())
+	s.NoError(err)
+	s.Equal(http.StatusOK, res.StatusCode)
+	s.False(mockT.HasFailed)
+}
+
+func (s *TestSuite) TestGraphQLVariablesNamedLikeKeywordsAreNotOperations() {
+	mockT := httpregistry.NewMockTestingT()
+	registry := httpregistry.NewRegistry(mockT)
+	registry.AddRequest(httpregistry.NewRequest().WithGraphQLOperationName("Search").WithGraphQLOperationType("query"))
+
+	server := registry.GetServer()
+	defer server.Close()
+
+	// without an operationName the name is taken from the document, which must define a single operation
+	document := `query Search($query: String!, $mutation: Boolean) { search(query: $query, mutation: $mutation) { id } }`
+	body := mustMarshalJSON(map[string]any{"query": document, "variables": map[string]any{"query": "John"}})
+	res, err := http.Post(server.URL+"/graphql", "application/json", bytes.NewReader(body))
+	s.NoError(err)
+	s.Equal(http.StatusOK, res.StatusCode)
+	s.False(mockT.HasFailed)
+	s.Empty(registry.Why())
+}
+
+func (s *TestSuite) TestGraphQLCriteriaFailOnRequestsThatAreNotGraphQL() {
+	mockT := httpregistry.NewMockTestingT()
+	registry := httpregistry.NewRegistry(mockT)
+	registry.AddRequest(httpregistry.NewRequest().WithGraphQLOperationName("GetUser"))
+
+	server := registry.GetServer()
+	defer server.Close()
+
+	res, err := http.Post(server.URL, "application/json", bytes.NewReader([]byte(`{"name": "John"}`)))
+	s.NoError(err)
+	s.Equal(http.StatusInternalServerError, res.StatusCode)
+	s.Equal(`mock request #1 missed because the GraphQL operation does not match: the request does not contain a GraphQL query`, registry.Why())
+}
+
+func (s *TestSuite) TestGraphQLResponses() {
+	testCases := []struct {
+		name         string
+		response     httpregistry.Response
+		expectedBody string
+	}{
+		{
+			name:         "data",
+			response:     httpregistry.NewResponse().WithGraphQLData(map[string]any{"user": map[string]any{"name": "John"}}),
+			expectedBody: `{"data":{"user":{"name":"John"}}}`,
+		},
+		{
+			name:         "null data",
+			response:     httpregistry.NewResponse().WithGraphQLData(nil),
+			expectedBody: `{"data":null}`,
+		},
+		{
+			name: "errors",
+			response: httpregistry.NewResponse().WithGraphQLErrors(
+				httpregistry.GraphQLError{
+					Message:    "syntax error",
+					Locations:  []httpregistry.GraphQLLocation{{Line: 1, Column: 7}},
+					Extensions: map[string]any{"code": "GRAPHQL_PARSE_FAILED"},
+				},
+			),
+			expectedBody: `{"errors":[{"message":"syntax error","locations":[{"line":1,"column":7}],"extensions":{"code":"GRAPHQL_PARSE_FAILED"}}]}`,
+		},
+		{
+			name: "partial errors",
+			response: httpregistry.NewResponse().WithGraphQLBody(
+				map[string]any{"user": map[string]any{"name": "John", "friends": []any{map[string]any{"name": nil}}}},
+				httpregistry.GraphQLError{Message: "friend not found", Path: []any{"user", "friends", 0, "name"}},
+			),
+			expectedBody: `{"data":{"user":{"name":"John","friends":[{"name":null}]}},"errors":[{"message":"friend not found","path":["user","friends",0,"name"]}]}`,
+		},
+	}
+	for _, tc := range testCases {
+		s.Run(tc.name, func() {
+			registry := httpregistry.NewRegistry(s.T())
+			registry.AddResponse(tc.response)
+
+			server := registry.GetServer()
+			defer server.Close()
+
+			res, err := http.Post(server.URL, "application/json", nil)
+			s.NoError(err)
+			body, err := io.ReadAll(res.Body)
+			s.NoError(err)
+
+			s.Equal(http.StatusOK, res.StatusCode)
+			s.Equal("application/json", res.Header.Get("Content-Type"))
+			s.JSONEq(tc.expectedBody, string(body))
+			s.True(json.Valid(body))
+		})
+	}
+}
@@ -0,0 +1,79 @@
+package httpregistry
+
+func (s *TestSuite) TestGraphQLOperations() {
+	testCases := []struct {
+		name     string
+		document string
+		expected []graphqlOperation
+	}{
+		{
+			name:     "anonymous query",
+			document: `{ user { name } }`,
+			expected: []graphqlOperation{{operationType: "query"}},
+		},
+		{
+			name:     "named operations and fragments",
+			document: `query GetUser { user { ...F } } fragment F on User { name } mutation { update(input: {query: "mutation"}) { id } }`,
+			expected: []graphqlOperation{{operationType: "query", name: "GetUser"}, {operationType: "mutation"}},
+		},
+		{
+			name:     "variables with default objects",
+			document: `subscription OnEvent($filter: Filter = {type: "a"}) { event(filter: $filter) { id } }`,
+			expected: []graphqlOperation{{operationType: "subscription", name: "OnEvent"}},
+		},
+		{
+			name:     "variables named like keywords",
+			document: `query Search($query: String!, $mutation: Boolean = false) { search(query: $query, mutation: $mutation) { id } }`,
+			expected: []graphqlOperation{{operationType: "query", name: "Search"}},
+		},
+	}
+	for _, tc := range testCases {
+		s.Run(tc.name, func() {
+			s.Equal(tc.expected, graphqlOperations(graphqlTokens(tc.document)))
+		})
+	}
+}
+
+func (s *TestSuite) TestGraphQLTokens() {
+	testCases := []struct {
+		name     string
+		document string
+		expected []string
+	}{
+		{
+			name:     "names",
+			document: `{ _user2 { first_name } }`,
+			expected: []string{"{", "_user2", "{", "first_name", "}", "}"},
+		},
+		{
+			name:     "names cannot contain dots or dashes",
+			document: `{ user.name first-name }`,
+			expected: []string{"{", "user", ".", "name", "first", "-", "name", "}"},
+		},
+		{
+			name:     "names cannot start with a digit or contain non ASCII letters",
+			document: `{ 2fa café }`,
+			expected: []string{"{", "2", "fa", "caf", "é", "}"},
+		},
+		{
+			name:     "numbers",
+			document: `{ users(limit: -10, offset: 0, ratio: 1.5e-3, scale: 2E+2, weight: -0.25) }`,
+			expected: []string{
+				"{", "users", "(", "limit", ":", "-10", "offset", ":", "0", "ratio", ":", "1.5e-3", "scale", ":", "2E+2", "weight", ":", "-0.25", ")", "}",
+			},
+		},
+	}
+	for _, tc := range testCases {
+		s.Run(tc.name, func() {
+			s.Equal(tc.expected, graphqlTokens(tc.document))
+		})
+	}
+}
+
+func (s *TestSuite) TestNormalizeGraphQL() {
+	s.Equal(
+		normalizeGraphQL(`query { user(name: "John,  Smith") { id name } }`),
+		normalizeGraphQL("query {\n  # the user\n  user(name: \"John,  Smith\") {\n    id,\n    name\n  }\n}"),
+	)
+	s.NotEqual(normalizeGraphQL(`{ user(name: "John Smith") }`), normalizeGraphQL(`{ user(name: "John  Smith") }`))
+}
@@ -0,0 +1,15 @@
+module github.com/dfioravanti/httpregistry/grpcregistry
+
+go 1.24
+
+require (
+	github.com/dfioravanti/httpregistry v0.0.0-20261018123825-021c31724eba
+	github.com/stretchr/testify v1.10.0
+	google.golang.org/protobuf v1.36.12
+)
+
+require (
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
@@ -0,0 +1,308 @@
+// Package grpcregistry extends httpregistry with fakes for unary gRPC methods.
+// It lives in its own module so that the users of httpregistry that do not need gRPC do not depend on protobuf.
+//
+// A method is registered by its full name and the incoming message is decoded into the type of the expected message
+// and compared with proto.Equal. Responses carry either a message or a status code, optionally with details,
+// that is sent in the trailers like a real gRPC server does
+//
+//	reg := httpregistry.NewRegistry(t)
+//	sayHello := grpcregistry.NewRequest("/helloworld.Greeter/SayHello").
+//		WithBodyMatcher(grpcregistry.Message(&pb.HelloRequest{Name: "John"}))
+//	reg.AddRequestWithResponse(sayHello, grpcregistry.NewResponse(&pb.HelloReply{Message: "Hello John"}))
+//	server := grpcregistry.NewServer(reg)
+package grpcregistry
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/dfioravanti/httpregistry"
+	"google.golang.org/protobuf/encoding/prototext"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+// Code is the status code of a gRPC call
+type Code uint32
+
+// These constants are the status codes defined by gRPC
+const (
+	OK                 Code = 0
+	Canceled           Code = 1
+	Unknown            Code = 2
+	InvalidArgument    Code = 3
+	DeadlineExceeded   Code = 4
+	NotFound           Code = 5
+	AlreadyExists      Code = 6
+	PermissionDenied   Code = 7
+	ResourceExhausted  Code = 8
+	FailedPrecondition Code = 9
+	Aborted            Code = 10
+	OutOfRange         Code = 11
+	Unimplemented      Code = 12
+	Internal           Code = 13
+	Unavailable        Code = 14
+	DataLoss           Code = 15
+	Unauthenticated    Code = 16
+)
+
+// frameHeaderLength is the length of the prefix of a gRPC message, one byte for the compression flag and four for the length
+const frameHeaderLength = 5
+
+// contentType is the Content-Type of the gRPC requests and responses
+const contentType = "application/grpc"
+
+// maxLengthInReports is the maximum length of the messages shown in the reports of the registry
+const maxLengthInReports = 200
+
+// frame returns message prefixed by the gRPC message header
+func frame(message []byte) []byte {
+	framed := make([]byte, frameHeaderLength, frameHeaderLength+len(message))
+	binary.BigEndian.PutUint32(framed[1:], uint32(len(message)))
+	return append(framed, message...)
+}
+
+// unframe returns the message contained in body, a unary gRPC request must contain exactly one uncompressed message
+func unframe(body []byte) ([]byte, error) {
+	if len(body) < frameHeaderLength {
+		return nil, errors.New("the body is not a gRPC message")
+	}
+	if body[0] != 0 {
+		return nil, errors.New("compressed gRPC messages are not supported")
+	}
+	length := binary.BigEndian.Uint32(body[1:frameHeaderLength])
+	if int(length) != len(body)-frameHeaderLength {
+		return nil, fmt.Errorf("the body does not contain exactly one gRPC message of %d bytes", length)
+	}
+	return body[frameHeaderLength:], nil
+}
+
+// mustMarshal marshals message deterministically and panics if it cannot
+func mustMarshal(message proto.Message) []byte {
+	b, err := proto.MarshalOptions{Deterministic: true}.Marshal(message)
+	if err != nil {
+		panic(fmt.Sprintf("message cannot be marshaled to protobuf: %s", err))
+	}
+	return b
+}
+
+// truncate shortens s so that it can be shown in the reports of the registry
+func truncate(s string) string {
+	if len(s) <= maxLengthInReports {
+		return s
+	}
+	return s[:maxLengthInReports] + "..."
+}
+
+// protoText returns the compact text representation of message, it is used in the reports
+func protoText(message proto.Message) string {
+	return truncate(prototext.MarshalOptions{}.Format(message))
+}
+
+// NewRequest returns a new request that matches only the gRPC calls of the method fullMethod, for example "/helloworld.Greeter/SayHello".
+// The leading slash is optional. gRPC calls are POST requests with the Content-Type `application/grpc`,
+// so the request is set to match the method POST, the path of fullMethod and the Content-Type prefix
+//
+//	grpcregistry.NewRequest("/helloworld.Greeter/SayHello").
+//		WithBodyMatcher(grpcregistry.Message(&pb.HelloRequest{Name: "John"}))
+func NewRequest(fullMethod string) httpregistry.Request {
+	fullMethod = "/" + strings.TrimPrefix(fullMethod, "/")
+	return httpregistry.NewRequest().
+		WithMethod(http.MethodPost).
+		WithURL("^"+regexp.QuoteMeta(fullMethod)+"$").
+		WithHeaderPrefix("Content-Type", contentType)
+}
+
+// messageMatcher is a httpregistry.BodyMatcher that checks the protobuf message sent by an incoming gRPC call
+type messageMatcher struct {
+	messageType protoreflect.MessageType
+	// message is the deterministic encoding of the expected message, it is stored encoded so that requests can be compared with reflect.DeepEqual
+	message []byte
+}
+
+// Message returns a httpregistry.BodyMatcher that matches only if the body contains a protobuf message equal to message according to proto.Equal.
+// The body of the incoming request is decoded into the type of message.
+// This function panics if message cannot be marshaled
+func Message(message proto.Message) httpregistry.BodyMatcher {
+	return messageMatcher{messageType: message.ProtoReflect().Type(), message: mustMarshal(message)}
+}
+
+// expectedMessage decodes the expected message
+func (m messageMatcher) expectedMessage() proto.Message {
+	message := m.messageType.New().Interface()
+	if err := proto.Unmarshal(m.message, message); err != nil {
+		panic(fmt.Errorf("cannot decode a message that was encoded by the registry: %w", err))
+	}
+	return message
+}
+
+// Name returns the name of the matcher used in the reports of the registry
+func (m messageMatcher) Name() string {
+	return "gRPC message"
+}
+
+// Expected returns the text representation of the expected message
+func (m messageMatcher) Expected() string {
+	return protoText(m.expectedMessage())
+}
+
+// Match decodes the message in body and compares it with the expected message
+func (m messageMatcher) Match(_ *http.Request, body []byte) (string, bool, string) {
+	payload, err := unframe(body)
+	if err != nil {
+		return truncate(string(body)), false, err.Error()
+	}
+	actual := m.messageType.New().Interface()
+	if err := proto.Unmarshal(payload, actual); err != nil {
+		return truncate(string(payload)), false, fmt.Sprintf("the message is not a %s: %s", m.messageType.Descriptor().FullName(), err)
+	}
+
+	expected := m.expectedMessage()
+	return protoText(actual), proto.Equal(expected, actual), fmt.Sprintf("the message is not equal to {%s}", protoText(expected))
+}
+
+// DecodeMessages decodes the gRPC message in the body of each request in requests into a T.
+// It is designed to be used with the requests returned by functions like Registry.GetMatchesForRequest
+//
+//	messages := grpcregistry.DecodeMessages[*pb.HelloRequest](t, reg.GetMatchesForRequest(sayHello))
+//
+// If a body cannot be decoded the test is failed and the corresponding element is nil,
+// so that the i-th element always refers to the i-th request.
+// The bodies of the requests are not consumed, so they can be accessed again.
+func DecodeMessages[T proto.Message](t httpregistry.TestingT, requests []*http.Request) []T {
+	var zero T
+	messageType := zero.ProtoReflect().Type()
+	decoded := make([]T, len(requests))
+	for i, r := range requests {
+		payload, err := unframe(readBody(r))
+		if err == nil {
+			message := messageType.New().Interface()
+			if err = proto.Unmarshal(payload, message); err == nil {
+				decoded[i] = message.(T)
+				continue
+			}
+		}
+		t.Errorf("the body of call %d to %v %v cannot be decoded as gRPC: %v", i, requests[i].Method, requests[i].URL, err)
+	}
+	return decoded
+}
+
+// readBody reads the body of r and replaces it with a copy so that it can be read again
+func readBody(r *http.Request) []byte {
+	if r.Body == nil {
+		return []byte{}
+	}
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		panic(fmt.Errorf("cannot read the body of the request: %w", err))
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	return body
+}
+
+// reply is what a response returns to a gRPC call
+type reply struct {
+	// message is the encoded response message, it is nil if the call fails
+	message []byte
+	code    Code
+	status  string
+	// details is the encoded google.rpc.Status sent in the grpc-status-details-bin trailer, it is nil if there are no details
+	details []byte
+}
+
+// serve emits the reply, the status of the call is sent in the trailers as required by the gRPC protocol
+func (reply reply) serve(w http.ResponseWriter) {
+	trailers := http.Header{}
+	trailers.Set("Grpc-Status", fmt.Sprint(uint32(reply.code)))
+	if reply.status != "" {
+		trailers.Set("Grpc-Message", encodeMessage(reply.status))
+	}
+	if reply.details != nil {
+		trailers.Set("Grpc-Status-Details-Bin", base64.RawStdEncoding.EncodeToString(reply.details))
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	for name := range trailers {
+		w.Header().Add("Trailer", name)
+	}
+	w.WriteHeader(http.StatusOK)
+	if reply.message != nil {
+		if _, err := w.Write(frame(reply.message)); err != nil {
+			panic("cannot write body of request")
+		}
+	}
+	for name, values := range trailers {
+		for _, value := range values {
+			w.Header().Add(name, value)
+		}
+	}
+}
+
+// encodeMessage percent-encodes message as required for the grpc-message trailer
+func encodeMessage(message string) string {
+	var b strings.Builder
+	for _, c := range []byte(message) {
+		if c < ' ' || c > '~' || c == '%' {
+			fmt.Fprintf(&b, "%%%02X", c)
+			continue
+		}
+		b.WriteByte(c)
+	}
+	return b.String()
+}
+
+// encodeStatus encodes a google.rpc.Status message with code, message and details
+func encodeStatus(code Code, message string, details []proto.Message) []byte {
+	b := protowire.AppendTag(nil, 1, protowire.VarintType)
+	b = protowire.AppendVarint(b, uint64(code))
+	b = protowire.AppendTag(b, 2, protowire.BytesType)
+	b = protowire.AppendString(b, message)
+	for _, detail := range details {
+		packed, err := anypb.New(detail)
+		if err != nil {
+			panic(fmt.Sprintf("detail cannot be packed: %s", err))
+		}
+		b = protowire.AppendTag(b, 3, protowire.BytesType)
+		b = protowire.AppendBytes(b, mustMarshal(packed))
+	}
+	return b
+}
+
+// NewResponse returns a response that answers a gRPC call with message and the status OK.
+// This function panics if message cannot be marshaled
+//
+//	reg.AddRequestWithResponse(
+//		grpcregistry.NewRequest("/helloworld.Greeter/SayHello"),
+//		grpcregistry.NewResponse(&pb.HelloReply{Message: "Hello John"}),
+//	)
+func NewResponse(message proto.Message) httpregistry.CustomResponse {
+	r := reply{message: mustMarshal(message), code: OK}
+	return httpregistry.NewCustomResponse(func(w http.ResponseWriter, _ *http.Request) {
+		r.serve(w)
+	})
+}
+
+// NewErrorResponse returns a response that fails a gRPC call with code and message.
+// The details, if any, are packed into google.protobuf.Any messages and sent in the grpc-status-details-bin trailer
+// as a google.rpc.Status, so that clients can read them with status.FromError(err).Details().
+// This function panics if a detail cannot be marshaled
+//
+//	grpcregistry.NewErrorResponse(grpcregistry.NotFound, "user not found")
+func NewErrorResponse(code Code, message string, details ...proto.Message) httpregistry.CustomResponse {
+	r := reply{code: code, status: message}
+	if len(details) > 0 {
+		r.details = encodeStatus(code, message, details)
+	}
+	return httpregistry.NewCustomResponse(func(w http.ResponseWriter, _ *http.Request) {
+		r.serve(w)
+	})
+}
@@ -0,0 +1,192 @@
+package grpcregistry_test
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/dfioravanti/httpregistry"
+	"github.com/dfioravanti/httpregistry/grpcregistry"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+// grpcResult is what a gRPC client receives from a unary call
+type grpcResult struct {
+	httpStatus int
+	protocol   string
+	message    []byte
+	trailer    http.Header
+}
+
+// callGRPC executes a unary gRPC call of fullMethod with message over HTTP/2 without TLS
+func callGRPC(s *TestSuite, url string, fullMethod string, message proto.Message) grpcResult {
+	payload, err := proto.Marshal(message)
+	s.NoError(err)
+	frame := make([]byte, 5, 5+len(payload))
+	binary.BigEndian.PutUint32(frame[1:], uint32(len(payload)))
+	frame = append(frame, payload...)
+
+	request, err := http.NewRequest(http.MethodPost, url+fullMethod, bytes.NewReader(frame))
+	s.NoError(err)
+	request.Header.Set("Content-Type", "application/grpc")
+	request.Header.Set("Te", "trailers")
+
+	protocols := &http.Protocols{}
+	protocols.SetUnencryptedHTTP2(true)
+	client := &http.Client{Transport: &http.Transport{Protocols: protocols}}
+	res, err := client.Do(request)
+	s.NoError(err)
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(res.Body)
+	s.NoError(err)
+	result := grpcResult{httpStatus: res.StatusCode, protocol: res.Proto, trailer: res.Trailer}
+	if len(body) >= 5 {
+		result.message = body[5:]
+	}
+	return result
+}
+
+func (s *TestSuite) TestUnaryCall() {
+	registry := httpregistry.NewRegistry(s.T())
+	sayHello := grpcregistry.NewRequest("helloworld.Greeter/SayHello").
+		WithBodyMatcher(grpcregistry.Message(wrapperspb.String("John")))
+	registry.AddRequestWithResponse(sayHello, grpcregistry.NewResponse(wrapperspb.String("Hello John")))
+
+	server := grpcregistry.NewServer(registry)
+	defer server.Close()
+
+	result := callGRPC(s, server.URL, "/helloworld.Greeter/SayHello", wrapperspb.String("John"))
+	s.Equal("HTTP/2.0", result.protocol)
+	s.Equal(http.StatusOK, result.httpStatus)
+	s.Equal("0", result.trailer.Get("Grpc-Status"))
+
+	reply := &wrapperspb.StringValue{}
+	s.NoError(proto.Unmarshal(result.message, reply))
+	s.Equal("Hello John", reply.GetValue())
+
+	messages := grpcregistry.DecodeMessages[*wrapperspb.StringValue](s.T(), registry.GetMatchesForRequest(sayHello))
+	s.Len(messages, 1)
+	s.True(proto.Equal(wrapperspb.String("John"), messages[0]))
+}
+
+func (s *TestSuite) TestUnmatchedCallsReceiveAGRPCStatus() {
+	mockT := httpregistry.NewMockTestingT()
+	registry := httpregistry.NewRegistry(mockT)
+	registry.AddRequest(
+		grpcregistry.NewRequest("/helloworld.Greeter/SayHello").
+			WithBodyMatcher(grpcregistry.Message(wrapperspb.String("John"))),
+	)
+
+	server := grpcregistry.NewServer(registry)
+	defer server.Close()
+
+	result := callGRPC(s, server.URL, "/helloworld.Greeter/SayHello", wrapperspb.String("Jane"))
+	s.Equal(http.StatusOK, result.httpStatus)
+	s.Empty(result.message)
+	s.Equal("12", result.trailer.Get("Grpc-Status"))
+	s.Equal(
+		"no registered request matched /helloworld.Greeter/SayHello: mock request #1 differs in gRPC message",
+		result.trailer.Get("Grpc-Message"),
+	)
+	s.Equal(
+		`mock request #1 missed because the gRPC message does not match: the message is not equal to {value:"John"}`,
+		registry.Why(),
+	)
+
+	result = callGRPC(s, server.URL, "/helloworld.Greeter/SayGoodbye", wrapperspb.String("John"))
+	s.Equal("12", result.trailer.Get("Grpc-Status"))
+	s.Equal(`mock request #1 missed because the path does not match`, registry.Why())
+	s.Len(mockT.Messages, 2)
+}
+
+func (s *TestSuite) TestUnmatchedHTTPCallsReceiveTheMissReport() {
+	mockT := httpregistry.NewMockTestingT()
+	registry := httpregistry.NewRegistry(mockT)
+	registry.AddRequest(grpcregistry.NewRequest("/helloworld.Greeter/SayHello"))
+
+	server := grpcregistry.NewServer(registry)
+	defer server.Close()
+
+	res, err := http.Get(server.URL + "/users")
+	s.NoError(err)
+	defer res.Body.Close()
+	s.Equal(http.StatusInternalServerError, res.StatusCode)
+	s.Equal("application/json", res.Header.Get("Content-Type"))
+}
+
+func (s *TestSuite) TestErrorResponseWithDetails() {
+	registry := httpregistry.NewRegistry(s.T())
+	registry.AddRequestWithResponse(
+		grpcregistry.NewRequest("/users.Users/Get"),
+		grpcregistry.NewErrorResponse(grpcregistry.Unavailable, "try again in 5%", durationpb.New(5*time.Second)),
+	)
+
+	server := grpcregistry.NewServer(registry)
+	defer server.Close()
+
+	result := callGRPC(s, server.URL, "/users.Users/Get", wrapperspb.String("1"))
+	s.Equal(http.StatusOK, result.httpStatus)
+	s.Empty(result.message)
+	s.Equal("14", result.trailer.Get("Grpc-Status"))
+	s.Equal("try again in 5%25", result.trailer.Get("Grpc-Message"))
+
+	// google.rpc.Status: code = 1, message = 2, details = 3
+	status, err := base64.RawStdEncoding.DecodeString(result.trailer.Get("Grpc-Status-Details-Bin"))
+	s.NoError(err)
+	details := []*anypb.Any{}
+	for len(status) > 0 {
+		number, wireType, n := protowire.ConsumeTag(status)
+		s.GreaterOrEqual(n, 0)
+		status = status[n:]
+		n = protowire.ConsumeFieldValue(number, wireType, status)
+		s.GreaterOrEqual(n, 0)
+		if number == 3 {
+			value, _ := protowire.ConsumeBytes(status)
+			detail := &anypb.Any{}
+			s.NoError(proto.Unmarshal(value, detail))
+			details = append(details, detail)
+		}
+		status = status[n:]
+	}
+	s.Len(details, 1)
+	delay := &durationpb.Duration{}
+	s.NoError(details[0].UnmarshalTo(delay))
+	s.Equal(5*time.Second, delay.AsDuration())
+}
+
+func (s *TestSuite) TestRequestsCanBeCompared() {
+	first := grpcregistry.NewRequest("/a.B/C").WithBodyMatcher(grpcregistry.Message(wrapperspb.String("x")))
+	second := grpcregistry.NewRequest("a.B/C").WithBodyMatcher(grpcregistry.Message(wrapperspb.String("x")))
+	third := grpcregistry.NewRequest("/a.B/C").WithBodyMatcher(grpcregistry.Message(wrapperspb.String("y")))
+
+	s.True(first.Equal(second))
+	s.False(first.Equal(third))
+}
+
+func (s *TestSuite) TestDecodeMessagesKeepsTheIndexes() {
+	valid, err := proto.Marshal(wrapperspb.String("John"))
+	s.NoError(err)
+	framed := append([]byte{0, 0, 0, 0, byte(len(valid))}, valid...)
+
+	requests := []*http.Request{}
+	for _, body := range [][]byte{[]byte("not gRPC"), framed} {
+		r, err := http.NewRequest(http.MethodPost, "/helloworld.Greeter/SayHello", bytes.NewReader(body))
+		s.NoError(err)
+		requests = append(requests, r)
+	}
+
+	mockT := httpregistry.NewMockTestingT()
+	messages := grpcregistry.DecodeMessages[*wrapperspb.StringValue](mockT, requests)
+	s.Len(messages, 2)
+	s.Nil(messages[0])
+	s.True(proto.Equal(wrapperspb.String("John"), messages[1]))
+	s.Len(mockT.Messages, 1)
+}
@@ -0,0 +1,60 @@
+package grpcregistry
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	"github.com/dfioravanti/httpregistry"
+)
+
+// NewServer returns a httptest.Server that serves the requests registered with reg.
+// The server accepts both HTTP/1.1 and HTTP/2 without TLS (h2c), the latter is needed to serve gRPC calls.
+// It also sets ServeMiss as the miss handler of reg, so that the gRPC calls that do not match receive a gRPC status
+//
+//	server := grpcregistry.NewServer(reg)
+//	conn, err := grpc.NewClient(strings.TrimPrefix(server.URL, "http://"), grpc.WithTransportCredentials(insecure.NewCredentials()))
+func NewServer(reg *httpregistry.Registry) *httptest.Server {
+	reg.SetMissHandler(ServeMiss)
+	server := httptest.NewUnstartedServer(reg.Handler())
+	server.Config.Protocols = &http.Protocols{}
+	server.Config.Protocols.SetHTTP1(true)
+	server.Config.Protocols.SetUnencryptedHTTP2(true)
+	server.Start()
+	return server
+}
+
+// ServeMiss is a httpregistry.MissHandler that answers the gRPC calls that do not match any registered request
+// with the status Unimplemented, like a real server does for the methods that it does not know.
+// The message of the status lists the criteria that did not match for each registered request.
+// Requests that are not gRPC calls are answered by httpregistry.ServeMissReport
+func ServeMiss(w http.ResponseWriter, r *http.Request, report httpregistry.MissReport) {
+	if !strings.HasPrefix(r.Header.Get("Content-Type"), contentType) {
+		httpregistry.ServeMissReport(w, r, report)
+		return
+	}
+	reply{code: Unimplemented, status: missMessage(report)}.serve(w)
+}
+
+// missMessage summarizes report in a single line that fits in the grpc-message trailer
+func missMessage(report httpregistry.MissReport) string {
+	reasons := []string{}
+	for _, registration := range report.Registrations {
+		failed := []string{}
+		for _, criterion := range registration.Criteria {
+			if !criterion.Matched {
+				failed = append(failed, criterion.Criterion)
+			}
+		}
+		if len(failed) > 0 {
+			reasons = append(reasons, fmt.Sprintf("%s differs in %s", registration.Name, strings.Join(failed, ", ")))
+		}
+	}
+
+	message := "no registered request matched " + report.Request.URL
+	if len(reasons) > 0 {
+		message += ": " + strings.Join(reasons, "; ")
+	}
+	return message
+}
@@ -0,0 +1,146 @@
+package httpregistry
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// headerRule defines how the values of a header of an incoming request are compared with the expected value
+type headerRule string
+
+// These constants are the rules that can be used to match a header, they are also used to describe the expected value in the reports
+const (
+	headerEquals    = headerRule("equal to")
+	headerContains  = headerRule("containing")
+	headerHasPrefix = headerRule("with prefix")
+	headerMatches   = headerRule("matching")
+	headerPresent   = headerRule("present")
+	headerAbsent    = headerRule("absent")
+	headerAllValues = headerRule("with exactly the values")
+)
+
+// headerCriterion is a condition that a registered Request places on a header of an incoming request.
+// Except for headerAbsent and headerAllValues, a repeated header satisfies the criterion if any of its values does.
+type headerCriterion struct {
+	header string
+	rule   headerRule
+	values []string
+	// regex is the compiled version of values[0] for headerMatches, it is compiled once when the criterion is created
+	regex *regexp.Regexp
+}
+
+// newHeaderCriterion creates a new headerCriterion, the name of the header is canonicalized so that the comparison is case insensitive
+func newHeaderCriterion(header string, rule headerRule, values ...string) headerCriterion {
+	if values == nil {
+		values = []string{}
+	}
+	return headerCriterion{
+		header: http.CanonicalHeaderKey(header),
+		rule:   rule,
+		values: values,
+	}
+}
+
+// expected returns the human readable version of what the criterion expects.
+// For headerEquals it is just the expected value to keep the reports easy to read
+func (c headerCriterion) expected() string {
+	switch c.rule {
+	case headerEquals:
+		return c.values[0]
+	case headerPresent, headerAbsent:
+		return string(c.rule)
+	case headerAllValues:
+		return fmt.Sprintf("%s %q", c.rule, c.values)
+	default:
+		return fmt.Sprintf("%s %q", c.rule, c.values[0])
+	}
+}
+
+// failure returns the human readable explanation of why the criterion is not satisfied
+func (c headerCriterion) failure() string {
+	switch c.rule {
+	case headerEquals:
+		return fmt.Sprintf("header %q is not equal to %q", c.header, c.values[0])
+	case headerContains:
+		return fmt.Sprintf("header %q does not contain %q", c.header, c.values[0])
+	case headerHasPrefix:
+		return fmt.Sprintf("header %q does not start with %q", c.header, c.values[0])
+	case headerMatches:
+		return fmt.Sprintf("header %q does not match %q", c.header, c.values[0])
+	case headerPresent:
+		return fmt.Sprintf("header %q is not present", c.header)
+	case headerAbsent:
+		return fmt.Sprintf("header %q is present but it should be absent", c.header)
+	default:
+		return fmt.Sprintf("header %q does not have exactly the values %q", c.header, c.values)
+	}
+}
+
+// isSatisfiedBy checks if values, the values of the header in the incoming request, satisfy the criterion
+func (c headerCriterion) isSatisfiedBy(values []string) bool {
+	switch c.rule {
+	case headerPresent:
+		return len(values) > 0
+	case headerAbsent:
+		return len(values) == 0
+	case headerAllValues:
+		return slices.Equal(c.values, values)
+	}
+
+	for _, value := range values {
+		var ok bool
+		switch c.rule {
+		case headerEquals:
+			ok = value == c.values[0]
+		case headerContains:
+			ok = strings.Contains(value, c.values[0])
+		case headerHasPrefix:
+			ok = strings.HasPrefix(value, c.values[0])
+		case headerMatches:
+			ok = c.regex.MatchString(value)
+		}
+		if ok {
+			return true
+		}
+	}
+	return false
+}
+
+// evaluate checks the criterion against the headers of the incoming request
+func (c headerCriterion) evaluate(headers http.Header) criterionResult {
+	values := headers.Values(c.header)
+	result := newCriterionResult(
+		fmt.Sprintf("header %q", c.header), c.expected(), strings.Join(values, ", "), c.isSatisfiedBy(values), headerDoesNotMatch,
+	)
+	result.missDetail = c.failure()
+	return result
+}
+
+// withHeaderCriterion returns a copy of criteria with criterion added.
+// If replace is true, the criteria with the same header and rule are removed first.
+// The criteria are kept sorted so that two requests with the same criteria are equal independently of the order in which they were added
+func withHeaderCriterion(criteria []headerCriterion, criterion headerCriterion, replace bool) []headerCriterion {
+	newCriteria := make([]headerCriterion, 0, len(criteria)+1)
+	for _, c := range criteria {
+		if replace && c.header == criterion.header && c.rule == criterion.rule {
+			continue
+		}
+		newCriteria = append(newCriteria, c)
+	}
+	newCriteria = append(newCriteria, criterion)
+
+	sort.SliceStable(newCriteria, func(i, j int) bool {
+		if newCriteria[i].header != newCriteria[j].header {
+			return newCriteria[i].header < newCriteria[j].header
+		}
+		if newCriteria[i].rule != newCriteria[j].rule {
+			return newCriteria[i].rule < newCriteria[j].rule
+		}
+		return strings.Join(newCriteria[i].values, "\x00") < strings.Join(newCriteria[j].values, "\x00")
+	})
+	return newCriteria
+}
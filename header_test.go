@@ -0,0 +1,155 @@
+package httpregistry_test
+
+import (
+	"net/http"
+
+	"github.com/dfioravanti/httpregistry"
+)
+
+func (s *TestSuite) TestHeaderRules() {
+	testCases := []struct {
+		name        string
+		request     httpregistry.Request
+		headers     http.Header
+		shouldMatch bool
+		expectedWhy string
+	}{
+		{
+			name:        "exact matches the second value of a repeated header",
+			request:     httpregistry.NewRequest().WithHeader("Accept", "text/plain"),
+			headers:     http.Header{"Accept": {"application/json", "text/plain"}},
+			shouldMatch: true,
+		},
+		{
+			name:        "exact fails",
+			request:     httpregistry.NewRequest().WithHeader("Accept", "text/plain"),
+			headers:     http.Header{"Accept": {"application/json"}},
+			expectedWhy: `mock request #1 missed because the header does not match: header "Accept" is not equal to "text/plain"`,
+		},
+		{
+			name:        "contains",
+			request:     httpregistry.NewRequest().WithHeaderContaining("Accept", "json"),
+			headers:     http.Header{"Accept": {"application/json"}},
+			shouldMatch: true,
+		},
+		{
+			name:        "contains fails",
+			request:     httpregistry.NewRequest().WithHeaderContaining("Accept", "xml"),
+			headers:     http.Header{"Accept": {"application/json"}},
+			expectedWhy: `mock request #1 missed because the header does not match: header "Accept" does not contain "xml"`,
+		},
+		{
+			name:        "prefix",
+			request:     httpregistry.NewRequest().WithHeaderPrefix("Authorization", "Bearer "),
+			headers:     http.Header{"Authorization": {"Bearer token"}},
+			shouldMatch: true,
+		},
+		{
+			name:        "prefix fails",
+			request:     httpregistry.NewRequest().WithHeaderPrefix("Authorization", "Bearer "),
+			headers:     http.Header{"Authorization": {"Basic dXNlcjpwYXNz"}},
+			expectedWhy: `mock request #1 missed because the header does not match: header "Authorization" does not start with "Bearer "`,
+		},
+		{
+			name:        "regex",
+			request:     httpregistry.NewRequest().WithHeaderMatching("X-Request-Id", "^[0-9a-f]{8}$"),
+			headers:     http.Header{"X-Request-Id": {"deadbeef"}},
+			shouldMatch: true,
+		},
+		{
+			name:        "regex fails",
+			request:     httpregistry.NewRequest().WithHeaderMatching("X-Request-Id", "^[0-9a-f]{8}$"),
+			headers:     http.Header{"X-Request-Id": {"not-an-id"}},
+			expectedWhy: `mock request #1 missed because the header does not match: header "X-Request-Id" does not match "^[0-9a-f]{8}$"`,
+		},
+		{
+			name:        "present",
+			request:     httpregistry.NewRequest().WithHeaderPresent("x-trace"),
+			headers:     http.Header{"X-Trace": {""}},
+			shouldMatch: true,
+		},
+		{
+			name:        "present fails",
+			request:     httpregistry.NewRequest().WithHeaderPresent("X-Trace"),
+			headers:     http.Header{},
+			expectedWhy: `mock request #1 missed because the header does not match: header "X-Trace" is not present`,
+		},
+		{
+			name:        "absent",
+			request:     httpregistry.NewRequest().WithHeaderAbsent("Authorization"),
+			headers:     http.Header{},
+			shouldMatch: true,
+		},
+		{
+			name:        "absent fails",
+			request:     httpregistry.NewRequest().WithHeaderAbsent("Authorization"),
+			headers:     http.Header{"Authorization": {"Bearer token"}},
+			expectedWhy: `mock request #1 missed because the header does not match: header "Authorization" is present but it should be absent`,
+		},
+		{
+			name:        "all values",
+			request:     httpregistry.NewRequest().WithHeaderValues("Link", "<a>", "<b>"),
+			headers:     http.Header{"Link": {"<a>", "<b>"}},
+			shouldMatch: true,
+		},
+		{
+			name:        "all values fails",
+			request:     httpregistry.NewRequest().WithHeaderValues("Link", "<a>", "<b>"),
+			headers:     http.Header{"Link": {"<a>"}},
+			expectedWhy: `mock request #1 missed because the header does not match: header "Link" does not have exactly the values ["<a>" "<b>"]`,
+		},
+		{
+			name: "only the failing rule is reported",
+			request: httpregistry.NewRequest().
+				WithHeaderPrefix("Authorization", "Bearer ").
+				WithHeaderAbsent("Cookie"),
+			headers:     http.Header{"Authorization": {"Bearer token"}, "Cookie": {"a=b"}},
+			expectedWhy: `mock request #1 missed because the header does not match: header "Cookie" is present but it should be absent`,
+		},
+	}
+	for _, tc := range testCases {
+		s.Run(tc.name, func() {
+			mockT := httpregistry.NewMockTestingT()
+			registry := httpregistry.NewRegistry(mockT)
+			registry.AddRequest(tc.request)
+
+			server := registry.GetServer()
+			defer server.Close()
+
+			request, err := http.NewRequest(http.MethodGet, server.URL, nil)
+			s.NoError(err)
+			request.Header = tc.headers
+
+			res, err := http.DefaultClient.Do(request)
+			s.NoError(err)
+
+			if tc.shouldMatch {
+				s.Equal(http.StatusOK, res.StatusCode)
+				s.False(mockT.HasFailed)
+				return
+			}
+			s.Equal(http.StatusInternalServerError, res.StatusCode)
+			s.Equal(tc.expectedWhy, registry.Why())
+		})
+	}
+}
+
+func (s *TestSuite) TestMatchingRulesPanicOnInvalidRegexes() {
+	s.PanicsWithValue(
+		"the regex \"(\" of the header \"Accept\" is not valid: error parsing regexp: missing closing ): `(`",
+		func() { httpregistry.NewRequest().WithHeaderMatching("accept", "(") },
+	)
+	s.PanicsWithValue(
+		"the regex \"[\" of the cookie \"session\" is not valid: error parsing regexp: missing closing ]: `[`",
+		func() { httpregistry.NewRequest().WithCookieMatching("session", "[") },
+	)
+}
+
+func (s *TestSuite) TestRequestsWithTheSameRegexesAreEqual() {
+	first := httpregistry.NewRequest().WithHeaderMatching("Accept", "json$").WithCookieMatching("session", "^[a-z]+$")
+	second := httpregistry.NewRequest().WithHeaderMatching("Accept", "json$").WithCookieMatching("session", "^[a-z]+$")
+	third := httpregistry.NewRequest().WithHeaderMatching("Accept", "xml$").WithCookieMatching("session", "^[a-z]+$")
+
+	s.True(first.Equal(second))
+	s.False(first.Equal(third))
+}
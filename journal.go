@@ -0,0 +1,137 @@
+package httpregistry
+
+import (
+	"net/http"
+	"regexp"
+	"time"
+)
+
+// JournalEntry records a request that reached the server created by a Registry together with the outcome of the matching.
+// Entries are created for every incoming request, independently of whether it matched or not,
+// so the journal can be used to understand what the code under test actually called.
+type JournalEntry struct {
+	// Timestamp is the moment in which the request reached the server
+	Timestamp time.Time
+	// Request is a clone of the incoming request, the body can be read without affecting the other entries
+	Request *http.Request
+	// Matched is true if one of the registered requests was used to serve the incoming request
+	Matched bool
+	// Registration is the registered request that served the incoming request.
+	// If Matched is false this is the zero value of Request
+	Registration Request
+	// ResponseName is the name of the response that was served.
+	// If Matched is false this is the empty string
+	ResponseName string
+	// Conditional is true if the request carried the header `If-None-Match` or `If-Modified-Since`,
+	// that is if the client was revalidating a cached response instead of fetching it again
+	Conditional bool
+	misses      []miss
+	report      MissReport
+}
+
+// Why returns a string that contains all the reasons why the registered requests did not match the request of the entry.
+// The format is the same one used by Registry.Why
+func (e JournalEntry) Why() string {
+	return missesToString(e.misses)
+}
+
+// Report returns the structured version of Why for the request of the entry.
+// If the request matched, the report contains no registrations
+func (e JournalEntry) Report() MissReport {
+	return e.report
+}
+
+// SetJournalLimit makes the registry keep only the last limit entries of the journal and the last limit requests matched by each registration,
+// the older ones are dropped. The number of calls of the registrations is still counted exactly.
+// This is useful for long running servers, like the httpregistry command, that would otherwise keep a copy of every request.
+// A limit of 0, the default, keeps every request. This method panics if limit is negative
+//
+//	reg := httpregistry.NewRegistry(t)
+//	reg.SetJournalLimit(1000)
+func (reg *Registry) SetJournalLimit(limit int) {
+	if limit < 0 {
+		panic("the limit of the journal cannot be negative")
+	}
+
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+	reg.journalLimit = limit
+}
+
+// JournalQuery is used to filter the entries of the journal of a Registry.
+// The zero value of a JournalQuery, as returned by NewJournalQuery, selects every entry.
+type JournalQuery struct {
+	method      string
+	pathAsRegex *regexp.Regexp
+	matched     *bool
+	conditional *bool
+}
+
+// NewJournalQuery creates a new JournalQuery that selects every entry of the journal.
+// This function is designed to be used in conjunction with other other receivers.
+// For example
+//
+//	NewJournalQuery().
+//		WithMethod(http.MethodPost).
+//		WithPath("/users/.+").
+//		OnlyUnmatched()
+func NewJournalQuery() JournalQuery {
+	return JournalQuery{}
+}
+
+// WithMethod returns a new query that selects only the entries whose request used method
+func (q JournalQuery) WithMethod(method string) JournalQuery {
+	q.method = method
+	return q
+}
+
+// WithPath returns a new query that selects only the entries whose request path matches path interpreted as a regex
+func (q JournalQuery) WithPath(path string) JournalQuery {
+	q.pathAsRegex = regexp.MustCompile(path)
+	return q
+}
+
+// OnlyMatched returns a new query that selects only the entries that matched a registered request
+func (q JournalQuery) OnlyMatched() JournalQuery {
+	matched := true
+	q.matched = &matched
+	return q
+}
+
+// OnlyUnmatched returns a new query that selects only the entries that did not match any registered request
+func (q JournalQuery) OnlyUnmatched() JournalQuery {
+	matched := false
+	q.matched = &matched
+	return q
+}
+
+// OnlyConditional returns a new query that selects only the entries whose request was conditional, see JournalEntry.Conditional
+func (q JournalQuery) OnlyConditional() JournalQuery {
+	conditional := true
+	q.conditional = &conditional
+	return q
+}
+
+// OnlyUnconditional returns a new query that selects only the entries whose request was not conditional
+func (q JournalQuery) OnlyUnconditional() JournalQuery {
+	conditional := false
+	q.conditional = &conditional
+	return q
+}
+
+// selects checks if entry satisfies all the conditions of the query
+func (q JournalQuery) selects(entry JournalEntry) bool {
+	if q.method != "" && q.method != entry.Request.Method {
+		return false
+	}
+	if q.pathAsRegex != nil && !q.pathAsRegex.MatchString(entry.Request.URL.Path) {
+		return false
+	}
+	if q.matched != nil && *q.matched != entry.Matched {
+		return false
+	}
+	if q.conditional != nil && *q.conditional != entry.Conditional {
+		return false
+	}
+	return true
+}
@@ -0,0 +1,128 @@
+package httpregistry_test
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+
+	"github.com/dfioravanti/httpregistry"
+)
+
+func (s *TestSuite) TestJournalRecordsMatchedAndUnmatchedRequests() {
+	mockT := httpregistry.NewMockTestingT()
+	registry := httpregistry.NewRegistry(mockT)
+	registry.AddRequestWithResponse(
+		httpregistry.NewRequest().WithMethod(http.MethodPost).WithURL("/users").WithName("create user"),
+		httpregistry.CreatedResponse,
+	)
+
+	server := registry.GetServer()
+	defer server.Close()
+	client := http.Client{}
+
+	res, err := client.Post(server.URL+"/users", "application/json", bytes.NewBufferString(`{"name": "John"}`))
+	s.NoError(err)
+	s.Equal(http.StatusCreated, res.StatusCode)
+
+	res, err = client.Get(server.URL + "/unknown")
+	s.NoError(err)
+	s.Equal(http.StatusInternalServerError, res.StatusCode)
+
+	entries := registry.GetJournal(httpregistry.NewJournalQuery())
+	s.Equal(2, len(entries))
+
+	s.True(entries[0].Matched)
+	s.Equal("create user", entries[0].Registration.String())
+	s.Equal("httpregistry.CreatedResponse", entries[0].ResponseName)
+	s.Empty(entries[0].Why())
+	body, err := io.ReadAll(entries[0].Request.Body)
+	s.NoError(err)
+	s.Equal(`{"name": "John"}`, string(body))
+
+	s.False(entries[1].Matched)
+	s.Equal("/unknown", entries[1].Request.URL.Path)
+	s.Empty(entries[1].ResponseName)
+	s.Equal("create user missed because the path does not match\ncreate user missed because the method does not match", entries[1].Why())
+	s.False(entries[0].Timestamp.After(entries[1].Timestamp))
+
+	// the misses of the previous entries are not lost when a new request comes in
+	res, err = client.Post(server.URL+"/users", "application/json", nil)
+	s.NoError(err)
+	s.Equal(http.StatusInternalServerError, res.StatusCode)
+	entries = registry.GetJournal(httpregistry.NewJournalQuery().OnlyUnmatched())
+	s.Equal(2, len(entries))
+	s.Equal("create user missed because the path does not match\ncreate user missed because the method does not match", entries[0].Why())
+	s.Equal("create user missed because the route matches but there was no response available", entries[1].Why())
+}
+
+func (s *TestSuite) TestJournalQueryFiltersEntries() {
+	registry := httpregistry.NewRegistry(s.T())
+	registry.AddInfiniteResponse(httpregistry.NoContentResponse)
+
+	server := registry.GetServer()
+	defer server.Close()
+	client := http.Client{}
+
+	for _, path := range []string{"/users/1", "/users/2", "/orders/1"} {
+		res, err := client.Get(server.URL + path)
+		s.NoError(err)
+		s.Equal(http.StatusNoContent, res.StatusCode)
+	}
+	req, err := http.NewRequest(http.MethodDelete, server.URL+"/users/1", nil)
+	s.NoError(err)
+	_, err = client.Do(req)
+	s.NoError(err)
+
+	testCases := []struct {
+		name          string
+		query         httpregistry.JournalQuery
+		expectedPaths []string
+	}{
+		{"everything", httpregistry.NewJournalQuery(), []string{"/users/1", "/users/2", "/orders/1", "/users/1"}},
+		{"method", httpregistry.NewJournalQuery().WithMethod(http.MethodDelete), []string{"/users/1"}},
+		{"path", httpregistry.NewJournalQuery().WithPath("^/users/"), []string{"/users/1", "/users/2", "/users/1"}},
+		{"method and path", httpregistry.NewJournalQuery().WithMethod(http.MethodGet).WithPath("/1$"), []string{"/users/1", "/orders/1"}},
+		{"matched", httpregistry.NewJournalQuery().OnlyMatched(), []string{"/users/1", "/users/2", "/orders/1", "/users/1"}},
+		{"unmatched", httpregistry.NewJournalQuery().OnlyUnmatched(), []string{}},
+	}
+	for _, tc := range testCases {
+		s.Run(tc.name, func() {
+			paths := []string{}
+			for _, entry := range registry.GetJournal(tc.query) {
+				paths = append(paths, entry.Request.URL.Path)
+			}
+			s.Equal(tc.expectedPaths, paths)
+		})
+	}
+}
+
+func (s *TestSuite) TestJournalLimitKeepsTheLastRequests() {
+	registry := httpregistry.NewRegistry(s.T())
+	registry.SetJournalLimit(2)
+	users := registry.AddRequestWithInfiniteResponse(httpregistry.NewRequest().WithURL("/users/.+"), httpregistry.NoContentResponse)
+
+	url := registry.GetServer().URL
+	for _, id := range []string{"1", "2", "3"} {
+		res, err := http.Get(url + "/users/" + id)
+		s.NoError(err)
+		s.Equal(http.StatusNoContent, res.StatusCode)
+	}
+
+	paths := []string{}
+	for _, entry := range registry.GetJournal(httpregistry.NewJournalQuery()) {
+		paths = append(paths, entry.Request.URL.Path)
+	}
+	s.Equal([]string{"/users/2", "/users/3"}, paths)
+
+	paths = []string{}
+	for _, match := range users.Matches() {
+		paths = append(paths, match.URL.Path)
+	}
+	s.Equal([]string{"/users/2", "/users/3"}, paths)
+	s.Equal(3, users.NumberOfCalls())
+}
+
+func (s *TestSuite) TestSetJournalLimitPanicsOnNegativeLimits() {
+	registry := httpregistry.NewRegistry(s.T())
+	s.PanicsWithValue("the limit of the journal cannot be negative", func() { registry.SetJournalLimit(-1) })
+}
@@ -0,0 +1,344 @@
+package httpregistry
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+)
+
+// jsonrpcVersion is the only version of JSON-RPC that is supported
+const jsonrpcVersion = "2.0"
+
+// These constants are the error codes defined by the JSON-RPC 2.0 specification
+const (
+	JSONRPCParseError     = -32700
+	JSONRPCInvalidRequest = -32600
+	JSONRPCMethodNotFound = -32601
+	JSONRPCInvalidParams  = -32602
+	JSONRPCInternalError  = -32603
+)
+
+// jsonrpcCall is a JSON-RPC 2.0 request or notification
+type jsonrpcCall struct {
+	JSONRPC string          `json:"jsonrpc"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+	// ID is nil if the call is a notification
+	ID json.RawMessage `json:"id,omitempty"`
+}
+
+// parseJSONRPCCall decodes body as a single JSON-RPC 2.0 call
+func parseJSONRPCCall(body []byte) (jsonrpcCall, error) {
+	call := jsonrpcCall{}
+	if err := json.Unmarshal(body, &call); err != nil {
+		return jsonrpcCall{}, fmt.Errorf("the body is not a JSON-RPC call: %w", err)
+	}
+	if call.JSONRPC != jsonrpcVersion {
+		return jsonrpcCall{}, fmt.Errorf("the body is not a JSON-RPC %s call", jsonrpcVersion)
+	}
+	return call, nil
+}
+
+// jsonrpcBatch returns the calls contained in body if it is a JSON-RPC batch, that is a non empty array of JSON-RPC 2.0 calls
+func jsonrpcBatch(body []byte) ([]json.RawMessage, bool) {
+	trimmed := bytes.TrimSpace(body)
+	if len(trimmed) == 0 || trimmed[0] != '[' {
+		return nil, false
+	}
+
+	calls := []json.RawMessage{}
+	if err := json.Unmarshal(trimmed, &calls); err != nil || len(calls) == 0 {
+		return nil, false
+	}
+	for _, call := range calls {
+		if _, err := parseJSONRPCCall(call); err != nil {
+			return nil, false
+		}
+	}
+	return calls, true
+}
+
+// JSONRPCError is the error object of a JSON-RPC 2.0 response
+type JSONRPCError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+	Data    any    `json:"data,omitempty"`
+}
+
+// jsonrpcResponse is a JSON-RPC 2.0 response
+type jsonrpcResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *JSONRPCError   `json:"error,omitempty"`
+	ID      json.RawMessage `json:"id"`
+}
+
+// jsonrpcReply is what a Response returns to a JSON-RPC call, the id is taken from the call when the response is served
+type jsonrpcReply struct {
+	result json.RawMessage
+	err    *JSONRPCError
+}
+
+// body returns the JSON-RPC response to the call with id id
+func (reply jsonrpcReply) body(id json.RawMessage) []byte {
+	if id == nil {
+		id = json.RawMessage("null")
+	}
+	return mustMarshalJSON(jsonrpcResponse{
+		JSONRPC: jsonrpcVersion,
+		Result:  reply.result,
+		Error:   reply.err,
+		ID:      id,
+	})
+}
+
+// serve emits reply as the response to the JSON-RPC call in the body of r.
+// Notifications must not be answered, so for them only the status code 204 is sent
+func (reply jsonrpcReply) serve(w http.ResponseWriter, r *http.Request, statusCode int) {
+	call, err := parseJSONRPCCall(readBody(r))
+	if err == nil && call.ID == nil {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	_, err = w.Write(reply.body(call.ID))
+	if err != nil {
+		panic("cannot write body of request")
+	}
+}
+
+// WithJSONRPCResult returns a new response that answers a JSON-RPC 2.0 call with result.
+// The id of the response is copied from the call when the response is served, and notifications are not answered.
+// This method panics if result cannot be converted to JSON
+//
+//	reg.AddRequestWithResponse(
+//		httpregistry.NewRequest().WithJSONRPCMethod("add").WithJSONRPCParams([]int{1, 2}),
+//		httpregistry.NewResponse().WithJSONRPCResult(3),
+//	)
+func (res Response) WithJSONRPCResult(result any) Response {
+	res.jsonrpc = &jsonrpcReply{result: mustMarshalJSON(result)}
+	return res
+}
+
+// WithJSONRPCError returns a new response that answers a JSON-RPC 2.0 call with the error err.
+// The id of the response is copied from the call when the response is served, and notifications are not answered
+//
+//	NewResponse().WithJSONRPCError(httpregistry.JSONRPCError{Code: httpregistry.JSONRPCInvalidParams, Message: "b must be positive"})
+func (res Response) WithJSONRPCError(err JSONRPCError) Response {
+	res.jsonrpc = &jsonrpcReply{err: &err}
+	return res
+}
+
+// jsonrpcRule defines which part of a JSON-RPC call is compared with the expected value
+type jsonrpcRule string
+
+// These constants are the rules that can be used to match a JSON-RPC call, they are also used as names of the criteria in the reports
+const (
+	jsonrpcMethod       = jsonrpcRule("JSON-RPC method")
+	jsonrpcParams       = jsonrpcRule("JSON-RPC params")
+	jsonrpcParamsSubset = jsonrpcRule("JSON-RPC params subset")
+)
+
+// jsonrpcCriterion is a condition that a registered Request places on the JSON-RPC call sent by an incoming request
+type jsonrpcCriterion struct {
+	rule   jsonrpcRule
+	method string
+	// params are the expected params, decoded from JSON so that they can be compared with the ones of the incoming call
+	params any
+}
+
+// expected returns the human readable version of what the criterion expects
+func (c jsonrpcCriterion) expected() string {
+	switch c.rule {
+	case jsonrpcMethod:
+		return c.method
+	case jsonrpcParamsSubset:
+		return "containing " + compactJSON(c.params)
+	default:
+		return compactJSON(c.params)
+	}
+}
+
+// failure returns the human readable explanation of why the criterion is not satisfied
+func (c jsonrpcCriterion) failure() string {
+	switch c.rule {
+	case jsonrpcMethod:
+		return fmt.Sprintf("the JSON-RPC method is not %q", c.method)
+	case jsonrpcParamsSubset:
+		return fmt.Sprintf("the JSON-RPC params do not contain %s", compactJSON(c.params))
+	default:
+		return fmt.Sprintf("the JSON-RPC params are not equal to %s", compactJSON(c.params))
+	}
+}
+
+// evaluate checks the criterion against the incoming request whose body was already read into body
+func (c jsonrpcCriterion) evaluate(body []byte) criterionResult {
+	name := string(c.rule)
+	if c.rule == jsonrpcParamsSubset {
+		name = string(jsonrpcParams)
+	}
+
+	call, err := parseJSONRPCCall(body)
+	if err != nil {
+		result := newCriterionResult(name, c.expected(), truncate(string(body)), false, jsonrpcDoesNotMatch)
+		result.missDetail = err.Error()
+		return result
+	}
+
+	if c.rule == jsonrpcMethod {
+		result := newCriterionResult(name, c.expected(), call.Method, call.Method == c.method, jsonrpcDoesNotMatch)
+		result.missDetail = c.failure()
+		return result
+	}
+
+	var params any
+	if len(call.Params) > 0 {
+		if err := json.Unmarshal(call.Params, &params); err != nil {
+			panic(fmt.Errorf("cannot decode params that were already validated: %w", err))
+		}
+	}
+	details := jsonDiff("$", c.params, params)
+	if c.rule == jsonrpcParamsSubset {
+		details = jsonSubsetDiff("$", c.params, params)
+	}
+
+	result := newCriterionResult(name, c.expected(), compactJSON(params), len(details) == 0, jsonrpcDoesNotMatch)
+	if !result.matched {
+		result.details = details
+	}
+	result.missDetail = c.failure()
+	return result
+}
+
+// withJSONRPCCriterion returns a copy of criteria with criterion added, the criteria with the same rule are replaced.
+// Since there can be only one criterion per rule, the criteria are kept in a fixed order
+func withJSONRPCCriterion(criteria []jsonrpcCriterion, criterion jsonrpcCriterion) []jsonrpcCriterion {
+	newCriteria := make([]jsonrpcCriterion, 0, len(criteria)+1)
+	for _, rule := range []jsonrpcRule{jsonrpcMethod, jsonrpcParams, jsonrpcParamsSubset} {
+		if rule == criterion.rule {
+			newCriteria = append(newCriteria, criterion)
+			continue
+		}
+		for _, c := range criteria {
+			if c.rule == rule {
+				newCriteria = append(newCriteria, c)
+			}
+		}
+	}
+	return newCriteria
+}
+
+// mustNormalizeJSON is like normalizeJSON but it panics if v cannot be converted to JSON
+func mustNormalizeJSON(v any) any {
+	normalized, err := normalizeJSON(v)
+	if err != nil {
+		panic(fmt.Sprintf("value cannot be converted to JSON: %s", err))
+	}
+	return normalized
+}
+
+// WithJSONRPCMethod returns a new request that matches only if the body is a JSON-RPC 2.0 call of the method method
+//
+//	NewRequest().
+//		WithMethod(http.MethodPost).
+//		WithURL("/rpc").
+//		WithJSONRPCMethod("subtract").
+//		WithJSONRPCParams(map[string]int{"minuend": 42, "subtrahend": 23})
+func (r Request) WithJSONRPCMethod(method string) Request {
+	r.jsonrpc = withJSONRPCCriterion(r.jsonrpc, jsonrpcCriterion{rule: jsonrpcMethod, method: method})
+	return r
+}
+
+// WithJSONRPCParams returns a new request that matches only if the body is a JSON-RPC 2.0 call whose params are exactly params.
+// params are compared after being encoded to JSON, so they can be a slice for positional params or a map or a struct for named params.
+// This method panics if params cannot be converted to JSON
+func (r Request) WithJSONRPCParams(params any) Request {
+	r.jsonrpc = withJSONRPCCriterion(r.jsonrpc, jsonrpcCriterion{rule: jsonrpcParams, params: mustNormalizeJSON(params)})
+	return r
+}
+
+// WithJSONRPCParamsSubset returns a new request that matches only if the body is a JSON-RPC 2.0 call whose params contain params,
+// the fields of the objects that are not in params are ignored.
+// This method panics if params cannot be converted to JSON
+func (r Request) WithJSONRPCParamsSubset(params any) Request {
+	r.jsonrpc = withJSONRPCCriterion(r.jsonrpc, jsonrpcCriterion{rule: jsonrpcParamsSubset, params: mustNormalizeJSON(params)})
+	return r
+}
+
+// hasJSONRPCRegistrations returns true if one of the registrations of reg, or of its parents, places a criterion on JSON-RPC calls
+func (reg *Registry) hasJSONRPCRegistrations() bool {
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+
+	for _, registration := range reg.layeredRegistrations() {
+		if len(registration.match.Request().jsonrpc) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// serveJSONRPCBatch serves each call of a JSON-RPC batch as if it was an independent request and answers with a single batch response.
+// Every call is matched and recorded in the journal independently, the responses to the notifications are dropped as required by the specification.
+// Calls that do not get a JSON-RPC response, for example because they do not match any registered request, are answered with an error object.
+// The cookies set by the responses of the calls, notifications included, are set by the batch response so that they reach the client.
+// Since the batch is a single request, the calls in the batch are not affected by the cookies set by the calls that precede them
+func (reg *Registry) serveJSONRPCBatch(w http.ResponseWriter, r *http.Request, calls []json.RawMessage) {
+	responses := []json.RawMessage{}
+	for _, rawCall := range calls {
+		request := r.Clone(r.Context())
+		request.Body = io.NopCloser(bytes.NewReader(rawCall))
+		request.ContentLength = int64(len(rawCall))
+
+		recorder := httptest.NewRecorder()
+		reg.serveRequest(recorder, request)
+		for _, cookie := range recorder.Header().Values("Set-Cookie") {
+			w.Header().Add("Set-Cookie", cookie)
+		}
+
+		call, _ := parseJSONRPCCall(rawCall)
+		if call.ID == nil {
+			continue
+		}
+		responses = append(responses, jsonrpcResponseFromRecorder(recorder, call.ID))
+	}
+
+	if len(responses) == 0 {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_, err := w.Write(mustMarshalJSON(responses))
+	if err != nil {
+		panic("cannot write body of request")
+	}
+}
+
+// jsonrpcResponseFromRecorder returns the JSON-RPC response recorded in recorder.
+// If recorder does not contain a JSON-RPC response, the body is used as result for successful responses and as data of an error otherwise
+func jsonrpcResponseFromRecorder(recorder *httptest.ResponseRecorder, id json.RawMessage) json.RawMessage {
+	body := recorder.Body.Bytes()
+	response := jsonrpcResponse{}
+	if json.Unmarshal(body, &response) == nil && response.JSONRPC == jsonrpcVersion {
+		return body
+	}
+
+	var data any = string(body)
+	if json.Valid(body) {
+		data = json.RawMessage(body)
+	}
+	if recorder.Code < http.StatusBadRequest {
+		return jsonrpcReply{result: mustMarshalJSON(data)}.body(id)
+	}
+	return jsonrpcReply{err: &JSONRPCError{
+		Code:    JSONRPCInternalError,
+		Message: fmt.Sprintf("the call was answered with the status code %d", recorder.Code),
+		Data:    data,
+	}}.body(id)
+}
@@ -0,0 +1,218 @@
+package httpregistry_test
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/dfioravanti/httpregistry"
+)
+
+func (s *TestSuite) TestJSONRPCMatching() {
+	testCases := []struct {
+		name        string
+		request     httpregistry.Request
+		shouldMatch bool
+		expectedWhy string
+	}{
+		{
+			name:        "method",
+			request:     httpregistry.NewRequest().WithJSONRPCMethod("subtract"),
+			shouldMatch: true,
+		},
+		{
+			name:        "different method",
+			request:     httpregistry.NewRequest().WithJSONRPCMethod("add"),
+			expectedWhy: `mock request #1 missed because the JSON-RPC call does not match: the JSON-RPC method is not "add"`,
+		},
+		{
+			name:        "exact params",
+			request:     httpregistry.NewRequest().WithJSONRPCParams(map[string]any{"minuend": 42, "subtrahend": 23}),
+			shouldMatch: true,
+		},
+		{
+			name:        "params are not exact",
+			request:     httpregistry.NewRequest().WithJSONRPCParams(map[string]any{"minuend": 42}),
+			expectedWhy: `mock request #1 missed because the JSON-RPC call does not match: the JSON-RPC params are not equal to {"minuend":42}`,
+		},
+		{
+			name:        "params subset",
+			request:     httpregistry.NewRequest().WithJSONRPCParamsSubset(map[string]any{"minuend": 42}),
+			shouldMatch: true,
+		},
+		{
+			name:        "params are not a subset",
+			request:     httpregistry.NewRequest().WithJSONRPCParamsSubset(map[string]any{"minuend": 43}),
+			expectedWhy: `mock request #1 missed because the JSON-RPC call does not match: the JSON-RPC params do not contain {"minuend":43}`,
+		},
+	}
+	for _, tc := range testCases {
+		s.Run(tc.name, func() {
+			mockT := httpregistry.NewMockTestingT()
+			registry := httpregistry.NewRegistry(mockT)
+			registry.AddRequest(tc.request)
+
+			server := registry.GetServer()
+			defer server.Close()
+
+			body := `{"jsonrpc": "2.0", "method": "subtract", "params": {"minuend": 42, "subtrahend": 23}, "id": 1}`
+			res, err := http.Post(server.URL, "application/json", strings.NewReader(body))
+			s.NoError(err)
+
+			if tc.shouldMatch {
+				s.False(mockT.HasFailed)
+				return
+			}
+			s.Equal(http.StatusInternalServerError, res.StatusCode)
+			s.Equal(tc.expectedWhy, registry.Why())
+		})
+	}
+}
+
+func (s *TestSuite) TestJSONRPCResponsesCopyTheID() {
+	testCases := []struct {
+		name         string
+		response     httpregistry.Response
+		call         string
+		expectedCode int
+		expectedBody string
+	}{
+		{
+			name:         "result with numeric id",
+			response:     httpregistry.NewResponse().WithJSONRPCResult(19),
+			call:         `{"jsonrpc": "2.0", "method": "subtract", "params": [42, 23], "id": 1}`,
+			expectedCode: http.StatusOK,
+			expectedBody: `{"jsonrpc":"2.0","result":19,"id":1}`,
+		},
+		{
+			name:         "null result with string id",
+			response:     httpregistry.NewResponse().WithJSONRPCResult(nil),
+			call:         `{"jsonrpc": "2.0", "method": "reset", "id": "abc"}`,
+			expectedCode: http.StatusOK,
+			expectedBody: `{"jsonrpc":"2.0","result":null,"id":"abc"}`,
+		},
+		{
+			name: "error",
+			response: httpregistry.NewResponse().WithJSONRPCError(httpregistry.JSONRPCError{
+				Code:    httpregistry.JSONRPCInvalidParams,
+				Message: "subtrahend must be positive",
+				Data:    map[string]any{"subtrahend": -1},
+			}),
+			call:         `{"jsonrpc": "2.0", "method": "subtract", "params": [42, -1], "id": 7}`,
+			expectedCode: http.StatusOK,
+			expectedBody: `{"jsonrpc":"2.0","error":{"code":-32602,"message":"subtrahend must be positive","data":{"subtrahend":-1}},"id":7}`,
+		},
+		{
+			name:         "notifications are not answered",
+			response:     httpregistry.NewResponse().WithJSONRPCResult("ignored"),
+			call:         `{"jsonrpc": "2.0", "method": "log", "params": ["hello"]}`,
+			expectedCode: http.StatusNoContent,
+			expectedBody: ``,
+		},
+	}
+	for _, tc := range testCases {
+		s.Run(tc.name, func() {
+			registry := httpregistry.NewRegistry(s.T())
+			registry.AddResponse(tc.response)
+
+			server := registry.GetServer()
+			defer server.Close()
+
+			res, err := http.Post(server.URL, "application/json", strings.NewReader(tc.call))
+			s.NoError(err)
+			body, err := io.ReadAll(res.Body)
+			s.NoError(err)
+
+			s.Equal(tc.expectedCode, res.StatusCode)
+			s.Equal(tc.expectedBody, string(body))
+		})
+	}
+}
+
+func (s *TestSuite) TestJSONRPCBatchesAreSplit() {
+	mockT := httpregistry.NewMockTestingT()
+	registry := httpregistry.NewRegistry(mockT)
+	add := registry.AddRequestWithResponse(
+		httpregistry.NewRequest().WithJSONRPCMethod("add").WithJSONRPCParams([]int{1, 2}),
+		httpregistry.NewResponse().WithJSONRPCResult(3),
+	)
+	registry.AddRequestWithResponse(
+		httpregistry.NewRequest().WithJSONRPCMethod("divide"),
+		httpregistry.NewResponse().WithJSONRPCError(httpregistry.JSONRPCError{Code: httpregistry.JSONRPCInvalidParams, Message: "division by zero"}),
+	)
+	notify := registry.AddRequest(httpregistry.NewRequest().WithJSONRPCMethod("notify"))
+
+	server := registry.GetServer()
+	defer server.Close()
+
+	batch := `[
+		{"jsonrpc": "2.0", "method": "add", "params": [1, 2], "id": "1"},
+		{"jsonrpc": "2.0", "method": "notify", "params": ["hello"]},
+		{"jsonrpc": "2.0", "method": "divide", "params": [1, 0], "id": "2"}
+	]`
+	res, err := http.Post(server.URL, "application/json", strings.NewReader(batch))
+	s.NoError(err)
+	body, err := io.ReadAll(res.Body)
+	s.NoError(err)
+
+	s.Equal(http.StatusOK, res.StatusCode)
+	s.JSONEq(`[
+		{"jsonrpc": "2.0", "result": 3, "id": "1"},
+		{"jsonrpc": "2.0", "error": {"code": -32602, "message": "division by zero"}, "id": "2"}
+	]`, string(body))
+	s.Equal(1, add.NumberOfCalls())
+	s.Equal(1, notify.NumberOfCalls())
+	s.Len(registry.GetJournal(httpregistry.NewJournalQuery()), 3)
+	s.False(mockT.HasFailed)
+}
+
+func (s *TestSuite) TestUnmatchedCallsInABatchAreAnsweredWithAnError() {
+	mockT := httpregistry.NewMockTestingT()
+	registry := httpregistry.NewRegistry(mockT)
+	registry.AddRequestWithResponse(
+		httpregistry.NewRequest().WithJSONRPCMethod("add"),
+		httpregistry.NewResponse().WithJSONRPCResult(3),
+	)
+
+	server := registry.GetServer()
+	defer server.Close()
+
+	batch := `[{"jsonrpc": "2.0", "method": "add", "id": 1}, {"jsonrpc": "2.0", "method": "multiply", "id": 2}]`
+	res, err := http.Post(server.URL, "application/json", strings.NewReader(batch))
+	s.NoError(err)
+
+	responses := []struct {
+		Result any                        `json:"result"`
+		Error  *httpregistry.JSONRPCError `json:"error"`
+		ID     int                        `json:"id"`
+	}{}
+	s.NoError(json.NewDecoder(res.Body).Decode(&responses))
+	s.Len(responses, 2)
+	s.Equal(float64(3), responses[0].Result)
+	s.Equal(2, responses[1].ID)
+	s.Equal(httpregistry.JSONRPCInternalError, responses[1].Error.Code)
+	s.True(mockT.HasFailed)
+}
+
+func (s *TestSuite) TestBatchesAreNotSplitWithoutJSONRPCRegistrations() {
+	registry := httpregistry.NewRegistry(s.T())
+	proxy := registry.AddRequestWithResponse(
+		httpregistry.NewRequest().WithMethod(http.MethodPost).WithURL("/proxy"),
+		httpregistry.NewResponse().WithBody([]byte("forwarded")),
+	)
+
+	server := registry.GetServer()
+	defer server.Close()
+
+	batch := `[{"jsonrpc": "2.0", "method": "add", "id": 1}, {"jsonrpc": "2.0", "method": "multiply", "id": 2}]`
+	res, err := http.Post(server.URL+"/proxy", "application/json", strings.NewReader(batch))
+	s.NoError(err)
+	body, err := io.ReadAll(res.Body)
+	s.NoError(err)
+
+	s.Equal(http.StatusOK, res.StatusCode)
+	s.Equal("forwarded", string(body))
+	s.Equal(1, proxy.NumberOfCalls())
+	s.Len(registry.GetJournal(httpregistry.NewJournalQuery()), 1)
+}
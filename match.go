@@ -14,17 +14,27 @@ var (
 type match interface {
 	// Request returns the request that triggers the match
 	Request() Request
-	// RecordMatch records that a request was a successful match for this match
-	RecordMatch(req *http.Request)
+	// RecordMatch records that a request was a successful match for this match.
+	// If limit is positive only the last limit requests are kept, the number of calls is counted anyway
+	RecordMatch(req *http.Request, limit int)
 	// Next response returns the next response associated with the match and records which request triggered the match.
 	// If the list of responses is exhausted it will return a ErrNoNextResponseFound error
 	NextResponse() (mockResponse, error)
+	// PeekResponse returns the response that NextResponse would return without consuming it.
+	// It must be called only if RemainingResponses is not 0
+	PeekResponse() mockResponse
 	// NumberOfCalls returns the number of times the match was fulfilled
 	NumberOfCalls() int
 	// Matches returns the list of http.Request that matched with this Match
 	Matches() []*http.Request
+	// RemainingResponses returns the number of responses that can still be returned,
+	// matches that are never consumed return InfiniteResponses
+	RemainingResponses() int
 }
 
+// InfiniteResponses is the number of remaining responses of a registration whose responses are never consumed
+const InfiniteResponses = -1
+
 // A consumableResponsesMatch is a match that returns a different Response each time a predefined Request happens
 // Important: the list of responses gets consumed by the server. Do not reuse this structure, create a new one
 type consumableResponsesMatch struct {
@@ -49,9 +59,10 @@ func (m *consumableResponsesMatch) Request() Request {
 	return m.request
 }
 
-// RecordMatch records that a request was a successful match for this match
-func (m *consumableResponsesMatch) RecordMatch(req *http.Request) {
-	m.matches = append(m.matches, cloneHTTPRequest(req))
+// RecordMatch records that a request was a successful match for this match, keeping at most limit requests if limit is positive
+func (m *consumableResponsesMatch) RecordMatch(req *http.Request, limit int) {
+	m.numberOfCalls++
+	m.matches = appendLimited(m.matches, cloneHTTPRequest(req), limit)
 }
 
 // Next response returns the next response associated with the match.
@@ -68,6 +79,11 @@ func (m *consumableResponsesMatch) NextResponse() (mockResponse, error) {
 	return head, nil
 }
 
+// PeekResponse returns the next response without consuming it
+func (m *consumableResponsesMatch) PeekResponse() mockResponse {
+	return m.responses[0]
+}
+
 // Matches returns the list of http.Request that matched with this Match
 func (m *consumableResponsesMatch) Matches() []*http.Request {
 	return m.matches
@@ -75,7 +91,12 @@ func (m *consumableResponsesMatch) Matches() []*http.Request {
 
 // NumberOfCalls returns the number of times the match was fulfilled
 func (m *consumableResponsesMatch) NumberOfCalls() int {
-	return len(m.matches)
+	return m.numberOfCalls
+}
+
+// RemainingResponses returns the number of responses that can still be returned
+func (m *consumableResponsesMatch) RemainingResponses() int {
+	return len(m.responses)
 }
 
 // A infiniteResponsesMatch is a match that returns the same Response each time a predefined Request happens.
@@ -102,9 +123,10 @@ func (m *infiniteResponsesMatch) Request() Request {
 	return m.request
 }
 
-// RecordMatch records that a request was a successful match for this match
-func (m *infiniteResponsesMatch) RecordMatch(req *http.Request) {
-	m.matches = append(m.matches, cloneHTTPRequest(req))
+// RecordMatch records that a request was a successful match for this match, keeping at most limit requests if limit is positive
+func (m *infiniteResponsesMatch) RecordMatch(req *http.Request, limit int) {
+	m.numberOfCalls++
+	m.matches = appendLimited(m.matches, cloneHTTPRequest(req), limit)
 }
 
 // Next response returns the next response associated with the match.
@@ -113,6 +135,11 @@ func (m *infiniteResponsesMatch) NextResponse() (mockResponse, error) {
 	return m.response, nil
 }
 
+// PeekResponse returns the response of the match, which is never consumed
+func (m *infiniteResponsesMatch) PeekResponse() mockResponse {
+	return m.response
+}
+
 // Matches returns the list of http.Request that matched with this Match
 func (m *infiniteResponsesMatch) Matches() []*http.Request {
 	return m.matches
@@ -120,5 +147,10 @@ func (m *infiniteResponsesMatch) Matches() []*http.Request {
 
 // NumberOfCalls returns the number of times the match was fulfilled
 func (m *infiniteResponsesMatch) NumberOfCalls() int {
-	return len(m.matches)
+	return m.numberOfCalls
+}
+
+// RemainingResponses returns InfiniteResponses since the response is never consumed
+func (m *infiniteResponsesMatch) RemainingResponses() int {
+	return InfiniteResponses
 }
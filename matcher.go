@@ -0,0 +1,43 @@
+package httpregistry
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// BodyMatcher is a condition on the body of an incoming request that is defined outside of this package,
+// for example by a package that matches bodies encoded in a format that the registry does not understand.
+// Two requests are equal only if their matchers are equal according to reflect.DeepEqual, so implementations should be comparable values
+type BodyMatcher interface {
+	// Name describes what the matcher checks, for example "gRPC message".
+	// It is used in the reasons why a request did not match as "the <Name> does not match"
+	Name() string
+	// Expected returns the human readable version of what the matcher expects
+	Expected() string
+	// Match checks the incoming request r, whose body was already read into body.
+	// It returns the human readable version of what the body contains, whether it matches and,
+	// if it does not, an explanation of which part of the body did not match
+	Match(r *http.Request, body []byte) (actual string, matched bool, explanation string)
+}
+
+// WithBodyMatcher returns a new request that matches only if matcher accepts the body of the incoming request.
+// Multiple matchers can be added and all of them must match
+//
+//	NewRequest().
+//		WithURL("/helloworld.Greeter/SayHello").
+//		WithBodyMatcher(grpcregistry.Message(&pb.HelloRequest{Name: "John"}))
+func (r Request) WithBodyMatcher(matcher BodyMatcher) Request {
+	r.bodyMatchers = append(append([]BodyMatcher{}, r.bodyMatchers...), matcher)
+	return r
+}
+
+// evaluateBodyMatcher checks matcher against the incoming request r whose body was already read into body
+func evaluateBodyMatcher(matcher BodyMatcher, r *http.Request, body []byte) criterionResult {
+	actual, matched, explanation := matcher.Match(r, body)
+	why := whyMissed(fmt.Sprintf("the %s does not match", matcher.Name()))
+	result := newCriterionResult(matcher.Name(), matcher.Expected(), actual, matched, why)
+	if !matched {
+		result.missDetail = explanation
+	}
+	return result
+}
@@ -0,0 +1,51 @@
+package httpregistry_test
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/dfioravanti/httpregistry"
+)
+
+// prefixMatcher is a BodyMatcher that matches the bodies that start with prefix
+type prefixMatcher struct {
+	prefix string
+}
+
+// Name returns the name of the matcher
+func (m prefixMatcher) Name() string {
+	return "body prefix"
+}
+
+// Expected returns the expected prefix
+func (m prefixMatcher) Expected() string {
+	return m.prefix
+}
+
+// Match checks that body starts with the prefix
+func (m prefixMatcher) Match(_ *http.Request, body []byte) (string, bool, string) {
+	return string(body), strings.HasPrefix(string(body), m.prefix), "the body does not start with " + m.prefix
+}
+
+func (s *TestSuite) TestBodyMatcher() {
+	mockT := httpregistry.NewMockTestingT()
+	registry := httpregistry.NewRegistry(mockT)
+	request := httpregistry.NewRequest().WithBodyMatcher(prefixMatcher{prefix: "hello"})
+	registry.AddRequest(request)
+
+	server := registry.GetServer()
+	defer server.Close()
+
+	res, err := http.Post(server.URL, "text/plain", strings.NewReader("goodbye world"))
+	s.NoError(err)
+	s.Equal(http.StatusInternalServerError, res.StatusCode)
+	s.Equal("mock request #1 missed because the body prefix does not match: the body does not start with hello", registry.Why())
+
+	res, err = http.Post(server.URL, "text/plain", strings.NewReader("hello world"))
+	s.NoError(err)
+	s.Equal(http.StatusOK, res.StatusCode)
+	s.Len(registry.GetMatchesForRequest(request), 1)
+
+	s.True(request.Equal(httpregistry.NewRequest().WithBodyMatcher(prefixMatcher{prefix: "hello"})))
+	s.False(request.Equal(httpregistry.NewRequest().WithBodyMatcher(prefixMatcher{prefix: "hi"})))
+}
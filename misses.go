@@ -6,10 +6,21 @@ type whyMissed string
 
 // These constants are used to represents why a match does not work and they are returned to the user as part of the error message so that errors can be debugged easily
 const (
-	pathDoesNotMatch   = whyMissed("the path does not match")
-	methodDoesNotMatch = whyMissed("the method does not match")
-	headerDoesNotMatch = whyMissed("the header does not match")
-	outOfResponses     = whyMissed("the route matches but there was no response available")
+	pathDoesNotMatch          = whyMissed("the path does not match")
+	methodDoesNotMatch        = whyMissed("the method does not match")
+	headerDoesNotMatch        = whyMissed("the header does not match")
+	bodyDoesNotMatch          = whyMissed("the body does not match")
+	outOfResponses            = whyMissed("the route matches but there was no response available")
+	registrationDisabled      = whyMissed("the request is disabled")
+	cookieDoesNotMatch        = whyMissed("the cookie does not match")
+	sessionCookieNotSent      = whyMissed("the session cookie was not sent")
+	formFieldDoesNotMatch     = whyMissed("the form field does not match")
+	multipartPartDoesNotMatch = whyMissed("the multipart part does not match")
+	xmlBodyDoesNotMatch       = whyMissed("the XML body does not match")
+	xpathDoesNotMatch         = whyMissed("the XPath does not match")
+	soapActionDoesNotMatch    = whyMissed("the SOAP action does not match")
+	graphqlDoesNotMatch       = whyMissed("the GraphQL operation does not match")
+	jsonrpcDoesNotMatch       = whyMissed("the JSON-RPC call does not match")
 )
 
 // miss represents that the registry was not able to match a registered request with the current request that is coming in from the outside.
@@ -27,15 +38,38 @@ const (
 type miss struct {
 	Request Request   `json:"request"`
 	Why     whyMissed `json:"why"`
+	Detail  string    `json:"detail,omitempty"`
 }
 
 // newMiss creates a new miss and clones the match object to
 // guarantee that it is not modified from outside changes
 func newMiss(match match, why whyMissed) miss {
-	return miss{match.Request(), why}
+	return newMissWithDetail(match, why, "")
+}
+
+// newMissWithDetail creates a new miss that also explains which part of the request did not match,
+// for example which header and which rule failed
+func newMissWithDetail(match match, why whyMissed, detail string) miss {
+	return miss{match.Request(), why, detail}
 }
 
 // String returns a human readable version of why the match could not happen
 func (m miss) String() string {
+	if m.Detail != "" {
+		return fmt.Sprintf("%v missed because %v: %v", m.Request, m.Why, m.Detail)
+	}
 	return fmt.Sprintf("%v missed because %v", m.Request, m.Why)
 }
+
+// missesToString returns the human readable version of misses, one miss per line
+func missesToString(misses []miss) string {
+	outputString := ""
+	for i, miss := range misses {
+		if i == 0 {
+			outputString = miss.String()
+		} else {
+			outputString += "\n" + miss.String()
+		}
+	}
+	return outputString
+}
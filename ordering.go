@@ -0,0 +1,178 @@
+package httpregistry
+
+import (
+	"strings"
+)
+
+// orderedGroup is a set of registered matches that must be called in the order in which they are declared.
+// A match can be called multiple times in a row, for example if it has multiple responses,
+// but once a later match of the group is called an earlier one cannot be called anymore.
+type orderedGroup struct {
+	matches []match
+	// calls contains, in chronological order, the positions in matches of the matches that were called
+	calls []int
+}
+
+// recordCall records that m was called and returns false if this call violates the order of the group.
+// Calls of matches that are not part of the group are ignored
+func (g *orderedGroup) recordCall(m match) bool {
+	position := -1
+	for i, groupMatch := range g.matches {
+		if groupMatch == m {
+			position = i
+			break
+		}
+	}
+	if position == -1 {
+		return true
+	}
+
+	inOrder := true
+	for _, call := range g.calls {
+		if call > position {
+			inOrder = false
+		}
+	}
+	g.calls = append(g.calls, position)
+	return inOrder
+}
+
+// neverCalled returns the human readable version of the matches of the group that were never called, or the empty string if all of them were called
+func (g *orderedGroup) neverCalled() string {
+	names := []string{}
+	for i, m := range g.matches {
+		called := false
+		for _, call := range g.calls {
+			called = called || call == i
+		}
+		if !called {
+			names = append(names, m.Request().String())
+		}
+	}
+	return strings.Join(names, ", ")
+}
+
+// expectedOrder returns the human readable version of the order in which the matches are expected to be called
+func (g *orderedGroup) expectedOrder() string {
+	names := make([]string, 0, len(g.matches))
+	for _, m := range g.matches {
+		names = append(names, m.Request().String())
+	}
+	return strings.Join(names, " -> ")
+}
+
+// actualOrder returns the human readable version of the order in which the matches were called
+func (g *orderedGroup) actualOrder() string {
+	names := make([]string, 0, len(g.calls))
+	for _, call := range g.calls {
+		names = append(names, g.matches[call].Request().String())
+	}
+	return strings.Join(names, " -> ")
+}
+
+// ExpectInOrder declares that the registered requests must be called in the order in which they are passed.
+// A request can be called multiple times in a row, for example if it has multiple responses,
+// but once a request is called the ones that precede it cannot be called anymore.
+// Requests that are not part of the group can be called at any moment.
+// The test fails as soon as a call happens out of order and the failure reports the actual order of the calls,
+// while CheckAllResponsesAreConsumed fails the test if some requests of the group were never called.
+// The groups of a registry are also checked while a scope of the registry is open.
+//
+// Each request must already be registered, it is identified using Request.Equal.
+// If the same request is registered multiple times, it is associated with the first registration that is not already part of the group
+//
+//	auth := httpregistry.NewRequest().WithMethod(http.MethodPost).WithURL("/auth")
+//	data := httpregistry.NewRequest().WithMethod(http.MethodGet).WithURL("/data")
+//	logout := httpregistry.NewRequest().WithMethod(http.MethodDelete).WithURL("/session")
+//
+//	reg := httpregistry.NewRegistry(t)
+//	reg.AddRequest(auth)
+//	reg.AddRequest(data)
+//	reg.AddRequest(logout)
+//	reg.ExpectInOrder(auth, data, logout)
+//	reg.GetServer()
+//
+// will create a http server that fails the test if DELETE "/session" is called before GET "/data" or POST "/auth"
+func (reg *Registry) ExpectInOrder(requests ...Request) {
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+
+	group := &orderedGroup{}
+	for _, request := range requests {
+		m := reg.findRegisteredMatch(request, group.matches)
+		if m == nil {
+			reg.t.Errorf("request %v cannot be expected in order since it is not registered", request)
+			return
+		}
+		group.matches = append(group.matches, m)
+	}
+	reg.orderedGroups = append(reg.orderedGroups, group)
+}
+
+// findRegisteredMatch returns the first registered match whose request is equal to request and that is not in excluded.
+// If no such match exists it returns nil
+func (reg *Registry) findRegisteredMatch(request Request, excluded []match) match {
+	for _, registration := range reg.registrations {
+		m := registration.match
+		if !m.Request().Equal(request) {
+			continue
+		}
+
+		isExcluded := false
+		for _, e := range excluded {
+			if e == m {
+				isExcluded = true
+			}
+		}
+		if !isExcluded {
+			return m
+		}
+	}
+	return nil
+}
+
+// checkOrderedGroupsAreCompleted fails the test for each ordered group with matches that were never called.
+// It must be called while holding the lock
+func (reg *Registry) checkOrderedGroupsAreCompleted() {
+	for _, group := range reg.orderedGroups {
+		if neverCalled := group.neverCalled(); neverCalled != "" {
+			reg.t.Errorf("the requests expected in the order\n\t%v\nwere not all called, never called: %v", group.expectedOrder(), neverCalled)
+		}
+	}
+}
+
+// checkCallOrder records that m was called in all the ordered groups and fails the test if the call violates the order of one of them.
+// The groups of the parents of a scope are checked too, since the calls served by their registrations go through the scope
+func (reg *Registry) checkCallOrder(m match) {
+	groups := reg.orderedGroups
+	for parent := reg.parent; parent != nil; parent = parent.parent {
+		groups = append(groups[:len(groups):len(groups)], parent.orderedGroups...)
+	}
+	for _, group := range groups {
+		if !group.recordCall(m) {
+			reg.t.Errorf(
+				"request %v was called out of order, the expected order is\n\t%v\nbut the actual order is\n\t%v",
+				m.Request(), group.expectedOrder(), group.actualOrder(),
+			)
+		}
+	}
+}
+
+// ExpectRegistrationsInOrder declares that the registrations must be called in the order in which they are passed.
+// It behaves like ExpectInOrder but the registrations are identified by the handles returned by the Add* methods,
+// so it works also when multiple registrations share the same request
+//
+//	reg := httpregistry.NewRegistry(t)
+//	first := reg.AddMethodAndURLWithStatusCode(http.MethodGet, "/status", http.StatusAccepted)
+//	second := reg.AddMethodAndURLWithStatusCode(http.MethodGet, "/status", http.StatusOK)
+//	reg.ExpectRegistrationsInOrder(first, second)
+func (reg *Registry) ExpectRegistrationsInOrder(registrations ...*Registration) {
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+
+	group := &orderedGroup{}
+	for _, registration := range registrations {
+		group.matches = append(group.matches, registration.match)
+	}
+	reg.orderedGroups = append(reg.orderedGroups, group)
+}
@@ -0,0 +1,151 @@
+package httpregistry_test
+
+import (
+	"net/http"
+
+	"github.com/dfioravanti/httpregistry"
+)
+
+func (s *TestSuite) TestExpectInOrder() {
+	type call struct {
+		method string
+		path   string
+	}
+	authCall := call{http.MethodPost, "/auth"}
+	dataCall := call{http.MethodGet, "/data"}
+	logoutCall := call{http.MethodDelete, "/session"}
+	healthCall := call{http.MethodGet, "/health"}
+
+	auth := httpregistry.NewRequest().WithMethod(authCall.method).WithURL(authCall.path).WithName("auth")
+	data := httpregistry.NewRequest().WithMethod(dataCall.method).WithURL(dataCall.path).WithName("data")
+	logout := httpregistry.NewRequest().WithMethod(logoutCall.method).WithURL(logoutCall.path).WithName("logout")
+	health := httpregistry.NewRequest().WithMethod(healthCall.method).WithURL(healthCall.path).WithName("health")
+
+	testCases := []struct {
+		name             string
+		calls            []call
+		expectedMessages []string
+	}{
+		{
+			name:  "calls in order",
+			calls: []call{authCall, dataCall, dataCall, logoutCall},
+		},
+		{
+			name:  "unordered requests interleave freely",
+			calls: []call{healthCall, authCall, healthCall, dataCall, logoutCall, healthCall},
+		},
+		{
+			name:  "calls out of order",
+			calls: []call{authCall, logoutCall, dataCall},
+			expectedMessages: []string{
+				"request data was called out of order, the expected order is\n\tauth -> data -> logout\nbut the actual order is\n\tauth -> logout -> data",
+			},
+		},
+	}
+	for _, tc := range testCases {
+		s.Run(tc.name, func() {
+			mockT := httpregistry.NewMockTestingT()
+			registry := httpregistry.NewRegistry(mockT)
+			registry.AddRequestWithInfiniteResponse(health, httpregistry.OkResponse)
+			registry.AddRequest(auth)
+			registry.AddRequestWithResponses(data, httpregistry.OkResponse, httpregistry.OkResponse)
+			registry.AddRequest(logout)
+			registry.ExpectInOrder(auth, data, logout)
+
+			server := registry.GetServer()
+			defer server.Close()
+
+			for _, c := range tc.calls {
+				req, err := http.NewRequest(c.method, server.URL+c.path, nil)
+				s.NoError(err)
+				res, err := http.DefaultClient.Do(req)
+				s.NoError(err)
+				s.Equal(http.StatusOK, res.StatusCode)
+			}
+
+			s.Equal(tc.expectedMessages, mockT.Messages)
+		})
+	}
+}
+
+func (s *TestSuite) TestExpectInOrderFailsForUnregisteredRequests() {
+	mockT := httpregistry.NewMockTestingT()
+	registry := httpregistry.NewRegistry(mockT)
+	registry.AddURL("/foo")
+
+	registry.ExpectInOrder(
+		httpregistry.NewRequest().WithURL("/foo"),
+		httpregistry.NewRequest().WithURL("/bar").WithName("bar"),
+	)
+
+	s.Equal([]string{"request bar cannot be expected in order since it is not registered"}, mockT.Messages)
+}
+
+func (s *TestSuite) TestExpectInOrderReportsRequestsThatWereNeverCalled() {
+	testCases := []struct {
+		name             string
+		paths            []string
+		expectedMessages []string
+	}{
+		{
+			name:  "all the requests are called",
+			paths: []string{"/auth", "/data", "/logout"},
+		},
+		{
+			name:  "some requests are never called",
+			paths: []string{"/auth"},
+			expectedMessages: []string{
+				"request data has httpregistry.OkResponse as unused response",
+				"request logout has httpregistry.OkResponse as unused response",
+				"the requests expected in the order\n\tauth -> data -> logout\nwere not all called, never called: data, logout",
+			},
+		},
+	}
+	for _, tc := range testCases {
+		s.Run(tc.name, func() {
+			mockT := httpregistry.NewMockTestingT()
+			registry := httpregistry.NewRegistry(mockT)
+			auth := registry.AddRequest(httpregistry.NewRequest().WithURL("/auth").WithName("auth"))
+			data := registry.AddRequest(httpregistry.NewRequest().WithURL("/data").WithName("data"))
+			logout := registry.AddRequest(httpregistry.NewRequest().WithURL("/logout").WithName("logout"))
+			registry.ExpectRegistrationsInOrder(auth, data, logout)
+
+			server := registry.GetServer()
+			defer server.Close()
+
+			for _, path := range tc.paths {
+				res, err := http.Get(server.URL + path)
+				s.NoError(err)
+				s.Equal(http.StatusOK, res.StatusCode)
+			}
+			registry.CheckAllResponsesAreConsumed()
+
+			s.Equal(tc.expectedMessages, mockT.Messages)
+		})
+	}
+}
+
+func (s *TestSuite) TestExpectInOrderIsCheckedWhileAScopeIsOpen() {
+	mockT := httpregistry.NewMockTestingT()
+	registry := httpregistry.NewRegistry(mockT)
+	auth := registry.AddRequest(httpregistry.NewRequest().WithURL("/auth").WithName("auth"))
+	data := registry.AddRequest(httpregistry.NewRequest().WithURL("/data").WithName("data"))
+	registry.ExpectRegistrationsInOrder(auth, data)
+
+	server := registry.GetServer()
+	defer server.Close()
+
+	scopeT := httpregistry.NewMockTestingT()
+	scope := registry.Scope(scopeT)
+	defer scope.Close()
+	scope.AddURL("/health")
+
+	for _, path := range []string{"/data", "/health", "/auth"} {
+		res, err := http.Get(server.URL + path)
+		s.NoError(err)
+		s.Equal(http.StatusOK, res.StatusCode)
+	}
+
+	s.Equal([]string{"request auth was called out of order, the expected order is\n\tauth -> data\nbut the actual order is\n\tdata -> auth"}, scopeT.Messages)
+	s.Empty(mockT.Messages)
+}
@@ -0,0 +1,375 @@
+package httpregistry
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// PaginationStyle is the way in which a client selects the page of a Pagination
+type PaginationStyle int
+
+const (
+	// OffsetPagination selects the page with the query parameters `offset` and `limit`, the body is the array of the items of the page
+	OffsetPagination PaginationStyle = iota
+	// PageNumberPagination selects the page with the query parameters `page`, starting from 1, and `per_page`,
+	// the body is the array of the items of the page
+	PageNumberPagination
+	// CursorPagination selects the page with the query parameters `cursor` and `limit`, the body is an object
+	// with the items of the page in the field `items` and the cursor of the next page in the field `next_cursor`,
+	// which is empty on the last page
+	CursorPagination
+	// LinkHeaderPagination selects the page like PageNumberPagination and links the first, previous, next and last pages
+	// in the header `Link`
+	LinkHeaderPagination
+)
+
+// String returns the name of the style
+func (s PaginationStyle) String() string {
+	switch s {
+	case OffsetPagination:
+		return "offset"
+	case PageNumberPagination:
+		return "page number"
+	case CursorPagination:
+		return "cursor"
+	case LinkHeaderPagination:
+		return "Link header"
+	default:
+		return fmt.Sprintf("PaginationStyle(%d)", int(s))
+	}
+}
+
+// Pagination describes how a registration created with Registry.AddRequestWithPagination splits a list of items in pages
+type Pagination struct {
+	items    []json.RawMessage
+	pageSize int
+	style    PaginationStyle
+	// positionParameter is the query parameter that selects the page, sizeParameter is the one that selects the size of the page
+	positionParameter string
+	sizeParameter     string
+	// itemsField is the field of the object that contains the items of the page, if it is empty the body is the array of the items
+	itemsField string
+}
+
+// NewPagination creates a Pagination that serves items in pages of pageSize items with the given style.
+// The clients can request a different page size with the size query parameter of the style.
+// This function panics if pageSize is not positive or if one of the items cannot be marshalled to JSON
+//
+//	httpregistry.NewPagination(users, 10, httpregistry.CursorPagination)
+func NewPagination[T any](items []T, pageSize int, style PaginationStyle) Pagination {
+	if pageSize <= 0 {
+		panic("the page size of a Pagination must be positive")
+	}
+
+	encoded := make([]json.RawMessage, 0, len(items))
+	for i, item := range items {
+		b, err := json.Marshal(item)
+		if err != nil {
+			panic(fmt.Sprintf("item %d of the Pagination cannot be marshalled to JSON: %v", i, err))
+		}
+		encoded = append(encoded, b)
+	}
+
+	pagination := Pagination{items: encoded, pageSize: pageSize, style: style}
+	switch style {
+	case OffsetPagination:
+		pagination.positionParameter, pagination.sizeParameter = "offset", "limit"
+	case PageNumberPagination, LinkHeaderPagination:
+		pagination.positionParameter, pagination.sizeParameter = "page", "per_page"
+	case CursorPagination:
+		pagination.positionParameter, pagination.sizeParameter = "cursor", "limit"
+		pagination.itemsField = "items"
+	default:
+		panic(fmt.Sprintf("unknown pagination style %v", style))
+	}
+	return pagination
+}
+
+// WithQueryParameters returns a new Pagination that reads the page from the query parameter position and the size of the page
+// from the query parameter size, instead of the default ones of its style
+//
+//	httpregistry.NewPagination(users, 10, httpregistry.PageNumberPagination).WithQueryParameters("p", "size")
+func (p Pagination) WithQueryParameters(position string, size string) Pagination {
+	p.positionParameter = position
+	p.sizeParameter = size
+	return p
+}
+
+// WithItemsField returns a new Pagination whose body is an object with the items of the page in field, instead of the array of the items.
+// With CursorPagination it replaces the default field `items`
+func (p Pagination) WithItemsField(field string) Pagination {
+	p.itemsField = field
+	return p
+}
+
+// encodeCursor returns the opaque cursor of the page that starts at offset
+func encodeCursor(offset int) string {
+	return base64.RawURLEncoding.EncodeToString([]byte("offset:" + strconv.Itoa(offset)))
+}
+
+// decodeCursor returns the offset encoded in cursor
+func decodeCursor(cursor string) (int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, fmt.Errorf("the cursor %q is not valid", cursor)
+	}
+	value, found := strings.CutPrefix(string(b), "offset:")
+	if !found {
+		return 0, fmt.Errorf("the cursor %q is not valid", cursor)
+	}
+	offset, err := strconv.Atoi(value)
+	if err != nil || offset < 0 {
+		return 0, fmt.Errorf("the cursor %q is not valid", cursor)
+	}
+	return offset, nil
+}
+
+// PageRequest is a page requested to a registration created with Registry.AddRequestWithPagination
+type PageRequest struct {
+	// Number is the number of the page, starting from 1, if the page is aligned with the page size of the request
+	Number int
+	// Offset is the index of the first item of the page
+	Offset int
+	// Limit is the maximum number of items of the page
+	Limit int
+}
+
+// String returns the page and the range of items that it contains
+func (p PageRequest) String() string {
+	return fmt.Sprintf("page %d (offset %d, limit %d)", p.Number, p.Offset, p.Limit)
+}
+
+// Pages is a handle to a registration created with Registry.AddRequestWithPagination,
+// it records the pages requested by the clients so that it is possible to check that they walk all of them
+type Pages struct {
+	registration *Registration
+	pagination   Pagination
+
+	mu        sync.Mutex
+	requested []PageRequest
+}
+
+// paginatedResponse is the response of a registration created with AddRequestWithPagination
+type paginatedResponse struct {
+	pages *Pages
+}
+
+// page returns the page selected by the query of r
+func (p Pagination) page(r *http.Request) (PageRequest, error) {
+	query := r.URL.Query()
+
+	limit := p.pageSize
+	if value := query.Get(p.sizeParameter); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n <= 0 {
+			return PageRequest{}, fmt.Errorf("the query parameter %s must be a positive number, got %q", p.sizeParameter, value)
+		}
+		limit = n
+	}
+
+	offset := 0
+	value := query.Get(p.positionParameter)
+	switch p.style {
+	case OffsetPagination:
+		if value != "" {
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 0 {
+				return PageRequest{}, fmt.Errorf("the query parameter %s must be a non negative number, got %q", p.positionParameter, value)
+			}
+			offset = n
+		}
+	case PageNumberPagination, LinkHeaderPagination:
+		if value != "" {
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 {
+				return PageRequest{}, fmt.Errorf("the query parameter %s must be a positive number, got %q", p.positionParameter, value)
+			}
+			if n-1 > math.MaxInt/limit {
+				return PageRequest{}, fmt.Errorf("the query parameter %s selects a page that is out of range, got %q", p.positionParameter, value)
+			}
+			offset = (n - 1) * limit
+		}
+	case CursorPagination:
+		if value != "" {
+			n, err := decodeCursor(value)
+			if err != nil {
+				return PageRequest{}, err
+			}
+			offset = n
+		}
+	}
+	// the end of the page, offset+limit, must be representable
+	if offset > math.MaxInt-limit {
+		return PageRequest{}, fmt.Errorf("the query parameters %s and %s select a page that is out of range", p.positionParameter, p.sizeParameter)
+	}
+
+	return PageRequest{Number: offset/limit + 1, Offset: offset, Limit: limit}, nil
+}
+
+// pageURL returns the URL of r with the page selected by the query parameters set to number
+func (p Pagination) pageURL(r *http.Request, number int, limit int) string {
+	u := *r.URL
+	u.Host = r.Host
+	u.Scheme = "http"
+	if r.TLS != nil {
+		u.Scheme = "https"
+	}
+	query := u.Query()
+	query.Set(p.positionParameter, strconv.Itoa(number))
+	query.Set(p.sizeParameter, strconv.Itoa(limit))
+	u.RawQuery = query.Encode()
+	return u.String()
+}
+
+// serveResponse records the page requested by r and emits it
+func (res paginatedResponse) serveResponse(w http.ResponseWriter, r *http.Request) {
+	p := res.pages.pagination
+	page, err := p.page(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	res.pages.mu.Lock()
+	res.pages.requested = append(res.pages.requested, page)
+	res.pages.mu.Unlock()
+
+	total := len(p.items)
+	start := min(page.Offset, total)
+	end := start + min(page.Limit, total-start)
+	items := p.items[start:end]
+
+	var body any = items
+	if p.itemsField != "" {
+		object := map[string]any{p.itemsField: items}
+		if p.style == CursorPagination {
+			next := ""
+			if end < total {
+				next = encodeCursor(end)
+			}
+			object["next_cursor"] = next
+		}
+		body = object
+	}
+
+	if p.style == LinkHeaderPagination {
+		last := 1
+		if total > 0 {
+			last = (total-1)/page.Limit + 1
+		}
+		links := []string{fmt.Sprintf(`<%s>; rel="first"`, p.pageURL(r, 1, page.Limit))}
+		if page.Number > 1 {
+			links = append(links, fmt.Sprintf(`<%s>; rel="prev"`, p.pageURL(r, min(page.Number-1, last), page.Limit)))
+		}
+		if page.Number < last {
+			links = append(links, fmt.Sprintf(`<%s>; rel="next"`, p.pageURL(r, page.Number+1, page.Limit)))
+		}
+		links = append(links, fmt.Sprintf(`<%s>; rel="last"`, p.pageURL(r, last, page.Limit)))
+		w.Header().Set("Link", strings.Join(links, ", "))
+	}
+
+	b, err := json.Marshal(body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Total-Count", strconv.Itoa(total))
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(b)
+}
+
+// String returns a description of the pagination
+func (res paginatedResponse) String() string {
+	p := res.pages.pagination
+	return fmt.Sprintf("%d items in pages of %d with %v pagination", len(p.items), p.pageSize, p.style)
+}
+
+// AddRequestWithPagination adds to the registry a request that serves the items of pagination page by page, the response is never consumed.
+// The page is selected by the query parameters of the incoming request according to the style of pagination,
+// requests with invalid parameters are answered with a 400. Every page carries the header `X-Total-Count` with the number of items.
+// The returned Pages records the pages that were requested
+//
+//	reg := httpregistry.NewRegistry(t)
+//	pages := reg.AddRequestWithPagination(
+//		httpregistry.NewRequest().WithURL("/users"),
+//		httpregistry.NewPagination(users, 10, httpregistry.LinkHeaderPagination),
+//	)
+//	...
+//	pages.AssertAllPagesRequested(t)
+func (reg *Registry) AddRequestWithPagination(request Request, pagination Pagination) *Pages {
+	pages := &Pages{pagination: pagination}
+	pages.registration = reg.AddRequestWithInfiniteResponse(request, paginatedResponse{pages: pages})
+	return pages
+}
+
+// Registration returns the handle of the registration that serves the pages
+func (p *Pages) Registration() *Registration {
+	return p.registration
+}
+
+// String returns the name of the request that serves the pages
+func (p *Pages) String() string {
+	return p.registration.String()
+}
+
+// NumberOfPages returns the number of pages of the default page size, an empty list of items has one empty page
+func (p *Pages) NumberOfPages() int {
+	return max((len(p.pagination.items)+p.pagination.pageSize-1)/p.pagination.pageSize, 1)
+}
+
+// Requested returns the pages requested by the clients in the order in which they were requested
+func (p *Pages) Requested() []PageRequest {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]PageRequest{}, p.requested...)
+}
+
+// AssertAllPagesRequested checks that the clients requested pages that together contain every item, if not the test is failed.
+// If there are no items, it checks that at least one page was requested
+func (p *Pages) AssertAllPagesRequested(t TestingT) bool {
+	requested := p.Requested()
+	total := len(p.pagination.items)
+	if total == 0 {
+		if len(requested) == 0 {
+			t.Errorf("no page of request %v was requested", p)
+			return false
+		}
+		return true
+	}
+
+	seen := make([]bool, total)
+	for _, page := range requested {
+		for i := page.Offset; i < total && i-page.Offset < page.Limit; i++ {
+			seen[i] = true
+		}
+	}
+
+	missing := []string{}
+	for i := 0; i < total; {
+		if seen[i] {
+			i++
+			continue
+		}
+		start := i
+		for i < total && !seen[i] {
+			i++
+		}
+		if start == i-1 {
+			missing = append(missing, strconv.Itoa(start))
+		} else {
+			missing = append(missing, fmt.Sprintf("%d-%d", start, i-1))
+		}
+	}
+	if len(missing) > 0 {
+		t.Errorf("the items %s of request %v were never requested, the requested pages were %v", strings.Join(missing, ", "), p, requested)
+		return false
+	}
+	return true
+}
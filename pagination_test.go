@@ -0,0 +1,182 @@
+package httpregistry_test
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/dfioravanti/httpregistry"
+)
+
+// getPage calls url with GET and returns the response and its body
+func (s *TestSuite) getPage(url string) (*http.Response, string) {
+	res, err := http.Get(url)
+	s.Require().NoError(err)
+	defer res.Body.Close()
+	body, err := io.ReadAll(res.Body)
+	s.Require().NoError(err)
+	return res, string(body)
+}
+
+func (s *TestSuite) TestOffsetPagination() {
+	reg := httpregistry.NewRegistry(s.T())
+	pages := reg.AddRequestWithPagination(
+		httpregistry.NewRequest().WithURL("/users"),
+		httpregistry.NewPagination([]int{1, 2, 3, 4, 5}, 2, httpregistry.OffsetPagination),
+	)
+	url := reg.GetServer().URL + "/users"
+
+	res, body := s.getPage(url)
+	s.Equal(http.StatusOK, res.StatusCode)
+	s.Equal("application/json", res.Header.Get("Content-Type"))
+	s.Equal("5", res.Header.Get("X-Total-Count"))
+	s.JSONEq(`[1, 2]`, body)
+
+	_, body = s.getPage(url + "?offset=2&limit=3")
+	s.JSONEq(`[3, 4, 5]`, body)
+
+	_, body = s.getPage(url + "?offset=10")
+	s.JSONEq(`[]`, body)
+
+	s.Equal([]httpregistry.PageRequest{
+		{Number: 1, Offset: 0, Limit: 2},
+		{Number: 1, Offset: 2, Limit: 3},
+		{Number: 6, Offset: 10, Limit: 2},
+	}, pages.Requested())
+	s.Equal(3, pages.NumberOfPages())
+	s.True(pages.AssertAllPagesRequested(s.T()))
+}
+
+func (s *TestSuite) TestPageNumberPagination() {
+	reg := httpregistry.NewRegistry(s.T())
+	reg.AddRequestWithPagination(
+		httpregistry.NewRequest().WithURL("/users"),
+		httpregistry.NewPagination([]string{"a", "b", "c"}, 2, httpregistry.PageNumberPagination).
+			WithQueryParameters("p", "size").
+			WithItemsField("data"),
+	)
+	url := reg.GetServer().URL + "/users"
+
+	_, body := s.getPage(url + "?p=2")
+	s.JSONEq(`{"data": ["c"]}`, body)
+
+	_, body = s.getPage(url + "?p=1&size=3")
+	s.JSONEq(`{"data": ["a", "b", "c"]}`, body)
+
+	res, body := s.getPage(url + "?p=0")
+	s.Equal(http.StatusBadRequest, res.StatusCode)
+	s.Contains(body, `the query parameter p must be a positive number, got "0"`)
+}
+
+func (s *TestSuite) TestCursorPagination() {
+	reg := httpregistry.NewRegistry(s.T())
+	pages := reg.AddRequestWithPagination(
+		httpregistry.NewRequest().WithURL("/users"),
+		httpregistry.NewPagination([]map[string]int{{"id": 1}, {"id": 2}, {"id": 3}}, 2, httpregistry.CursorPagination),
+	)
+	url := reg.GetServer().URL + "/users"
+
+	var page struct {
+		Items      []map[string]int `json:"items"`
+		NextCursor string           `json:"next_cursor"`
+	}
+	_, body := s.getPage(url)
+	s.NoError(json.Unmarshal([]byte(body), &page))
+	s.Equal([]map[string]int{{"id": 1}, {"id": 2}}, page.Items)
+	s.NotEmpty(page.NextCursor)
+
+	_, body = s.getPage(url + "?cursor=" + page.NextCursor)
+	s.NoError(json.Unmarshal([]byte(body), &page))
+	s.Equal([]map[string]int{{"id": 3}}, page.Items)
+	s.Empty(page.NextCursor)
+	s.True(pages.AssertAllPagesRequested(s.T()))
+
+	res, _ := s.getPage(url + "?cursor=not-a-cursor")
+	s.Equal(http.StatusBadRequest, res.StatusCode)
+}
+
+func (s *TestSuite) TestLinkHeaderPagination() {
+	reg := httpregistry.NewRegistry(s.T())
+	reg.AddRequestWithPagination(
+		httpregistry.NewRequest().WithURL("/users"),
+		httpregistry.NewPagination([]int{1, 2, 3, 4, 5}, 2, httpregistry.LinkHeaderPagination),
+	)
+	url := reg.GetServer().URL + "/users"
+
+	res, body := s.getPage(url + "?page=2&sort=asc")
+	s.JSONEq(`[3, 4]`, body)
+	s.Equal(
+		`<`+url+`?page=1&per_page=2&sort=asc>; rel="first", `+
+			`<`+url+`?page=1&per_page=2&sort=asc>; rel="prev", `+
+			`<`+url+`?page=3&per_page=2&sort=asc>; rel="next", `+
+			`<`+url+`?page=3&per_page=2&sort=asc>; rel="last"`,
+		res.Header.Get("Link"),
+	)
+
+	res, _ = s.getPage(url)
+	s.Equal(`<`+url+`?page=1&per_page=2>; rel="first", <`+url+`?page=2&per_page=2>; rel="next", <`+url+`?page=3&per_page=2>; rel="last"`, res.Header.Get("Link"))
+}
+
+func (s *TestSuite) TestAssertAllPagesRequestedFails() {
+	reg := httpregistry.NewRegistry(s.T())
+	pages := reg.AddRequestWithPagination(
+		httpregistry.NewRequest().WithName("users").WithURL("/users"),
+		httpregistry.NewPagination([]int{1, 2, 3, 4, 5, 6}, 2, httpregistry.PageNumberPagination),
+	)
+
+	t := httpregistry.NewMockTestingT()
+	s.False(pages.AssertAllPagesRequested(t))
+
+	s.getPage(reg.GetServer().URL + "/users?page=2")
+	s.False(pages.AssertAllPagesRequested(t))
+
+	s.getPage(reg.GetServer().URL + "/users?page=1&per_page=5")
+	s.False(pages.AssertAllPagesRequested(t))
+
+	s.Equal([]string{
+		"the items 0-5 of request users were never requested, the requested pages were []",
+		"the items 0-1, 4-5 of request users were never requested, the requested pages were [page 2 (offset 2, limit 2)]",
+		"the items 5 of request users were never requested, the requested pages were [page 2 (offset 2, limit 2) page 1 (offset 0, limit 5)]",
+	}, t.Messages)
+}
+
+func (s *TestSuite) TestPaginationRejectsPagesOutOfRange() {
+	reg := httpregistry.NewRegistry(s.T())
+	offsets := reg.AddRequestWithPagination(
+		httpregistry.NewRequest().WithURL("/offsets"),
+		httpregistry.NewPagination([]int{1, 2, 3}, 2, httpregistry.OffsetPagination),
+	)
+	numbers := reg.AddRequestWithPagination(
+		httpregistry.NewRequest().WithURL("/numbers"),
+		httpregistry.NewPagination([]int{1, 2, 3}, 2, httpregistry.LinkHeaderPagination),
+	)
+	url := reg.GetServer().URL
+
+	res, body := s.getPage(url + "/numbers?page=9223372036854775807&per_page=2")
+	s.Equal(http.StatusBadRequest, res.StatusCode)
+	s.Contains(body, `the query parameter page selects a page that is out of range, got "9223372036854775807"`)
+
+	res, body = s.getPage(url + "/offsets?offset=9223372036854775807&limit=2")
+	s.Equal(http.StatusBadRequest, res.StatusCode)
+	s.Contains(body, "the query parameters offset and limit select a page that is out of range")
+
+	// the largest pages that can be represented are served
+	res, body = s.getPage(url + "/offsets?offset=9223372036854775805&limit=2")
+	s.Equal(http.StatusOK, res.StatusCode)
+	s.JSONEq(`[]`, body)
+	res, body = s.getPage(url + "/numbers?page=1&per_page=9223372036854775807")
+	s.Equal(http.StatusOK, res.StatusCode)
+	s.JSONEq(`[1, 2, 3]`, body)
+	s.Equal(`<`+url+`/numbers?page=1&per_page=9223372036854775807>; rel="first", <`+url+`/numbers?page=1&per_page=9223372036854775807>; rel="last"`, res.Header.Get("Link"))
+
+	t := httpregistry.NewMockTestingT()
+	s.False(offsets.AssertAllPagesRequested(t))
+	s.True(numbers.AssertAllPagesRequested(t))
+}
+
+func (s *TestSuite) TestNewPaginationPanicsOnInvalidArguments() {
+	s.PanicsWithValue("the page size of a Pagination must be positive", func() {
+		httpregistry.NewPagination([]int{1}, 0, httpregistry.OffsetPagination)
+	})
+	s.Panics(func() { httpregistry.NewPagination([]any{func() {}}, 1, httpregistry.OffsetPagination) })
+}
@@ -0,0 +1,198 @@
+package httpregistry
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+// MatchingStrategy defines which registration serves an incoming request when more than one registration matches it
+type MatchingStrategy int
+
+const (
+	// FirstRegisteredMatch selects, among the registrations with the highest priority, the first one that was registered.
+	// This is the default strategy of a Registry
+	FirstRegisteredMatch MatchingStrategy = iota
+	// MostSpecificMatch selects, among the registrations with the highest priority, the one that defines the most criteria,
+	// for example a registration for the method and the URL wins over one for the URL only.
+	// Registrations with the same number of criteria are selected in order of registration
+	MostSpecificMatch
+)
+
+// String returns the human readable name of the strategy
+func (s MatchingStrategy) String() string {
+	switch s {
+	case FirstRegisteredMatch:
+		return "first registered"
+	case MostSpecificMatch:
+		return "most specific"
+	default:
+		return fmt.Sprintf("MatchingStrategy(%d)", int(s))
+	}
+}
+
+// SetMatchingStrategy sets how the registry selects the registration that serves a request matched by more than one registration.
+// The priorities of the registrations are always taken into account first
+//
+//	reg := httpregistry.NewRegistry(t)
+//	reg.SetMatchingStrategy(httpregistry.MostSpecificMatch)
+//	reg.AddInfiniteResponse(httpregistry.NotFoundResponse)
+//	reg.AddMethodAndURL(http.MethodGet, "/users")
+//	reg.GetServer()
+//
+// will create a http server that returns 200 on calling GET "/users" and 404 on anything else,
+// while with the default strategy the catch-all would serve every request
+func (reg *Registry) SetMatchingStrategy(strategy MatchingStrategy) {
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+	reg.strategy = strategy
+}
+
+// SetPriority sets the priority of the registration. Registrations with a higher priority are tried before the ones with a lower priority,
+// independently of the order in which they were registered. The default priority is 0,
+// so a negative priority can be used for catch-all registrations that must be tried last
+//
+//	catchAll := reg.AddInfiniteResponse(httpregistry.NotFoundResponse)
+//	catchAll.SetPriority(-1)
+//	reg.AddMethodAndURL(http.MethodGet, "/users")
+func (r *Registration) SetPriority(priority int) {
+	r.reg.mu.Lock()
+	defer r.reg.mu.Unlock()
+	r.priority = priority
+}
+
+// Priority returns the priority of the registration
+func (r *Registration) Priority() int {
+	r.reg.mu.Lock()
+	defer r.reg.mu.Unlock()
+	return r.priority
+}
+
+// numberOfCriteria returns how many criteria the request defines, it is used to measure how specific the request is
+func (r Request) numberOfCriteria() int {
+	n := len(r.headers) + len(r.cookies) + len(r.form) + len(r.xml) + len(r.graphql) + len(r.jsonrpc) + len(r.bodyMatchers)
+	if r.url != "" {
+		n++
+	}
+	if r.method != "" {
+		n++
+	}
+	if len(r.body) > 0 {
+		n++
+	}
+	return n
+}
+
+// covers returns true if every criterion of r is also a criterion of other, so that r matches at least all the requests that other matches.
+// The check is conservative: for example a URL is considered covered only if it is identical, even if it is a broader regex
+func (r Request) covers(other Request) bool {
+	if r.url != "" && r.url != other.url {
+		return false
+	}
+	if r.method != "" && r.method != other.method {
+		return false
+	}
+	if len(r.body) > 0 && !bytes.Equal(r.body, other.body) {
+		return false
+	}
+	return containsAll(r.headers, other.headers) &&
+		containsAll(r.cookies, other.cookies) &&
+		containsAll(r.form, other.form) &&
+		containsAll(r.xml, other.xml) &&
+		containsAll(r.graphql, other.graphql) &&
+		containsAll(r.jsonrpc, other.jsonrpc) &&
+		containsAll(r.bodyMatchers, other.bodyMatchers)
+}
+
+// containsAll returns true if every element of subset is also in set
+func containsAll[T any](subset []T, set []T) bool {
+	for _, s := range subset {
+		found := false
+		for _, e := range set {
+			if reflect.DeepEqual(s, e) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// matchingOrder returns the registrations, including the ones of the parents of a scope, in the order in which they are tried.
+// The registrations of a scope are always tried before the ones of its parents, the priorities only order the registrations of the same registry.
+// It must be called while holding the lock
+func (reg *Registry) matchingOrder() []*Registration {
+	registrations := []*Registration{}
+	for layer := reg; layer != nil; layer = layer.parent {
+		registrations = append(registrations, layer.sortedRegistrations()...)
+	}
+	return registrations
+}
+
+// sortedRegistrations returns the registrations of reg, without the ones of its parents, sorted by priority and by the strategy of reg.
+// It must be called while holding the lock
+func (reg *Registry) sortedRegistrations() []*Registration {
+	registrations := append([]*Registration{}, reg.registrations...)
+	sort.SliceStable(registrations, func(i, j int) bool {
+		if registrations[i].priority != registrations[j].priority {
+			return registrations[i].priority > registrations[j].priority
+		}
+		if reg.strategy == MostSpecificMatch {
+			return registrations[i].match.Request().numberOfCriteria() > registrations[j].match.Request().numberOfCriteria()
+		}
+		return false
+	})
+	return registrations
+}
+
+// shadowedRegistration is a registration that can never be matched together with the registration that prevents it
+type shadowedRegistration struct {
+	registration *Registration
+	shadowedBy   *Registration
+}
+
+// shadowedRegistrations returns the enabled registrations of reg that can never be matched
+// because an enabled registration that is tried before them never runs out of responses and matches every request that they match.
+// It must be called while holding the lock
+func (reg *Registry) shadowedRegistrations() []shadowedRegistration {
+	order := reg.matchingOrder()
+	own := map[*Registration]bool{}
+	for _, registration := range reg.registrations {
+		own[registration] = true
+	}
+
+	shadowed := []shadowedRegistration{}
+	for j, registration := range order {
+		if registration.disabled || !own[registration] {
+			continue
+		}
+		for _, previous := range order[:j] {
+			if previous.disabled || previous.match.RemainingResponses() != InfiniteResponses {
+				continue
+			}
+			if previous.match.Request().covers(registration.match.Request()) {
+				shadowed = append(shadowed, shadowedRegistration{registration: registration, shadowedBy: previous})
+				break
+			}
+		}
+	}
+	return shadowed
+}
+
+// CheckNoShadowedRegistrations fails the test for each registration that can never be matched,
+// because a registration that is tried before it never runs out of responses and matches every request that it matches.
+// This usually happens when a catch-all like AddInfiniteResponse is registered before more specific requests.
+// Shadowed registrations with unused responses are also reported by CheckAllResponsesAreConsumed
+func (reg *Registry) CheckNoShadowedRegistrations() {
+	reg.mu.Lock()
+	shadowed := reg.shadowedRegistrations()
+	reg.mu.Unlock()
+
+	for _, s := range shadowed {
+		reg.t.Errorf("request %v can never be matched because it is shadowed by %v", s.registration, s.shadowedBy)
+	}
+}
@@ -0,0 +1,152 @@
+package httpregistry_test
+
+import (
+	"net/http"
+
+	"github.com/dfioravanti/httpregistry"
+)
+
+func (s *TestSuite) TestPriorityOverridesTheOrderOfRegistration() {
+	reg := httpregistry.NewRegistry(s.T())
+	catchAll := reg.AddInfiniteResponse(httpregistry.NotFoundResponse)
+	users := reg.AddRequestWithInfiniteResponse(httpregistry.NewRequest().WithURL("/users"), httpregistry.OkResponse)
+	users.SetPriority(1)
+	url := reg.GetServer().URL
+
+	status, _ := s.getBody(url + "/users")
+	s.Equal(http.StatusOK, status)
+	status, _ = s.getBody(url + "/orders")
+	s.Equal(http.StatusNotFound, status)
+
+	s.Equal(1, users.Priority())
+	s.Equal(0, catchAll.Priority())
+	users.SetPriority(-1)
+	status, _ = s.getBody(url + "/users")
+	s.Equal(http.StatusNotFound, status)
+}
+
+func (s *TestSuite) TestMostSpecificMatch() {
+	reg := httpregistry.NewRegistry(s.T())
+	reg.SetMatchingStrategy(httpregistry.MostSpecificMatch)
+	reg.AddInfiniteResponse(httpregistry.NotFoundResponse)
+	reg.AddRequestWithInfiniteResponse(httpregistry.NewRequest().WithURL("/users"), httpregistry.NoContentResponse)
+	reg.AddRequestWithInfiniteResponse(httpregistry.NewRequest().WithMethod(http.MethodGet).WithURL("/users"), httpregistry.OkResponse)
+	url := reg.GetServer().URL
+
+	status, _ := s.getBody(url + "/users")
+	s.Equal(http.StatusOK, status)
+
+	res, err := http.Post(url+"/users", "application/json", nil)
+	s.NoError(err)
+	s.Equal(http.StatusNoContent, res.StatusCode)
+
+	status, _ = s.getBody(url + "/orders")
+	s.Equal(http.StatusNotFound, status)
+
+	s.Equal("most specific", httpregistry.MostSpecificMatch.String())
+}
+
+func (s *TestSuite) TestPriorityWinsOverSpecificity() {
+	reg := httpregistry.NewRegistry(s.T())
+	reg.SetMatchingStrategy(httpregistry.MostSpecificMatch)
+	reg.AddRequestWithInfiniteResponse(httpregistry.NewRequest().WithMethod(http.MethodGet).WithURL("/users"), httpregistry.OkResponse)
+	maintenance := reg.AddInfiniteResponse(httpregistry.ServiceUnavailableResponse)
+	maintenance.SetPriority(10)
+	url := reg.GetServer().URL
+
+	status, _ := s.getBody(url + "/users")
+	s.Equal(http.StatusServiceUnavailable, status)
+}
+
+func (s *TestSuite) TestShadowedRegistrations() {
+	testCases := []struct {
+		name             string
+		setup            func(reg *httpregistry.Registry)
+		expectedMessages []string
+	}{
+		{
+			name: "catch-all registered first",
+			setup: func(reg *httpregistry.Registry) {
+				reg.AddInfiniteResponse(httpregistry.NotFoundResponse)
+				reg.AddMethodAndURL(http.MethodGet, "/users")
+			},
+			expectedMessages: []string{"request mock request #2 can never be matched because it is shadowed by mock request #1"},
+		},
+		{
+			name: "broader request registered first",
+			setup: func(reg *httpregistry.Registry) {
+				reg.AddRequestWithInfiniteResponse(httpregistry.NewRequest().WithURL("/users"), httpregistry.OkResponse)
+				reg.AddRequestWithInfiniteResponse(httpregistry.NewRequest().WithURL("/orders"), httpregistry.OkResponse)
+				reg.AddRequestWithInfiniteResponse(
+					httpregistry.NewRequest().WithName("get users").WithMethod(http.MethodGet).WithURL("/users"),
+					httpregistry.OkResponse,
+				)
+			},
+			expectedMessages: []string{"request get users can never be matched because it is shadowed by mock request #1"},
+		},
+		{
+			name: "catch-all with lower priority",
+			setup: func(reg *httpregistry.Registry) {
+				catchAll := reg.AddInfiniteResponse(httpregistry.NotFoundResponse)
+				catchAll.SetPriority(-1)
+				reg.AddMethodAndURL(http.MethodGet, "/users")
+			},
+		},
+		{
+			name: "most specific strategy",
+			setup: func(reg *httpregistry.Registry) {
+				reg.SetMatchingStrategy(httpregistry.MostSpecificMatch)
+				reg.AddInfiniteResponse(httpregistry.NotFoundResponse)
+				reg.AddMethodAndURL(http.MethodGet, "/users")
+			},
+		},
+		{
+			name: "catch-all that can be consumed",
+			setup: func(reg *httpregistry.Registry) {
+				reg.AddResponse(httpregistry.NotFoundResponse)
+				reg.AddMethodAndURL(http.MethodGet, "/users")
+			},
+		},
+		{
+			name: "disabled catch-all",
+			setup: func(reg *httpregistry.Registry) {
+				reg.AddInfiniteResponse(httpregistry.NotFoundResponse).Disable()
+				reg.AddMethodAndURL(http.MethodGet, "/users")
+			},
+		},
+	}
+	for _, tc := range testCases {
+		s.Run(tc.name, func() {
+			t := httpregistry.NewMockTestingT()
+			reg := httpregistry.NewRegistry(t)
+			tc.setup(reg)
+
+			reg.CheckNoShadowedRegistrations()
+			s.Equal(len(tc.expectedMessages) > 0, t.HasFailed)
+			s.Equal(tc.expectedMessages, t.Messages)
+		})
+	}
+}
+
+func (s *TestSuite) TestCheckAllResponsesAreConsumedReportsShadowedRegistrations() {
+	t := httpregistry.NewMockTestingT()
+	reg := httpregistry.NewRegistry(t)
+	reg.AddInfiniteResponse(httpregistry.NotFoundResponse)
+	reg.AddMethodAndURL(http.MethodGet, "/users")
+
+	reg.CheckAllResponsesAreConsumed()
+	s.Contains(t.Messages, "request mock request #2 has httpregistry.OkResponse as unused response, it can never be matched because it is shadowed by mock request #1")
+}
+
+func (s *TestSuite) TestStubPriority() {
+	reg := httpregistry.NewRegistry(s.T())
+	reg.AddInfiniteResponse(httpregistry.NotFoundResponse)
+	stubs, err := httpregistry.ParseStubs([]byte(`{"stubs": [{"request": {"url": "/users"}, "infinite": true, "priority": 5}]}`))
+	s.NoError(err)
+	registration, err := reg.AddStub(stubs[0])
+	s.NoError(err)
+	s.Equal(5, registration.Priority())
+
+	status, _ := s.getBody(reg.GetServer().URL + "/users")
+	s.Equal(http.StatusOK, status)
+}
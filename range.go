@@ -0,0 +1,201 @@
+package httpregistry
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"strconv"
+	"strings"
+)
+
+// RangeResponse is a response that serves a byte payload honouring the `Range` header of GET and HEAD requests, as a file server does.
+// A single range is answered with a PartialContentResponse with the header `Content-Range`, multiple ranges with a
+// PartialContentResponse whose body is `multipart/byteranges` and ranges that cannot be satisfied with a RangeNotSatisfiableResponse.
+// Requests without a valid `Range` header receive the whole payload with a 200
+type RangeResponse struct {
+	name        string
+	payload     []byte
+	contentType string
+	// abortAfter is the number of bytes of the body after which the connection is closed, it is negative if the body is sent completely
+	abortAfter int
+}
+
+// NewRangeResponse creates a RangeResponse that serves payload with the `Content-Type` `application/octet-stream`
+//
+//	reg := httpregistry.NewRegistry(t)
+//	reg.AddRequestWithInfiniteResponse(
+//		httpregistry.NewRequest().WithURL("/files/archive.zip"),
+//		httpregistry.NewRangeResponse(archive).WithAbortAfter(1024),
+//	)
+func NewRangeResponse(payload []byte) RangeResponse {
+	return RangeResponse{
+		payload:     payload,
+		contentType: "application/octet-stream",
+		abortAfter:  -1,
+	}
+}
+
+// WithName allows to add a name to a RangeResponse so that it can be better identified when debugging
+func (res RangeResponse) WithName(name string) RangeResponse {
+	res.name = name
+	return res
+}
+
+// String returns the name of the RangeResponse
+func (res RangeResponse) String() string {
+	return res.name
+}
+
+// WithContentType returns a new RangeResponse that serves the payload with the `Content-Type` contentType
+func (res RangeResponse) WithContentType(contentType string) RangeResponse {
+	res.contentType = contentType
+	return res
+}
+
+// WithAbortAfter returns a new RangeResponse that closes the connection after n bytes of the body were sent,
+// while the `Content-Length` announces the whole body. It is designed to simulate interrupted downloads.
+// This function panics if n is negative
+func (res RangeResponse) WithAbortAfter(n int) RangeResponse {
+	if n < 0 {
+		panic(fmt.Sprintf("the number of bytes after which the response is aborted cannot be negative, got %d", n))
+	}
+	res.abortAfter = n
+	return res
+}
+
+// serveResponse emits to w the part of the payload requested by r
+func (res RangeResponse) serveResponse(w http.ResponseWriter, r *http.Request) {
+	response := res.response(r)
+	if res.abortAfter < 0 || res.abortAfter >= len(response.body) {
+		response.serveResponse(w, r)
+		return
+	}
+
+	response = response.WithHeader("Content-Length", strconv.Itoa(len(response.body)))
+	response.body = response.body[:res.abortAfter]
+	response.serveResponse(w, r)
+	_ = http.NewResponseController(w).Flush()
+	// http.ErrAbortHandler makes the server close the connection without logging, so the client sees a truncated body
+	panic(http.ErrAbortHandler)
+}
+
+// response returns the Response that answers r
+func (res RangeResponse) response(r *http.Request) Response {
+	size := len(res.payload)
+	full := OkResponse.
+		WithHeader("Accept-Ranges", "bytes").
+		WithHeader("Content-Type", res.contentType).
+		WithBody(res.payload)
+
+	header := r.Header.Get("Range")
+	if header == "" || (r.Method != http.MethodGet && r.Method != http.MethodHead) {
+		return full
+	}
+
+	ranges, err := parseRanges(header, size)
+	if errors.Is(err, errRangeNotSatisfiable) {
+		return RangeNotSatisfiableResponse.
+			WithHeader("Accept-Ranges", "bytes").
+			WithHeader("Content-Range", fmt.Sprintf("bytes */%d", size))
+	}
+	if err != nil {
+		// a Range header that is not valid must be ignored
+		return full
+	}
+
+	if len(ranges) == 1 {
+		return PartialContentResponse.
+			WithHeader("Accept-Ranges", "bytes").
+			WithHeader("Content-Type", res.contentType).
+			WithHeader("Content-Range", ranges[0].contentRange(size)).
+			WithBody(res.payload[ranges[0].start : ranges[0].end+1])
+	}
+
+	var body bytes.Buffer
+	parts := multipart.NewWriter(&body)
+	for _, byteRange := range ranges {
+		part, _ := parts.CreatePart(textproto.MIMEHeader{
+			"Content-Type":  {res.contentType},
+			"Content-Range": {byteRange.contentRange(size)},
+		})
+		_, _ = part.Write(res.payload[byteRange.start : byteRange.end+1])
+	}
+	_ = parts.Close()
+
+	return PartialContentResponse.
+		WithHeader("Accept-Ranges", "bytes").
+		WithHeader("Content-Type", "multipart/byteranges; boundary="+parts.Boundary()).
+		WithBody(body.Bytes())
+}
+
+var (
+	errInvalidRange        = errors.New("the range is not valid")
+	errRangeNotSatisfiable = errors.New("the range cannot be satisfied")
+)
+
+// byteRange is a range of bytes of a payload, both start and end are included
+type byteRange struct {
+	start int
+	end   int
+}
+
+// contentRange returns the value of the header `Content-Range` of the range for a payload of size bytes
+func (b byteRange) contentRange(size int) string {
+	return fmt.Sprintf("bytes %d-%d/%d", b.start, b.end, size)
+}
+
+// parseRanges parses the value of a `Range` header for a payload of size bytes.
+// The ranges that cannot be satisfied are dropped, if none of them can be satisfied errRangeNotSatisfiable is returned
+func parseRanges(header string, size int) ([]byteRange, error) {
+	specs, found := strings.CutPrefix(header, "bytes=")
+	if !found {
+		return nil, errInvalidRange
+	}
+
+	ranges := []byteRange{}
+	for _, spec := range strings.Split(specs, ",") {
+		spec = strings.TrimSpace(spec)
+		first, last, found := strings.Cut(spec, "-")
+		if !found {
+			return nil, errInvalidRange
+		}
+
+		if first == "" {
+			// a suffix range selects the last bytes of the payload
+			length, err := strconv.Atoi(last)
+			if err != nil || length < 0 {
+				return nil, errInvalidRange
+			}
+			if length == 0 || size == 0 {
+				continue
+			}
+			ranges = append(ranges, byteRange{start: max(size-length, 0), end: size - 1})
+			continue
+		}
+
+		start, err := strconv.Atoi(first)
+		if err != nil || start < 0 {
+			return nil, errInvalidRange
+		}
+		end := size - 1
+		if last != "" {
+			end, err = strconv.Atoi(last)
+			if err != nil || end < start {
+				return nil, errInvalidRange
+			}
+			end = min(end, size-1)
+		}
+		if start >= size {
+			continue
+		}
+		ranges = append(ranges, byteRange{start: start, end: end})
+	}
+
+	if len(ranges) == 0 {
+		return nil, errRangeNotSatisfiable
+	}
+	return ranges, nil
+}
@@ -0,0 +1,127 @@
+package httpregistry_test
+
+import (
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"strings"
+
+	"github.com/dfioravanti/httpregistry"
+)
+
+// getRange calls url with GET and the header `Range` set to ranges, if not empty, and returns the response and its body
+func (s *TestSuite) getRange(url string, ranges string) (*http.Response, string) {
+	request, err := http.NewRequest(http.MethodGet, url, nil)
+	s.Require().NoError(err)
+	if ranges != "" {
+		request.Header.Set("Range", ranges)
+	}
+	res, err := http.DefaultClient.Do(request)
+	s.Require().NoError(err)
+	defer res.Body.Close()
+	body, err := io.ReadAll(res.Body)
+	s.Require().NoError(err)
+	return res, string(body)
+}
+
+func (s *TestSuite) TestRangeResponse() {
+	reg := httpregistry.NewRegistry(s.T())
+	reg.AddInfiniteResponse(httpregistry.NewRangeResponse([]byte("0123456789")).WithContentType("text/plain"))
+	url := reg.GetServer().URL
+
+	res, body := s.getRange(url, "")
+	s.Equal(http.StatusOK, res.StatusCode)
+	s.Equal("bytes", res.Header.Get("Accept-Ranges"))
+	s.Equal("text/plain", res.Header.Get("Content-Type"))
+	s.Equal("0123456789", body)
+
+	testCases := []struct {
+		ranges       string
+		contentRange string
+		body         string
+	}{
+		{ranges: "bytes=2-5", contentRange: "bytes 2-5/10", body: "2345"},
+		{ranges: "bytes=7-", contentRange: "bytes 7-9/10", body: "789"},
+		{ranges: "bytes=-3", contentRange: "bytes 7-9/10", body: "789"},
+		{ranges: "bytes=8-100", contentRange: "bytes 8-9/10", body: "89"},
+		{ranges: "bytes=20-30, 1-1", contentRange: "bytes 1-1/10", body: "1"},
+	}
+	for _, tc := range testCases {
+		res, body = s.getRange(url, tc.ranges)
+		s.Equal(http.StatusPartialContent, res.StatusCode, tc.ranges)
+		s.Equal(tc.contentRange, res.Header.Get("Content-Range"), tc.ranges)
+		s.Equal(tc.body, body, tc.ranges)
+	}
+
+	res, body = s.getRange(url, "items=0-1")
+	s.Equal(http.StatusOK, res.StatusCode)
+	s.Equal("0123456789", body)
+}
+
+func (s *TestSuite) TestMultiRangeResponse() {
+	reg := httpregistry.NewRegistry(s.T())
+	reg.AddInfiniteResponse(httpregistry.NewRangeResponse([]byte("0123456789")))
+
+	res, body := s.getRange(reg.GetServer().URL, "bytes=0-1, 5-6")
+	s.Equal(http.StatusPartialContent, res.StatusCode)
+
+	mediaType, params, err := mime.ParseMediaType(res.Header.Get("Content-Type"))
+	s.NoError(err)
+	s.Equal("multipart/byteranges", mediaType)
+
+	parts := multipart.NewReader(strings.NewReader(body), params["boundary"])
+	expected := []struct{ contentRange, body string }{
+		{contentRange: "bytes 0-1/10", body: "01"},
+		{contentRange: "bytes 5-6/10", body: "56"},
+	}
+	for _, e := range expected {
+		part, err := parts.NextPart()
+		s.Require().NoError(err)
+		s.Equal("application/octet-stream", part.Header.Get("Content-Type"))
+		s.Equal(e.contentRange, part.Header.Get("Content-Range"))
+		b, err := io.ReadAll(part)
+		s.NoError(err)
+		s.Equal(e.body, string(b))
+	}
+	_, err = parts.NextPart()
+	s.ErrorIs(err, io.EOF)
+}
+
+func (s *TestSuite) TestRangeNotSatisfiable() {
+	reg := httpregistry.NewRegistry(s.T())
+	reg.AddInfiniteResponse(httpregistry.NewRangeResponse([]byte("0123456789")))
+
+	res, body := s.getRange(reg.GetServer().URL, "bytes=10-20")
+	s.Equal(http.StatusRequestedRangeNotSatisfiable, res.StatusCode)
+	s.Equal("bytes */10", res.Header.Get("Content-Range"))
+	s.Empty(body)
+}
+
+func (s *TestSuite) TestRangeResponseAbortAfter() {
+	reg := httpregistry.NewRegistry(s.T())
+	reg.AddResponses(
+		httpregistry.NewRangeResponse([]byte("0123456789")).WithAbortAfter(4),
+		httpregistry.NewRangeResponse([]byte("0123456789")),
+	)
+	url := reg.GetServer().URL
+
+	res, err := http.Get(url)
+	s.Require().NoError(err)
+	s.Equal(http.StatusOK, res.StatusCode)
+	s.Equal(int64(10), res.ContentLength)
+	body, err := io.ReadAll(res.Body)
+	res.Body.Close()
+	s.ErrorIs(err, io.ErrUnexpectedEOF)
+	s.Equal("0123", string(body))
+
+	res, resumed := s.getRange(url, "bytes=4-")
+	s.Equal(http.StatusPartialContent, res.StatusCode)
+	s.Equal("0123456789", "0123"+resumed)
+}
+
+func (s *TestSuite) TestWithAbortAfterPanicsOnNegativeBytes() {
+	s.PanicsWithValue("the number of bytes after which the response is aborted cannot be negative, got -1", func() {
+		httpregistry.NewRangeResponse(nil).WithAbortAfter(-1)
+	})
+}
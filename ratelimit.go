@@ -0,0 +1,140 @@
+package httpregistry
+
+import (
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// RateLimit describes how many requests a registration created with Registry.AddRequestWithRateLimit allows in a window of time.
+// Requests are counted separately for each client, by default clients are identified by their IP address
+type RateLimit struct {
+	limit  int
+	window time.Duration
+	// keyHeader is the header that identifies the client, if it is empty the client is identified by its IP address
+	keyHeader string
+	exceeded  Response
+}
+
+// NewRateLimit creates a RateLimit that allows limit requests per client every window.
+// This function panics if limit is negative or window is not positive
+//
+//	httpregistry.NewRateLimit(10, time.Minute).WithKeyHeader("X-API-Key")
+func NewRateLimit(limit int, window time.Duration) RateLimit {
+	if limit < 0 {
+		panic("the limit of a RateLimit cannot be negative")
+	}
+	if window <= 0 {
+		panic("the window of a RateLimit must be positive")
+	}
+	return RateLimit{
+		limit:    limit,
+		window:   window,
+		exceeded: TooManyRequestsResponse,
+	}
+}
+
+// WithKeyHeader returns a new RateLimit that identifies the clients by the value of header, for example an API key,
+// instead of by their IP address. Requests without the header share the same limit
+func (l RateLimit) WithKeyHeader(header string) RateLimit {
+	l.keyHeader = header
+	return l
+}
+
+// WithExceededResponse returns a new RateLimit that returns response when the limit is exceeded instead of an empty 429.
+// The rate limit headers and `Retry-After` are added to response
+func (l RateLimit) WithExceededResponse(response Response) RateLimit {
+	l.exceeded = response
+	return l
+}
+
+// key returns the identifier of the client that sent r
+func (l RateLimit) key(r *http.Request) string {
+	if l.keyHeader != "" {
+		return r.Header.Get(l.keyHeader)
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// rateLimitWindow counts the requests of a client in the current window
+type rateLimitWindow struct {
+	start time.Time
+	count int
+}
+
+// rateLimitedResponse is the response of a registration created with AddRequestWithRateLimit.
+// It serves response while the client is within the limit and the exceeded response of the limit afterward
+type rateLimitedResponse struct {
+	limit    RateLimit
+	response mockResponse
+
+	mu      *sync.Mutex
+	windows map[string]*rateLimitWindow
+}
+
+// serveResponse counts r in the window of its client and emits either the response or the exceeded response of the limit
+func (res rateLimitedResponse) serveResponse(w http.ResponseWriter, r *http.Request) {
+	now := clockFromRequest(r).Now()
+	key := res.limit.key(r)
+
+	res.mu.Lock()
+	window, found := res.windows[key]
+	if !found || !now.Before(window.start.Add(res.limit.window)) {
+		window = &rateLimitWindow{start: now}
+		res.windows[key] = window
+	}
+	window.count++
+	count := window.count
+	reset := window.start.Add(res.limit.window)
+	res.mu.Unlock()
+
+	untilReset := int(math.Ceil(reset.Sub(now).Seconds()))
+	remaining := max(res.limit.limit-count, 0)
+	headers := w.Header()
+	headers.Set("X-RateLimit-Limit", strconv.Itoa(res.limit.limit))
+	headers.Set("X-RateLimit-Remaining", strconv.Itoa(remaining))
+	headers.Set("X-RateLimit-Reset", strconv.FormatInt(reset.Unix(), 10))
+	headers.Set("RateLimit-Limit", strconv.Itoa(res.limit.limit))
+	headers.Set("RateLimit-Remaining", strconv.Itoa(remaining))
+	headers.Set("RateLimit-Reset", strconv.Itoa(untilReset))
+
+	if count > res.limit.limit {
+		headers.Set("Retry-After", strconv.Itoa(untilReset))
+		res.limit.exceeded.serveResponse(w, r)
+		return
+	}
+	res.response.serveResponse(w, r)
+}
+
+// String returns the name of the response served while the client is within the limit
+func (res rateLimitedResponse) String() string {
+	return res.response.String()
+}
+
+// AddRequestWithRateLimit adds to the registry a request that is served with response, which is never consumed, as long as the client
+// that sent it is within limit. Once the limit is exceeded the request is answered with a 429 until the window of the client ends.
+// Every response carries the `X-RateLimit-Limit`, `X-RateLimit-Remaining` and `X-RateLimit-Reset` headers and their standard
+// counterparts `RateLimit-Limit`, `RateLimit-Remaining` and `RateLimit-Reset`, the 429 also carries `Retry-After`.
+// The windows follow the clock of the registry, see SetClock
+//
+//	reg := httpregistry.NewRegistry(t)
+//	reg.AddRequestWithRateLimit(
+//		httpregistry.NewRequest().WithURL("/users"),
+//		httpregistry.NewRateLimit(2, time.Minute).WithKeyHeader("X-API-Key"),
+//		httpregistry.OkResponse,
+//	)
+func (reg *Registry) AddRequestWithRateLimit(request Request, limit RateLimit, response mockResponse) *Registration {
+	return reg.AddRequestWithInfiniteResponse(request, rateLimitedResponse{
+		limit:    limit,
+		response: reg.ifNeededSetDefaultNameToMockResponse(response),
+		mu:       &sync.Mutex{},
+		windows:  map[string]*rateLimitWindow{},
+	})
+}
@@ -0,0 +1,86 @@
+package httpregistry_test
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/dfioravanti/httpregistry"
+)
+
+// getWithHeader calls url with GET and the header header set to value
+func (s *TestSuite) getWithHeader(url string, header string, value string) *http.Response {
+	request, err := http.NewRequest(http.MethodGet, url, nil)
+	s.Require().NoError(err)
+	if header != "" {
+		request.Header.Set(header, value)
+	}
+	res, err := http.DefaultClient.Do(request)
+	s.Require().NoError(err)
+	res.Body.Close()
+	return res
+}
+
+func (s *TestSuite) TestRateLimit() {
+	clock := httpregistry.NewFakeClock(time.Date(2024, time.January, 1, 12, 0, 0, 0, time.UTC))
+	reg := httpregistry.NewRegistry(s.T())
+	reg.SetClock(clock)
+	reg.AddRequestWithRateLimit(
+		httpregistry.NewRequest().WithURL("/users"),
+		httpregistry.NewRateLimit(2, time.Minute),
+		httpregistry.OkResponse,
+	)
+	url := reg.GetServer().URL + "/users"
+
+	res := s.getWithHeader(url, "", "")
+	s.Equal(http.StatusOK, res.StatusCode)
+	s.Equal("2", res.Header.Get("X-RateLimit-Limit"))
+	s.Equal("1", res.Header.Get("X-RateLimit-Remaining"))
+	s.Equal("1704110460", res.Header.Get("X-RateLimit-Reset"))
+	s.Equal("2", res.Header.Get("RateLimit-Limit"))
+	s.Equal("1", res.Header.Get("RateLimit-Remaining"))
+	s.Equal("60", res.Header.Get("RateLimit-Reset"))
+
+	clock.Advance(20 * time.Second)
+	res = s.getWithHeader(url, "", "")
+	s.Equal(http.StatusOK, res.StatusCode)
+	s.Equal("0", res.Header.Get("X-RateLimit-Remaining"))
+
+	res = s.getWithHeader(url, "", "")
+	s.Equal(http.StatusTooManyRequests, res.StatusCode)
+	s.Equal("0", res.Header.Get("RateLimit-Remaining"))
+	s.Equal("40", res.Header.Get("RateLimit-Reset"))
+	s.Equal("40", res.Header.Get("Retry-After"))
+
+	clock.Advance(40 * time.Second)
+	res = s.getWithHeader(url, "", "")
+	s.Equal(http.StatusOK, res.StatusCode)
+	s.Equal("1", res.Header.Get("RateLimit-Remaining"))
+	s.Empty(res.Header.Get("Retry-After"))
+}
+
+func (s *TestSuite) TestRateLimitByHeader() {
+	clock := httpregistry.NewFakeClock(time.Date(2024, time.January, 1, 12, 0, 0, 0, time.UTC))
+	reg := httpregistry.NewRegistry(s.T())
+	reg.SetClock(clock)
+	reg.AddRequestWithRateLimit(
+		httpregistry.NewRequest().WithURL("/users"),
+		httpregistry.NewRateLimit(1, time.Hour).
+			WithKeyHeader("X-API-Key").
+			WithExceededResponse(httpregistry.TooManyRequestsResponse.WithJSONBody(map[string]string{"error": "slow down"})),
+		httpregistry.NoContentResponse,
+	)
+	url := reg.GetServer().URL + "/users"
+
+	s.Equal(http.StatusNoContent, s.getWithHeader(url, "X-API-Key", "alice").StatusCode)
+	s.Equal(http.StatusNoContent, s.getWithHeader(url, "X-API-Key", "bob").StatusCode)
+
+	res := s.getWithHeader(url, "X-API-Key", "alice")
+	s.Equal(http.StatusTooManyRequests, res.StatusCode)
+	s.Equal("application/json", res.Header.Get("Content-Type"))
+	s.Equal("3600", res.Header.Get("Retry-After"))
+}
+
+func (s *TestSuite) TestNewRateLimitPanicsOnInvalidArguments() {
+	s.PanicsWithValue("the limit of a RateLimit cannot be negative", func() { httpregistry.NewRateLimit(-1, time.Second) })
+	s.PanicsWithValue("the window of a RateLimit must be positive", func() { httpregistry.NewRateLimit(1, 0) })
+}
@@ -0,0 +1,166 @@
+package httpregistry
+
+import (
+	"net/http"
+)
+
+// Registration is a handle to a request registered to a Registry together with its responses.
+// It is returned by all the Add* methods of Registry and it can be used to inspect the registration during or after the test,
+// or to disable or remove it while the test is running.
+//
+//	reg := httpregistry.NewRegistry(t)
+//	users := reg.AddMethodAndURL(http.MethodGet, "/users")
+//	...
+//	if users.NumberOfCalls() != 1 {
+//		t.Errorf("GET /users was called %d times", users.NumberOfCalls())
+//	}
+type Registration struct {
+	reg      *Registry
+	id       int
+	match    match
+	disabled bool
+	priority int
+	// stub is the definition of the registration if it was added with AddStub, otherwise it is nil
+	stub *Stub
+}
+
+// register adds m to the registry and returns the handle to the new registration
+func (reg *Registry) register(m match) *Registration {
+	return reg.registerWith(m, func(*Registration) {})
+}
+
+// registerWith is like register but it calls configure on the new registration while holding the lock,
+// so that the registration is configured before it can match any request
+func (reg *Registry) registerWith(m match, configure func(*Registration)) *Registration {
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+
+	// the IDs are assigned by the outermost registry, so that the registrations of a scope do not share an ID with the ones of its parents
+	root := reg
+	for root.parent != nil {
+		root = root.parent
+	}
+	root.lastRegistrationID++
+	registration := &Registration{reg: reg, id: root.lastRegistrationID, match: m}
+	configure(registration)
+	reg.registrations = append(reg.registrations, registration)
+	return registration
+}
+
+// GetRegistrations returns, in order of registration, the registrations of the registry that were not removed,
+// including the ones added through the admin API. The registrations of the parent of a scope are not included
+func (reg *Registry) GetRegistrations() []*Registration {
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+	return append([]*Registration{}, reg.registrations...)
+}
+
+// enabledMatches returns the matches of the registrations that are currently enabled, including the ones of the parents of a scope
+func (reg *Registry) enabledMatches() []match {
+	registrations := reg.layeredRegistrations()
+	matches := make([]match, 0, len(registrations))
+	for _, registration := range registrations {
+		if !registration.disabled {
+			matches = append(matches, registration.match)
+		}
+	}
+	return matches
+}
+
+// Request returns the request of the registration
+func (r *Registration) Request() Request {
+	return r.match.Request()
+}
+
+// ID returns the identifier of the registration, it is unique within the registry and it never changes.
+// It is the identifier used by the admin API to refer to the registration
+func (r *Registration) ID() int {
+	return r.id
+}
+
+// String returns the name of the request of the registration
+func (r *Registration) String() string {
+	return r.match.Request().String()
+}
+
+// NumberOfCalls returns the number of times the registration was used to serve a request
+func (r *Registration) NumberOfCalls() int {
+	r.reg.mu.Lock()
+	defer r.reg.mu.Unlock()
+	return r.match.NumberOfCalls()
+}
+
+// Matches returns, in chronological order, the http.Request that were served by the registration,
+// only the last ones if a limit was set with Registry.SetJournalLimit.
+// The requests are cloned so that the body can be accessed every time this function is called
+func (r *Registration) Matches() []*http.Request {
+	r.reg.mu.Lock()
+	defer r.reg.mu.Unlock()
+
+	matches := r.match.Matches()
+	matchesToReturn := make([]*http.Request, 0, len(matches))
+	for _, m := range matches {
+		matchesToReturn = append(matchesToReturn, cloneHTTPRequest(m))
+	}
+	return matchesToReturn
+}
+
+// RemainingResponses returns the number of responses that the registration can still return.
+// Registrations created with AddInfiniteResponse or AddRequestWithInfiniteResponse return InfiniteResponses
+func (r *Registration) RemainingResponses() int {
+	r.reg.mu.Lock()
+	defer r.reg.mu.Unlock()
+	return r.match.RemainingResponses()
+}
+
+// ExpectationsMet returns true if all the responses of the registration were consumed.
+// Registrations whose responses are never consumed have no expectation on the number of calls and they always return true
+func (r *Registration) ExpectationsMet() bool {
+	remaining := r.RemainingResponses()
+	return remaining == 0 || remaining == InfiniteResponses
+}
+
+// CheckAllResponsesAreConsumed fails the test if the registration has unused responses.
+// It is the equivalent of Registry.CheckAllResponsesAreConsumed for a single registration
+func (r *Registration) CheckAllResponsesAreConsumed() {
+	if !r.ExpectationsMet() {
+		r.reg.t.Errorf("request %v has %d unused responses", r, r.RemainingResponses())
+	}
+}
+
+// Disable stops the registration from matching incoming requests until Enable is called.
+// A disabled registration keeps its responses and the requests that it matched
+func (r *Registration) Disable() {
+	r.reg.mu.Lock()
+	defer r.reg.mu.Unlock()
+	r.disabled = true
+}
+
+// Enable allows a registration that was disabled with Disable to match incoming requests again
+func (r *Registration) Enable() {
+	r.reg.mu.Lock()
+	defer r.reg.mu.Unlock()
+	r.disabled = false
+}
+
+// IsEnabled returns true if the registration can match incoming requests
+func (r *Registration) IsEnabled() bool {
+	r.reg.mu.Lock()
+	defer r.reg.mu.Unlock()
+	return !r.disabled
+}
+
+// Remove removes the registration from the registry, so that it does not match incoming requests anymore and
+// it is not considered by functions like Registry.CheckAllResponsesAreConsumed.
+// The handle can still be used to inspect the requests that were matched before the removal
+func (r *Registration) Remove() {
+	r.reg.mu.Lock()
+	defer r.reg.mu.Unlock()
+
+	for i, registration := range r.reg.registrations {
+		if registration == r {
+			r.reg.registrations = append(r.reg.registrations[:i:i], r.reg.registrations[i+1:]...)
+			return
+		}
+	}
+}
@@ -0,0 +1,152 @@
+package httpregistry_test
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+
+	"github.com/dfioravanti/httpregistry"
+)
+
+func (s *TestSuite) TestRegistrationsSharingTheSameRequestCanBeInspected() {
+	registry := httpregistry.NewRegistry(s.T())
+	first := registry.AddMethodAndURLWithStatusCode(http.MethodPost, "/users", http.StatusCreated)
+	second := registry.AddMethodAndURLWithStatusCode(http.MethodPost, "/users", http.StatusConflict)
+	catchAll := registry.AddResponse(httpregistry.NoContentResponse)
+
+	server := registry.GetServer()
+	defer server.Close()
+
+	s.Equal(1, first.RemainingResponses())
+	s.False(first.ExpectationsMet())
+
+	for _, body := range []string{"first", "second", "third"} {
+		_, err := http.Post(server.URL+"/users", "text/plain", bytes.NewBufferString(body))
+		s.NoError(err)
+	}
+
+	for registration, expectedBody := range map[*httpregistry.Registration]string{first: "first", second: "second", catchAll: "third"} {
+		s.Equal(1, registration.NumberOfCalls())
+		s.Equal(0, registration.RemainingResponses())
+		s.True(registration.ExpectationsMet())
+
+		matches := registration.Matches()
+		s.Equal(1, len(matches))
+		body, err := io.ReadAll(matches[0].Body)
+		s.NoError(err)
+		s.Equal(expectedBody, string(body))
+	}
+	s.Equal("mock request #3", catchAll.String())
+}
+
+func (s *TestSuite) TestInfiniteRegistrationsHaveNoExpectations() {
+	registry := httpregistry.NewRegistry(s.T())
+	registration := registry.AddInfiniteResponse(httpregistry.OkResponse)
+
+	s.Equal(httpregistry.InfiniteResponses, registration.RemainingResponses())
+	s.True(registration.ExpectationsMet())
+	registration.CheckAllResponsesAreConsumed()
+}
+
+func (s *TestSuite) TestCheckAllResponsesAreConsumedForASingleRegistration() {
+	mockT := httpregistry.NewMockTestingT()
+	registry := httpregistry.NewRegistry(mockT)
+	registration := registry.AddRequestWithResponses(
+		httpregistry.NewRequest().WithURL("/foo").WithName("foo"),
+		httpregistry.OkResponse,
+		httpregistry.OkResponse,
+	)
+
+	registration.CheckAllResponsesAreConsumed()
+
+	s.Equal([]string{"request foo has 2 unused responses"}, mockT.Messages)
+}
+
+func (s *TestSuite) TestCheckAllResponsesAreConsumedDoesNotConsumeResponses() {
+	mockT := httpregistry.NewMockTestingT()
+	registry := httpregistry.NewRegistry(mockT)
+	registration := registry.AddRequestWithResponses(
+		httpregistry.NewRequest().WithURL("/foo").WithName("foo"),
+		httpregistry.CreatedResponse,
+		httpregistry.NoContentResponse,
+	)
+
+	registry.CheckAllResponsesAreConsumed()
+	registry.CheckAllResponsesAreConsumed()
+
+	s.Equal(2, registration.RemainingResponses())
+	s.Equal([]string{
+		"request foo has httpregistry.CreatedResponse as unused response",
+		"request foo has httpregistry.CreatedResponse as unused response",
+	}, mockT.Messages)
+
+	res, err := http.Get(registry.GetServer().URL + "/foo")
+	s.NoError(err)
+	s.Equal(http.StatusCreated, res.StatusCode)
+}
+
+func (s *TestSuite) TestRegistrationsCanBeDisabledAndRemoved() {
+	mockT := httpregistry.NewMockTestingT()
+	registry := httpregistry.NewRegistry(mockT)
+	specific := registry.AddRequestWithInfiniteResponse(
+		httpregistry.NewRequest().WithURL("/foo"),
+		httpregistry.NewResponse().WithStatus(http.StatusAccepted),
+	)
+	catchAll := registry.AddInfiniteResponse(httpregistry.NoContentResponse)
+
+	server := registry.GetServer()
+	defer server.Close()
+
+	call := func() int {
+		res, err := http.Get(server.URL + "/foo")
+		s.NoError(err)
+		return res.StatusCode
+	}
+
+	s.Equal(http.StatusAccepted, call())
+
+	specific.Disable()
+	s.False(specific.IsEnabled())
+	s.Equal(http.StatusNoContent, call())
+
+	specific.Enable()
+	s.True(specific.IsEnabled())
+	s.Equal(http.StatusAccepted, call())
+
+	catchAll.Remove()
+	specific.Disable()
+	s.Equal(http.StatusInternalServerError, call())
+	s.Equal("mock request #1 missed because the request is disabled", registry.Why())
+	s.True(mockT.HasFailed)
+
+	specific.Remove()
+	s.Equal(2, specific.NumberOfCalls())
+	s.Equal(1, catchAll.NumberOfCalls())
+	s.Empty(registry.GetMatchesForRequest(httpregistry.DefaultRequest))
+}
+
+func (s *TestSuite) TestExpectRegistrationsInOrder() {
+	mockT := httpregistry.NewMockTestingT()
+	registry := httpregistry.NewRegistry(mockT)
+	first := registry.AddRequestWithResponse(
+		httpregistry.NewRequest().WithURL("/status").WithName("pending"),
+		httpregistry.AcceptedResponse,
+	)
+	second := registry.AddRequestWithResponse(
+		httpregistry.NewRequest().WithURL("/status").WithName("done"),
+		httpregistry.OkResponse,
+	)
+	registry.ExpectRegistrationsInOrder(second, first)
+
+	server := registry.GetServer()
+	defer server.Close()
+
+	for range 2 {
+		_, err := http.Get(server.URL + "/status")
+		s.NoError(err)
+	}
+
+	s.Equal([]string{
+		"request done was called out of order, the expected order is\n\tdone -> pending\nbut the actual order is\n\tpending -> done",
+	}, mockT.Messages)
+}
@@ -2,21 +2,36 @@ package httpregistry
 
 import (
 	"errors"
-	"fmt"
-	"io"
 	"net/http"
 	"net/http/httptest"
 	"net/http/httputil"
-	"reflect"
+	"sync"
 )
 
 // Registry represents a collection of matches that associate to a http request a http response.
 // It contains all the Match that were registered and after the server is called it contains all the reasons why a request did not match with a particular match
-// the testing.T is used to signal that there was an unexpected error or that not all the responses were consumed as expected
+// the testing.T is used to signal that there was an unexpected error or that not all the responses were consumed as expected.
+// All the Add* methods return a Registration that can be used to inspect, disable or remove what was registered
 type Registry struct {
 	t                          TestingT
-	matches                    []match
+	mu                         *sync.Mutex
+	registrations              []*Registration
 	misses                     []miss
+	journal                    []JournalEntry
+	report                     MissReport
+	mode                       Mode
+	strategy                   MatchingStrategy
+	clock                      Clock
+	orderedGroups              []*orderedGroup
+	sessions                   *sessionStore
+	parent                     *Registry
+	scope                      *Registry
+	lastRegistrationID         int
+	journalLimit               int
+	adminPrefix                string
+	admin                      http.Handler
+	fallback                   http.Handler
+	missHandler                MissHandler
 	nameRequestFunction        func() string
 	nameCustomResponseFunction func() string
 	nameResponseFunction       func() string
@@ -26,6 +41,8 @@ type Registry struct {
 func NewRegistry(t TestingT) *Registry {
 	reg := Registry{
 		t:                          t,
+		mu:                         &sync.Mutex{},
+		clock:                      realClock{},
 		nameRequestFunction:        defaultName("mock request"),
 		nameCustomResponseFunction: defaultName("custom mock response"),
 		nameResponseFunction:       defaultName("mock response"),
@@ -40,9 +57,9 @@ func NewRegistry(t TestingT) *Registry {
 //	reg.GetServer()
 //
 // will create a http server that returns 200 on calling anything.
-func (reg *Registry) Add() {
+func (reg *Registry) Add() *Registration {
 	request := NewRequest().WithName(reg.nameRequestFunction())
-	reg.matches = append(reg.matches, newConsumableResponsesMatch(request, mockResponses{OkResponse}))
+	return reg.register(newConsumableResponsesMatch(request, mockResponses{OkResponse}))
 }
 
 // AddURL adds to the registry a 200 response for a request that matches the URL
@@ -52,9 +69,9 @@ func (reg *Registry) Add() {
 //	reg.GetServer()
 //
 // will create a http server that returns 200 on calling GET "/foo" and fails the test on anything else
-func (reg *Registry) AddURL(URL string) {
+func (reg *Registry) AddURL(URL string) *Registration {
 	request := NewRequest().WithURL(URL).WithName(reg.nameRequestFunction())
-	reg.matches = append(reg.matches, newConsumableResponsesMatch(request, mockResponses{OkResponse}))
+	return reg.register(newConsumableResponsesMatch(request, mockResponses{OkResponse}))
 }
 
 // AddURLWithStatusCode adds to the registry a statusCode response for a request that matches the URL
@@ -64,11 +81,11 @@ func (reg *Registry) AddURL(URL string) {
 //	reg.GetServer()
 //
 // will create a http server that returns 401 on calling GET "/foo" and fails the test on anything else
-func (reg *Registry) AddURLWithStatusCode(URL string, statusCode int) {
+func (reg *Registry) AddURLWithStatusCode(URL string, statusCode int) *Registration {
 	request := NewRequest().WithURL(URL).WithName(reg.nameRequestFunction())
 	response := NewResponse().WithStatus(statusCode).WithName(reg.nameResponseFunction())
 
-	reg.matches = append(reg.matches, newConsumableResponsesMatch(request, mockResponses{response}))
+	return reg.register(newConsumableResponsesMatch(request, mockResponses{response}))
 }
 
 // AddMethod adds to the registry a 200 response for a request that matches the method
@@ -78,9 +95,9 @@ func (reg *Registry) AddURLWithStatusCode(URL string, statusCode int) {
 //	reg.GetServer()
 //
 // will create a http server that returns 200 on calling GET "/foo" and fails the test on anything else
-func (reg *Registry) AddMethod(method string) {
+func (reg *Registry) AddMethod(method string) *Registration {
 	request := NewRequest().WithMethod(method).WithName(reg.nameRequestFunction())
-	reg.matches = append(reg.matches, newConsumableResponsesMatch(request, mockResponses{OkResponse}))
+	return reg.register(newConsumableResponsesMatch(request, mockResponses{OkResponse}))
 }
 
 // AddMethodWithStatusCode adds to the registry a statusCode response for a request that matches the method
@@ -90,10 +107,10 @@ func (reg *Registry) AddMethod(method string) {
 //	reg.GetServer()
 //
 // will create a http server that returns 401 on calling GET "/foo" and fails the test on anything else
-func (reg *Registry) AddMethodWithStatusCode(method string, statusCode int) {
+func (reg *Registry) AddMethodWithStatusCode(method string, statusCode int) *Registration {
 	request := NewRequest().WithMethod(method).WithName(reg.nameRequestFunction())
 	response := NewResponse().WithStatus(statusCode).WithName(reg.nameResponseFunction())
-	reg.matches = append(reg.matches, newConsumableResponsesMatch(request, mockResponses{response}))
+	return reg.register(newConsumableResponsesMatch(request, mockResponses{response}))
 }
 
 // AddMethodAndURL adds to the registry a 200 response for a request that matches method and URL
@@ -103,9 +120,9 @@ func (reg *Registry) AddMethodWithStatusCode(method string, statusCode int) {
 //	reg.GetServer()
 //
 // will create a http server that returns 200 on calling GET "/foo" and fails the test on anything else
-func (reg *Registry) AddMethodAndURL(method string, URL string) {
+func (reg *Registry) AddMethodAndURL(method string, URL string) *Registration {
 	request := NewRequest().WithMethod(method).WithURL(URL).WithName(reg.nameRequestFunction())
-	reg.matches = append(reg.matches, newConsumableResponsesMatch(request, mockResponses{OkResponse}))
+	return reg.register(newConsumableResponsesMatch(request, mockResponses{OkResponse}))
 }
 
 // AddMethodAndURLWithStatusCode adds to the registry a statusCode response for a request that matches method and URL
@@ -115,11 +132,11 @@ func (reg *Registry) AddMethodAndURL(method string, URL string) {
 //	reg.GetServer()
 //
 // will create a http server that returns 204 on calling GET "/foo" and fails the test on anything else
-func (reg *Registry) AddMethodAndURLWithStatusCode(method string, URL string, statusCode int) {
+func (reg *Registry) AddMethodAndURLWithStatusCode(method string, URL string, statusCode int) *Registration {
 	request := NewRequest().WithMethod(method).WithURL(URL).WithName(reg.nameRequestFunction())
 	response := NewResponse().WithStatus(statusCode).WithName(reg.nameResponseFunction())
 
-	reg.matches = append(reg.matches, newConsumableResponsesMatch(request, mockResponses{response}))
+	return reg.register(newConsumableResponsesMatch(request, mockResponses{response}))
 }
 
 // AddBody adds to the registry a statusCode response for a request that matches method and URL
@@ -129,9 +146,9 @@ func (reg *Registry) AddMethodAndURLWithStatusCode(method string, URL string, st
 //	reg.GetServer()
 //
 // will create a http server that returns 204 on calling GET "/foo" and fails the test on anything else
-func (reg *Registry) AddBody(body []byte) {
+func (reg *Registry) AddBody(body []byte) *Registration {
 	request := NewRequest().WithBody(body).WithName(reg.nameRequestFunction())
-	reg.matches = append(reg.matches, newConsumableResponsesMatch(request, mockResponses{OkResponse}))
+	return reg.register(newConsumableResponsesMatch(request, mockResponses{OkResponse}))
 }
 
 // AddRequest adds to the registry a 200 response for a generic request that needs to be matched
@@ -143,9 +160,9 @@ func (reg *Registry) AddBody(body []byte) {
 //	reg.GetServer()
 //
 // will create a http server that returns 200 on calling GET "/foo" with the correct header and fails the test on anything else
-func (reg *Registry) AddRequest(request Request) {
+func (reg *Registry) AddRequest(request Request) *Registration {
 	request = reg.ifNeededSetDefaultNameToRequest(request)
-	reg.matches = append(reg.matches, newConsumableResponsesMatch(request, mockResponses{OkResponse}))
+	return reg.register(newConsumableResponsesMatch(request, mockResponses{OkResponse}))
 }
 
 // AddResponse adds to the registry a generic response that is returned for any call
@@ -157,10 +174,10 @@ func (reg *Registry) AddRequest(request Request) {
 //	reg.GetServer()
 //
 // will create a http server that returns 204 with "hello" as body on calling the server on any URL
-func (reg *Registry) AddResponse(response mockResponse) {
+func (reg *Registry) AddResponse(response mockResponse) *Registration {
 	request := NewRequest().WithName(reg.nameRequestFunction())
 	response = reg.ifNeededSetDefaultNameToMockResponse(response)
-	reg.matches = append(reg.matches, newConsumableResponsesMatch(request, mockResponses{response}))
+	return reg.register(newConsumableResponsesMatch(request, mockResponses{response}))
 }
 
 // AddResponses adds to the registry a generic response that is returned for any call
@@ -173,13 +190,13 @@ func (reg *Registry) AddResponse(response mockResponse) {
 //		reg.GetServer()
 //
 // will create a http server that returns 204 with "hello" as body on calling the server on any URL for two times and then returns an error
-func (reg *Registry) AddResponses(responses ...mockResponse) {
+func (reg *Registry) AddResponses(responses ...mockResponse) *Registration {
 	request := NewRequest().WithName(reg.nameRequestFunction())
 	responsesWithNames := make(mockResponses, 0, len(responses))
 	for _, response := range responses {
 		responsesWithNames = append(responsesWithNames, reg.ifNeededSetDefaultNameToMockResponse(response))
 	}
-	reg.matches = append(reg.matches, newConsumableResponsesMatch(request, responsesWithNames))
+	return reg.register(newConsumableResponsesMatch(request, responsesWithNames))
 }
 
 // AddRequestWithResponse adds to the registry a generic response for a generic request that needs to be matched
@@ -192,10 +209,10 @@ func (reg *Registry) AddResponses(responses ...mockResponse) {
 //	reg.GetServer()
 //
 // will create a http server that returns 204 with "hello" as body on calling GET "/foo" with the correct header and fails the test on anything else
-func (reg *Registry) AddRequestWithResponse(request Request, response mockResponse) {
+func (reg *Registry) AddRequestWithResponse(request Request, response mockResponse) *Registration {
 	request = reg.ifNeededSetDefaultNameToRequest(request)
 	response = reg.ifNeededSetDefaultNameToMockResponse(response)
-	reg.matches = append(reg.matches, newConsumableResponsesMatch(request, mockResponses{response}))
+	return reg.register(newConsumableResponsesMatch(request, mockResponses{response}))
 }
 
 // AddRequestWithResponses adds to the registry multiple responses for a generic request that needs to be matched.
@@ -211,13 +228,13 @@ func (reg *Registry) AddRequestWithResponse(request Request, response mockRespon
 //
 // will create a http server that returns 204 with "hello" as body on calling GET "/foo" the first call with the correct header,
 // it returns 200 with "hello again" as body on the second call with the correct header and fails the test on anything else
-func (reg *Registry) AddRequestWithResponses(request Request, responses ...mockResponse) {
+func (reg *Registry) AddRequestWithResponses(request Request, responses ...mockResponse) *Registration {
 	request = reg.ifNeededSetDefaultNameToRequest(request)
 	responsesWithNames := make(mockResponses, 0, len(responses))
 	for _, response := range responses {
 		responsesWithNames = append(responsesWithNames, reg.ifNeededSetDefaultNameToMockResponse(response))
 	}
-	reg.matches = append(reg.matches, newConsumableResponsesMatch(request, responsesWithNames))
+	return reg.register(newConsumableResponsesMatch(request, responsesWithNames))
 }
 
 // AddInfiniteResponse adds to the registry a generic response that is returned for any call and it is never consumed
@@ -229,10 +246,10 @@ func (reg *Registry) AddRequestWithResponses(request Request, responses ...mockR
 //	reg.GetServer()
 //
 // will create a http server that returns 204 with "hello" as body on calling the server on any URL for as many times as needed
-func (reg *Registry) AddInfiniteResponse(response mockResponse) {
+func (reg *Registry) AddInfiniteResponse(response mockResponse) *Registration {
 	request := NewRequest().WithName(reg.nameRequestFunction())
 	response = reg.ifNeededSetDefaultNameToMockResponse(response)
-	reg.matches = append(reg.matches, newInfiniteResponsesMatch(request, response))
+	return reg.register(newInfiniteResponsesMatch(request, response))
 }
 
 // AddRequestWithInfiniteResponse adds to the registry a generic response for a generic request that needs to be matched
@@ -245,15 +262,19 @@ func (reg *Registry) AddInfiniteResponse(response mockResponse) {
 //	reg.GetServer()
 //
 // will create a http server that returns 204 with "hello" as body on calling GET "/foo" with the correct header and fails the test on anything else
-func (reg *Registry) AddRequestWithInfiniteResponse(request Request, response mockResponse) {
+func (reg *Registry) AddRequestWithInfiniteResponse(request Request, response mockResponse) *Registration {
 	request = reg.ifNeededSetDefaultNameToRequest(request)
 	response = reg.ifNeededSetDefaultNameToMockResponse(response)
-	reg.matches = append(reg.matches, newInfiniteResponsesMatch(request, response))
+	return reg.register(newInfiniteResponsesMatch(request, response))
 }
 
 // GetMatchesForRequest returns the *http.Request that matched a generic Request
 func (reg *Registry) GetMatchesForRequest(r Request) []*http.Request {
-	for _, match := range reg.matches {
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+
+	for _, registration := range reg.registrations {
+		match := registration.match
 		if match.Request().Equal(r) {
 			matches := match.Matches()
 
@@ -271,7 +292,11 @@ func (reg *Registry) GetMatchesForRequest(r Request) []*http.Request {
 
 // GetMatchesForURL returns the http.Requests that matched a specific URL independently of the method used to call it
 func (reg *Registry) GetMatchesForURL(url string) []*http.Request {
-	for _, match := range reg.matches {
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+
+	for _, registration := range reg.registrations {
+		match := registration.match
 		r := match.Request()
 		if r.urlAsRegex.MatchString(url) {
 			matches := match.Matches()
@@ -290,7 +315,11 @@ func (reg *Registry) GetMatchesForURL(url string) []*http.Request {
 
 // GetMatchesURLAndMethod returns the http.Requests that matched a specific method, URL pair
 func (reg *Registry) GetMatchesURLAndMethod(url string, method string) []*http.Request {
-	for _, match := range reg.matches {
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+
+	for _, registration := range reg.registrations {
+		match := registration.match
 		r := match.Request()
 		if r.urlAsRegex.MatchString(url) && r.method == method {
 			matches := match.Matches()
@@ -307,135 +336,214 @@ func (reg *Registry) GetMatchesURLAndMethod(url string, method string) []*http.R
 	return []*http.Request{}
 }
 
-// doesRegisteredMatchMatchIncomingRequest checks if the incoming request is a match for the match that we are currently evaluating.
+// doesRegisteredMatchMatchIncomingRequest checks if the incoming request, whose body was already read into body, is a match for the match that we are currently evaluating.
+// A request is a match only if all the criteria defined by the registered request, and by the session store sessions, are satisfied.
 // If it is not a match this function will return a slice of miss objects that explain why the match is not possible.
-func doesRegisteredMatchMatchIncomingRequest(registeredMatch match, r *http.Request) (bool, []miss) {
-	// The default request matches everything so no point in checking further
-	if registeredMatch.Request().Equal(NewRequest()) {
-		return true, nil
+func doesRegisteredMatchMatchIncomingRequest(registeredMatch match, r *http.Request, body []byte, sessions *sessionStore) (bool, []miss) {
+	misses := []miss{}
+	for _, result := range evaluateRequest(sessions.apply(registeredMatch.Request()), r, body) {
+		if !result.matched {
+			misses = append(misses, newMissWithDetail(registeredMatch, result.why, result.missDetail))
+		}
 	}
+	return len(misses) == 0, misses
+}
 
-	expectedURL := registeredMatch.Request().url
-	expectedURLAsRegex := registeredMatch.Request().urlAsRegex
-	expectedMethod := registeredMatch.Request().method
-	expectedHeaders := registeredMatch.Request().headers
-	expectedBody := registeredMatch.Request().body
+// GetServer returns a httptest.Server designed to match all the requests registered with the Registry
+func (reg *Registry) GetServer() *httptest.Server {
+	return httptest.NewServer(reg.Handler())
+}
 
-	misses := []miss{}
+// Handler returns the http.Handler that serves the requests registered with the Registry.
+// It is useful to serve the registry from a server that is not created by GetServer, for example one listening on a fixed address
+func (reg *Registry) Handler() http.Handler {
+	return http.HandlerFunc(reg.serveHTTP)
+}
 
-	// if the match contains the default values then there is no point in saying that something was missed
-	if expectedURL != "" {
-		if expectedURLAsRegex.MatchString(r.URL.String()) {
-			return true, nil
-		}
-		misses = append(misses, newMiss(registeredMatch, pathDoesNotMatch))
+// serveHTTP serves r, calls to the admin API are served by it and JSON-RPC batches are split so that each call is served independently.
+// Bodies are checked for JSON-RPC batches only if some registration matches JSON-RPC calls, otherwise a JSON array is served as any other body
+func (reg *Registry) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	if admin := reg.adminHandler(r); admin != nil {
+		admin.ServeHTTP(w, r)
+		return
 	}
-
-	if expectedMethod != "" {
-		if expectedMethod == r.Method {
-			return true, nil
+	if scope := reg.activeScope(); scope != nil {
+		scope.serveHTTP(w, r)
+		return
+	}
+	if reg.hasJSONRPCRegistrations() {
+		if calls, ok := jsonrpcBatch(readBody(r)); ok {
+			reg.serveJSONRPCBatch(w, r, calls)
+			return
 		}
-		misses = append(misses, newMiss(registeredMatch, methodDoesNotMatch))
 	}
+	reg.serveRequest(w, r)
+}
 
-	if len(expectedBody) > 0 {
-		body, err := io.ReadAll(r.Body)
-		if err != nil {
-			panic(fmt.Errorf("cannot read the body of the request: %w", err))
-		}
-		if reflect.DeepEqual(expectedBody, body) {
-			return true, nil
+// serveRequest finds the first registered request that matches r and emits the associated response.
+// Every call is recorded in the journal, if no match is possible the test is failed.
+func (reg *Registry) serveRequest(w http.ResponseWriter, r *http.Request) {
+	reg.mu.Lock()
+	entry := JournalEntry{
+		Timestamp:   reg.clock.Now(),
+		Request:     cloneHTTPRequest(r),
+		Conditional: isConditionalRequest(r),
+	}
+	matched, response := reg.findResponse(r)
+	reg.report = MissReport{}
+	var closest []registrationDiff
+	if matched != nil {
+		entry.Matched = true
+		entry.Registration = matched.Request()
+		entry.ResponseName = response.String()
+		reg.checkCallOrder(matched)
+	} else {
+		body := readBody(r)
+		diffs := compareWithRegistrations(reg.enabledMatches(), r, body, reg.sessions)
+		reg.report = newMissReport(r, body, diffs)
+		closest = closestRegistrations(diffs, maxClosestRegistrations)
+	}
+	entry.misses = reg.misses
+	entry.report = reg.report
+	reg.journal = appendLimited(reg.journal, entry, reg.journalLimit)
+	report := reg.report
+	mode, fallback, missHandler, sessions, clock := reg.mode, reg.fallback, reg.missHandler, reg.sessions, reg.clock
+	reg.mu.Unlock()
+
+	if matched != nil {
+		if sessions != nil {
+			w = &sessionRecorder{ResponseWriter: w, reg: reg}
 		}
+		response.serveResponse(w, withClock(r, clock))
+		return
 	}
 
-	if len(expectedHeaders) > 0 {
-		headersMisses := []miss{}
-		for headerToMatch, valueToMatch := range expectedHeaders {
-			value := r.Header.Get(headerToMatch)
-			if value == "" || value != valueToMatch {
-				misses = append(misses, newMiss(registeredMatch, headerDoesNotMatch))
-			}
-		}
+	if mode == LenientMode {
+		logf(reg.t, "no registered request matched %v %v, it is served by the fallback%s", r.Method, r.URL, closestToString(closest))
+		serveFallback(fallback, w, r, report)
+		return
+	}
 
-		if len(headersMisses) == 0 {
-			return true, nil
-		}
-		misses = append(misses, headersMisses...)
+	res, err := httputil.DumpRequest(r, true)
+	if err != nil {
+		reg.t.Errorf("impossible to dump http request with error: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
 	}
 
-	return false, misses
+	reg.t.Errorf("no registered request matched %v\n The reasons why this is the case are returned in the body%s", string(res), closestToString(closest))
+	if missHandler == nil {
+		missHandler = ServeMissReport
+	}
+	missHandler(w, r, report)
 }
 
-// GetServer returns a httptest.Server designed to match all the requests registered with the Registry
-func (reg *Registry) GetServer() *httptest.Server {
-	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		// We reset the misses since if a previous request matched it is pointless to record that some of the mocks did not match it.
-		// If said request did not match then the test would have crashed in any case so the information in misses is useless.
-		reg.misses = []miss{}
-		for _, possibleMatch := range reg.matches {
-			doesMatch, misses := doesRegisteredMatchMatchIncomingRequest(possibleMatch, r)
-			if !doesMatch {
-				reg.misses = append(reg.misses, misses...)
-				continue
-			}
-
-			response, err := possibleMatch.NextResponse()
-			if err != nil {
-				if errors.Is(errNoNextResponseFound, err) {
-					reg.misses = append(reg.misses, newMiss(possibleMatch, outOfResponses))
-					continue
-				}
-			}
+// findResponse returns the first registered match that matches r together with its next response and records the match.
+// If no match is possible it returns a nil match and the reasons why are stored in reg.misses.
+func (reg *Registry) findResponse(r *http.Request) (match, mockResponse) {
+	// We reset the misses since if a previous request matched it is pointless to record that some of the mocks did not match it.
+	// If said request did not match then the test would have crashed in any case so the information in misses is useless.
+	reg.misses = []miss{}
+	body := readBody(r)
+	for _, registration := range reg.matchingOrder() {
+		possibleMatch := registration.match
+		if registration.disabled {
+			reg.misses = append(reg.misses, newMiss(possibleMatch, registrationDisabled))
+			continue
+		}
 
-			possibleMatch.RecordMatch(r)
-			response.serveResponse(w, r)
-			return
+		doesMatch, misses := doesRegisteredMatchMatchIncomingRequest(possibleMatch, r, body, reg.sessions)
+		if !doesMatch {
+			reg.misses = append(reg.misses, misses...)
+			continue
 		}
 
-		res, err := httputil.DumpRequest(r, true)
+		response, err := possibleMatch.NextResponse()
 		if err != nil {
-			reg.t.Errorf("impossible to dump http request with error: %v", err)
-			w.WriteHeader(http.StatusInternalServerError)
-			return
+			if errors.Is(errNoNextResponseFound, err) {
+				reg.misses = append(reg.misses, newMiss(possibleMatch, outOfResponses))
+				continue
+			}
 		}
 
-		reg.t.Errorf("no registered request matched %v\n The reasons why this is the case are returned in the body", string(res))
-		w.WriteHeader(http.StatusInternalServerError)
-		w.Header().Set("Content-Type", "application/json")
-		_, _ = w.Write([]byte(reg.Why()))
-	}))
+		possibleMatch.RecordMatch(r, reg.journalLimit)
+		return possibleMatch, response
+	}
+	return nil, nil
+}
+
+// GetJournal returns, in chronological order, the entries of the journal selected by query.
+// The journal contains every request that reached the server, both matched and unmatched ones,
+// unless a limit was set with SetJournalLimit.
+//
+//	unmatched := reg.GetJournal(httpregistry.NewJournalQuery().OnlyUnmatched())
+func (reg *Registry) GetJournal(query JournalQuery) []JournalEntry {
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+
+	entries := []JournalEntry{}
+	for _, entry := range reg.journal {
+		if !query.selects(entry) {
+			continue
+		}
+		// we clone the requests so that if this function is called multiple times things
+		// like the request body can be accessed again
+		entry.Request = cloneHTTPRequest(entry.Request)
+		entries = append(entries, entry)
+	}
+	return entries
 }
 
-// CheckAllResponsesAreConsumed fails the test if there are unused responses at the end of the test.
+// CheckAllResponsesAreConsumed fails the test if there are unused responses at the end of the test,
+// or if some requests declared with ExpectInOrder or ExpectRegistrationsInOrder were never called.
 // This is useful to check if all the expected calls happened or if there is an unexpected behavior happening.
 //
 // **Important**: If you are using AddInfiniteRequest this call will ALWAYS fail!
 func (reg *Registry) CheckAllResponsesAreConsumed() {
-	for _, match := range reg.matches {
-		response, err := match.NextResponse()
-		if err == nil {
-			reg.t.Errorf("request %v has %v as unused response", match.Request().String(), response)
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+
+	shadowedBy := map[*Registration]*Registration{}
+	for _, s := range reg.shadowedRegistrations() {
+		shadowedBy[s.registration] = s.shadowedBy
+	}
+
+	for _, registration := range reg.registrations {
+		match := registration.match
+		// the responses are only inspected, so that checking does not change what the registration serves
+		if match.RemainingResponses() == 0 {
+			continue
+		}
+		response := match.PeekResponse()
+		if previous, found := shadowedBy[registration]; found {
+			reg.t.Errorf("request %v has %v as unused response, it can never be matched because it is shadowed by %v", match.Request().String(), response, previous)
+			continue
 		}
+		reg.t.Errorf("request %v has %v as unused response", match.Request().String(), response)
 	}
+	reg.checkOrderedGroupsAreCompleted()
 }
 
 // Why returns a string that contains all the reasons why the request submitted to the registry failed to match with the registered requests.
 // The envision use of this function is just as a helper when debugging the tests,
 // most of the time it might not be obvious if there is a typo or a small error.
 func (reg *Registry) Why() string {
-	outputString := ""
-	for i, miss := range reg.misses {
-		if i == 0 {
-			outputString = miss.String()
-		} else {
-			outputString += "\n" + miss.String()
-		}
-	}
-	return outputString
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+	return missesToString(reg.misses)
+}
+
+// WhyReport returns the structured version of Why for the last request submitted to the registry.
+// If the last request matched, the report contains no registrations.
+// The report can be encoded to JSON and it is the same that the server returns in the body when a request does not match.
+func (reg *Registry) WhyReport() MissReport {
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+	return reg.report
 }
 
 // ifNeededSetDefaultNameToRequest overwrites the name field in a Request if the name is currently the empty string
-func (reg Registry) ifNeededSetDefaultNameToRequest(request Request) Request {
+func (reg *Registry) ifNeededSetDefaultNameToRequest(request Request) Request {
 	if request.name == "" {
 		request = request.WithName(reg.nameRequestFunction())
 	}
@@ -443,7 +551,7 @@ func (reg Registry) ifNeededSetDefaultNameToRequest(request Request) Request {
 }
 
 // ifNeededSetDefaultNameToResponse overwrites the name field in a Response if the name is currently the empty string
-func (reg Registry) ifNeededSetDefaultNameToResponse(response Response) Response {
+func (reg *Registry) ifNeededSetDefaultNameToResponse(response Response) Response {
 	if response.name == "" {
 		response = response.WithName(reg.nameResponseFunction())
 	}
@@ -451,20 +559,30 @@ func (reg Registry) ifNeededSetDefaultNameToResponse(response Response) Response
 }
 
 // ifNeededSetDefaultNameToCustomRequest overwrites the name field in a CustomResponse if the name is currently the empty string
-func (reg Registry) ifNeededSetDefaultNameToCustomResponse(response CustomResponse) CustomResponse {
+func (reg *Registry) ifNeededSetDefaultNameToCustomResponse(response CustomResponse) CustomResponse {
 	if response.name == "" {
 		response = response.WithName(reg.nameCustomResponseFunction())
 	}
 	return response
 }
 
+// ifNeededSetDefaultNameToRangeResponse overwrites the name field in a RangeResponse if the name is currently the empty string
+func (reg *Registry) ifNeededSetDefaultNameToRangeResponse(response RangeResponse) RangeResponse {
+	if response.name == "" {
+		response = response.WithName(reg.nameResponseFunction())
+	}
+	return response
+}
+
 // ifNeededSetDefaultNameToMockResponse overwrites the name field in a mockResponse if the name is currently the empty string
-func (reg Registry) ifNeededSetDefaultNameToMockResponse(response mockResponse) mockResponse {
+func (reg *Registry) ifNeededSetDefaultNameToMockResponse(response mockResponse) mockResponse {
 	switch r := response.(type) {
 	case Response:
 		response = reg.ifNeededSetDefaultNameToResponse(r)
 	case CustomResponse:
 		response = reg.ifNeededSetDefaultNameToCustomResponse(r)
+	case RangeResponse:
+		response = reg.ifNeededSetDefaultNameToRangeResponse(r)
 	}
 
 	return response
@@ -2,9 +2,11 @@ package httpregistry_test
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
+	"sync"
 
 	"github.com/dfioravanti/httpregistry"
 )
@@ -547,12 +549,70 @@ func (s *TestSuite) TestCallingTooMayTimesFails() {
 	response, err := client.Get(url + "/foo")
 	s.NoError(err)
 
-	bodyBytes, err := io.ReadAll(response.Body)
+	s.True(mockT.HasFailed)
+	s.Equal("mock request #1 missed because the route matches but there was no response available", registry.Why())
+
+	s.Equal(http.StatusInternalServerError, response.StatusCode)
+	s.Equal("application/json", response.Header.Get("Content-Type"))
+
+	var report httpregistry.MissReport
+	s.NoError(json.NewDecoder(response.Body).Decode(&report))
+	s.Equal(registry.WhyReport(), report)
+	s.Equal(http.MethodGet, report.Request.Method)
+	s.Equal("/foo", report.Request.URL)
+	s.Equal([]httpregistry.RegistrationReport{
+		{
+			Name: "mock request #1",
+			Criteria: []httpregistry.CriterionReport{
+				{Criterion: "path", Expected: "/foo", Actual: "/foo", Matched: true},
+				{Criterion: "method", Expected: http.MethodGet, Actual: http.MethodGet, Matched: true},
+				{Criterion: "responses", Expected: "at least one response available", Actual: "no response available", Matched: false},
+			},
+		},
+	}, report.Registrations)
+}
+
+func (s *TestSuite) TestMissReportContainsEveryCriterion() {
+	mockT := httpregistry.NewMockTestingT()
+	registry := httpregistry.NewRegistry(mockT)
+	registry.AddRequest(
+		httpregistry.NewRequest().
+			WithName("create user").
+			WithMethod(http.MethodPost).
+			WithURL("/users").
+			WithJSONBody(map[string]any{"name": "John"}),
+	)
+
+	server := registry.GetServer()
+	defer server.Close()
+
+	res, err := http.Post(server.URL+"/users", "text/plain", bytes.NewBufferString(`{"name": "Jon"}`))
 	s.NoError(err)
-	body := string(bodyBytes)
 
-	s.True(mockT.HasFailed)
-	s.Equal(body, "mock request #1 missed because the route matches but there was no response available")
+	var report httpregistry.MissReport
+	s.NoError(json.NewDecoder(res.Body).Decode(&report))
+	s.Equal(`{"name": "Jon"}`, report.Request.Body)
+	s.Equal([]httpregistry.RegistrationReport{
+		{
+			Name: "create user",
+			Criteria: []httpregistry.CriterionReport{
+				{Criterion: "path", Expected: "/users", Actual: "/users", Matched: true},
+				{Criterion: "method", Expected: http.MethodPost, Actual: http.MethodPost, Matched: true},
+				{
+					Criterion: "body",
+					Expected:  `{"name":"John"}`,
+					Actual:    `{"name": "Jon"}`,
+					Matched:   false,
+					Details:   []string{`$.name: expected "John" got "Jon"`},
+				},
+				{Criterion: `header "Content-Type"`, Expected: "application/json", Actual: "text/plain", Matched: false},
+			},
+		},
+	}, report.Registrations)
+
+	entries := registry.GetJournal(httpregistry.NewJournalQuery())
+	s.Equal(1, len(entries))
+	s.Equal(report, entries[0].Report())
 }
 
 func (s *TestSuite) TestMatchArbitraryRequests() {
@@ -580,6 +640,10 @@ func (s *TestSuite) TestMatchArbitraryRequests() {
 			methodToCall: http.MethodPost,
 			pathToCall:   "/foo",
 			bodyToCall:   mustMarshalJSON(map[string]int{"foo": 10, "bar": 20}),
+			// WithJSONBody also expects the Content-Type header and every criterion must match
+			headersToCall: http.Header{
+				"Content-Type": {"application/json"},
+			},
 		},
 	}
 	for _, tc := range testCases {
@@ -609,3 +673,95 @@ func (s *TestSuite) TestMatchArbitraryRequests() {
 		})
 	}
 }
+
+func (s *TestSuite) TestWhyCanBeCalledWhileRequestsAreServed() {
+	mockT := httpregistry.NewMockTestingT()
+	registry := httpregistry.NewRegistry(mockT)
+	registry.AddMethodAndURL(http.MethodPost, "/foo")
+
+	server := registry.GetServer()
+	defer server.Close()
+
+	var wg sync.WaitGroup
+	for range 5 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			res, err := http.Get(server.URL + "/foo")
+			s.NoError(err)
+			res.Body.Close()
+		}()
+		_ = registry.Why()
+	}
+	wg.Wait()
+
+	s.Equal("mock request #1 missed because the method does not match", registry.Why())
+}
+
+func (s *TestSuite) TestAllCriteriaMustMatch() {
+	mockT := httpregistry.NewMockTestingT()
+	registry := httpregistry.NewRegistry(mockT)
+	registry.AddMethodAndURL(http.MethodPost, "/foo")
+
+	server := registry.GetServer()
+	defer server.Close()
+
+	res, err := http.Get(server.URL + "/foo")
+	s.NoError(err)
+
+	s.Equal(http.StatusInternalServerError, res.StatusCode)
+	s.True(mockT.HasFailed)
+	s.Equal("mock request #1 missed because the method does not match", registry.Why())
+
+	registry.AddRequest(
+		httpregistry.NewRequest().
+			WithURL("/bar").
+			WithMethod(http.MethodPost).
+			WithJSONBody(map[string]int{"foo": 10}),
+	)
+	res, err = http.Post(server.URL+"/bar", "text/plain", bytes.NewReader(mustMarshalJSON(map[string]int{"foo": 10})))
+	s.NoError(err)
+
+	s.Equal(http.StatusInternalServerError, res.StatusCode)
+	s.Equal(
+		"mock request #1 missed because the path does not match\n"+
+			`mock request #2 missed because the header does not match: header "Content-Type" is not equal to "application/json"`,
+		registry.Why(),
+	)
+}
+
+func (s *TestSuite) TestUnmatchedRequestReportsTheClosestRegistrations() {
+	mockT := httpregistry.NewMockTestingT()
+	registry := httpregistry.NewRegistry(mockT)
+	registry.AddMethodAndURL(http.MethodDelete, "/orders")
+	registry.AddRequest(
+		httpregistry.NewRequest().
+			WithName("create user").
+			WithMethod(http.MethodPost).
+			WithURL("/users").
+			WithHeader("Accept", "application/json").
+			WithStringBody(`{"name": "John", "age": 42}`),
+	)
+
+	server := registry.GetServer()
+	defer server.Close()
+
+	req, err := http.NewRequest(http.MethodPost, server.URL+"/users", bytes.NewBufferString(`{"name": "Jon", "age": 42}`))
+	s.NoError(err)
+	req.Header.Set("Accept", "text/html")
+	res, err := http.DefaultClient.Do(req)
+	s.NoError(err)
+	s.Equal(http.StatusInternalServerError, res.StatusCode)
+
+	s.Equal(1, len(mockT.Messages))
+	message := mockT.Messages[0]
+	s.Contains(message, "The closest registered requests are:\n"+
+		"create user: 2 of 4 criteria match\n"+
+		"\tpath: matches \"/users\"\n"+
+		"\tmethod: matches \"POST\"\n"+
+		"\tbody: differs\n"+
+		"\t\t$.name: expected \"John\" got \"Jon\"\n"+
+		"\theader \"Accept\": expected \"application/json\" got \"text/html\"\n"+
+		"mock request #1: 0 of 2 criteria match",
+	)
+}
@@ -0,0 +1,94 @@
+package httpregistry
+
+import (
+	"net/http"
+)
+
+// MissReport is the structured, machine readable, version of Registry.Why.
+// It describes an incoming request that did not match and how each registered request was evaluated against it.
+// The report can be encoded to JSON with encoding/json and it is what the server returns in the body when a request does not match.
+type MissReport struct {
+	// Request is the incoming request that did not match
+	Request ReportedRequest `json:"request"`
+	// Registrations contains one entry for each registered request in order of registration
+	Registrations []RegistrationReport `json:"registrations"`
+}
+
+// ReportedRequest is the representation of an incoming request inside a MissReport
+type ReportedRequest struct {
+	Method  string      `json:"method"`
+	URL     string      `json:"url"`
+	Headers http.Header `json:"headers"`
+	Body    string      `json:"body"`
+}
+
+// RegistrationReport describes how an incoming request was evaluated against a registered request
+type RegistrationReport struct {
+	// Name is the name of the registered request
+	Name string `json:"name"`
+	// Criteria contains all the criteria that were evaluated.
+	// Criteria that are not set in the registered request are not evaluated and are therefore missing
+	Criteria []CriterionReport `json:"criteria"`
+}
+
+// CriterionReport describes the outcome of evaluating a single criterion of a registered request, for example the method or a header
+type CriterionReport struct {
+	// Criterion identifies what was evaluated, for example "method" or `header "Accept"`
+	Criterion string `json:"criterion"`
+	// Expected is what the registered request expects
+	Expected string `json:"expected"`
+	// Actual is what the incoming request contains
+	Actual string `json:"actual"`
+	// Matched is true if the incoming request satisfies the criterion
+	Matched bool `json:"matched"`
+	// Details contains additional explanations of the difference, for example a diff of JSON bodies
+	Details []string `json:"details,omitempty"`
+}
+
+// responsesCriterion is the criterion used in a report to represent that a registered request has no more responses available
+const responsesCriterion = "responses"
+
+// newMissReport creates a MissReport for the incoming request r, whose body was already read into body, from the comparisons in diffs
+func newMissReport(r *http.Request, body []byte, diffs []registrationDiff) MissReport {
+	registrations := make([]RegistrationReport, 0, len(diffs))
+	for _, d := range diffs {
+		criteria := make([]CriterionReport, 0, len(d.results)+1)
+		for _, result := range d.results {
+			criteria = append(criteria, CriterionReport{
+				Criterion: result.name,
+				Expected:  result.expected,
+				Actual:    result.actual,
+				Matched:   result.matched,
+				Details:   result.details,
+			})
+		}
+		if d.outOfResponses {
+			criteria = append(criteria, CriterionReport{
+				Criterion: responsesCriterion,
+				Expected:  "at least one response available",
+				Actual:    "no response available",
+				Matched:   false,
+			})
+		}
+
+		registrations = append(registrations, RegistrationReport{
+			Name:     d.request.String(),
+			Criteria: criteria,
+		})
+	}
+
+	return MissReport{
+		Request:       newReportedRequest(r, body),
+		Registrations: registrations,
+	}
+}
+
+// newReportedRequest creates the representation of r, whose body was already read into body
+func newReportedRequest(r *http.Request, body []byte) ReportedRequest {
+	return ReportedRequest{
+		Method:  r.Method,
+		URL:     r.URL.String(),
+		Headers: r.Header.Clone(),
+		Body:    string(body),
+	}
+}
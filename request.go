@@ -1,8 +1,10 @@
 package httpregistry
 
 import (
+	"fmt"
 	"reflect"
 	"regexp"
+	"strings"
 )
 
 // DefaultRequest represents the request that is used when no request is specified.
@@ -11,14 +13,24 @@ import (
 var DefaultRequest = newRequestWithName("httpregistry.DefaultRequest")
 
 // Request represents a request that will be registered to a Registry to get matched against an incoming HTTP request.
-// The match happens against the method, the headers and the URL interpreted as a regex
+// The match happens against the method, the headers, the body and the URL interpreted as a regex,
+// an incoming request is a match only if all the criteria that were set are satisfied
 type Request struct {
-	name       string
-	url        string
-	method     string
-	headers    map[string]string
-	body       []byte
-	urlAsRegex regexp.Regexp
+	name    string
+	url     string
+	method  string
+	headers []headerCriterion
+	cookies []cookieCriterion
+	form    []formCriterion
+	xml     []xmlCriterion
+	graphql []graphqlCriterion
+	jsonrpc []jsonrpcCriterion
+	// bodyMatchers are the conditions on the body defined outside of this package, see BodyMatcher
+	bodyMatchers []BodyMatcher
+	body         []byte
+	urlAsRegex   regexp.Regexp
+	// withoutSession is true if the request must match independently of the cookies in the session store of the registry
+	withoutSession bool
 }
 
 // Equal checks if a request is identical to another
@@ -26,6 +38,13 @@ func (r Request) Equal(r2 Request) bool {
 	return reflect.DeepEqual(r.url, r2.url) &&
 		reflect.DeepEqual(r.method, r2.method) &&
 		reflect.DeepEqual(r.headers, r2.headers) &&
+		reflect.DeepEqual(r.cookies, r2.cookies) &&
+		reflect.DeepEqual(r.form, r2.form) &&
+		reflect.DeepEqual(r.xml, r2.xml) &&
+		reflect.DeepEqual(r.graphql, r2.graphql) &&
+		reflect.DeepEqual(r.jsonrpc, r2.jsonrpc) &&
+		reflect.DeepEqual(r.bodyMatchers, r2.bodyMatchers) &&
+		r.withoutSession == r2.withoutSession &&
 		reflect.DeepEqual(r.body, r2.body) &&
 		reflect.DeepEqual(r.urlAsRegex, r2.urlAsRegex)
 }
@@ -56,27 +75,153 @@ func (r Request) WithMethod(method string) Request {
 	return r
 }
 
-// WithHeader returns a new request with the header header set to value
+// WithHeader returns a new request that matches only if one of the values of the header header is equal to value.
+// Differently from http.Header.Get, which only returns the first value, every value of a repeated header is compared,
+// so a request that sends `Accept: text/html` and `Accept: application/json` matches WithHeader("Accept", "application/json").
+// Use WithHeaderValues to require the exact list of values.
+// If WithHeader is called multiple times for the same header only the last value is applied.
 func (r Request) WithHeader(header string, value string) Request {
-	r.headers[header] = value
+	r.headers = withHeaderCriterion(r.headers, newHeaderCriterion(header, headerEquals, value), true)
 	return r
 }
 
 // WithJSONHeader returns a new request with the header `Content-Type` set to `application/json`
 func (r Request) WithJSONHeader() Request {
-	r.headers["Content-Type"] = "application/json"
-	return r
+	return r.WithHeader("Content-Type", "application/json")
 }
 
 // WithHeaders returns a new request with all the headers in headers applied.
 // If multiple headers with the same name are defined only the last one is applied.
 func (r Request) WithHeaders(headers map[string]string) Request {
 	for k, v := range headers {
-		r.headers[k] = v
+		r = r.WithHeader(k, v)
 	}
 	return r
 }
 
+// WithHeaderContaining returns a new request that matches only if one of the values of the header header contains value
+func (r Request) WithHeaderContaining(header string, value string) Request {
+	r.headers = withHeaderCriterion(r.headers, newHeaderCriterion(header, headerContains, value), false)
+	return r
+}
+
+// WithHeaderPrefix returns a new request that matches only if one of the values of the header header starts with prefix.
+// For example
+//
+//	NewRequest().WithHeaderPrefix("Authorization", "Bearer ")
+func (r Request) WithHeaderPrefix(header string, prefix string) Request {
+	r.headers = withHeaderCriterion(r.headers, newHeaderCriterion(header, headerHasPrefix, prefix), false)
+	return r
+}
+
+// WithHeaderMatching returns a new request that matches only if one of the values of the header header matches regex.
+// This method panics if regex is not a valid regular expression
+func (r Request) WithHeaderMatching(header string, regex string) Request {
+	criterion := newHeaderCriterion(header, headerMatches, regex)
+	criterion.regex = mustCompileRegex(regex, fmt.Sprintf("the header %q", criterion.header))
+	r.headers = withHeaderCriterion(r.headers, criterion, false)
+	return r
+}
+
+// WithHeaderPresent returns a new request that matches only if the header header is present, independently of its value
+func (r Request) WithHeaderPresent(header string) Request {
+	r.headers = withHeaderCriterion(r.headers, newHeaderCriterion(header, headerPresent), true)
+	return r
+}
+
+// WithHeaderAbsent returns a new request that matches only if the header header is not present
+func (r Request) WithHeaderAbsent(header string) Request {
+	r.headers = withHeaderCriterion(r.headers, newHeaderCriterion(header, headerAbsent), true)
+	return r
+}
+
+// WithHeaderValues returns a new request that matches only if the header header has exactly values as values, in the same order.
+// This is useful to match headers that are repeated, for example
+//
+//	NewRequest().WithHeaderValues("Accept", "application/json", "text/plain")
+//
+// matches a request with the headers
+//
+//	Accept: application/json
+//	Accept: text/plain
+func (r Request) WithHeaderValues(header string, values ...string) Request {
+	r.headers = withHeaderCriterion(r.headers, newHeaderCriterion(header, headerAllValues, values...), true)
+	return r
+}
+
+// WithCookie returns a new request that matches only if the cookie name is sent with value value
+func (r Request) WithCookie(name string, value string) Request {
+	r.cookies = withCookieCriterion(r.cookies, cookieCriterion{name: name, rule: cookieEquals, value: value})
+	return r
+}
+
+// WithCookiePresent returns a new request that matches only if the cookie name is sent, independently of its value
+func (r Request) WithCookiePresent(name string) Request {
+	r.cookies = withCookieCriterion(r.cookies, cookieCriterion{name: name, rule: cookiePresent})
+	return r
+}
+
+// WithCookieMatching returns a new request that matches only if the cookie name is sent with a value that matches regex.
+// This method panics if regex is not a valid regular expression
+func (r Request) WithCookieMatching(name string, regex string) Request {
+	r.cookies = withCookieCriterion(r.cookies, cookieCriterion{
+		name:  name,
+		rule:  cookieMatches,
+		value: regex,
+		regex: mustCompileRegex(regex, fmt.Sprintf("the cookie %q", name)),
+	})
+	return r
+}
+
+// WithoutSession returns a new request that matches independently of the cookies in the session store of the registry.
+// It has an effect only if the registry has sessions enabled via Registry.EnableSessions, see there for more details
+func (r Request) WithoutSession() Request {
+	r.withoutSession = true
+	return r
+}
+
+// WithFormField returns a new request that matches only if the application/x-www-form-urlencoded body contains the field name with value value.
+// If the field is sent multiple times it is enough that one of the values is equal to value
+func (r Request) WithFormField(name string, value string) Request {
+	r.form = withFormCriterion(r.form, formCriterion{field: name, rule: formFieldEquals, value: value})
+	return r
+}
+
+// WithMultipartField returns a new request that matches only if the multipart/form-data body contains a part named name whose content is value
+func (r Request) WithMultipartField(name string, value string) Request {
+	r.form = withFormCriterion(r.form, formCriterion{multipart: true, field: name, rule: partValueEquals, value: value})
+	return r
+}
+
+// WithMultipartFile returns a new request that matches only if the multipart/form-data body contains a part named name
+// that uploads a file called fileName
+func (r Request) WithMultipartFile(name string, fileName string) Request {
+	r.form = withFormCriterion(r.form, formCriterion{multipart: true, field: name, rule: partFileNameEquals, value: fileName})
+	return r
+}
+
+// WithMultipartContentType returns a new request that matches only if the multipart/form-data body contains a part named name
+// with the Content-Type contentType
+func (r Request) WithMultipartContentType(name string, contentType string) Request {
+	r.form = withFormCriterion(r.form, formCriterion{multipart: true, field: name, rule: partContentTypeEqual, value: contentType})
+	return r
+}
+
+// WithMultipartFileContent returns a new request that matches only if the multipart/form-data body contains a part named name
+// whose content is equal to content
+func (r Request) WithMultipartFileContent(name string, content []byte) Request {
+	r.form = withFormCriterion(r.form, formCriterion{multipart: true, field: name, rule: partContentEquals, value: string(content)})
+	return r
+}
+
+// WithMultipartFileChecksum returns a new request that matches only if the multipart/form-data body contains a part named name
+// whose content has the hex encoded SHA-256 checksum checksum.
+// This is useful to match large files without keeping them in the test
+func (r Request) WithMultipartFileChecksum(name string, checksum string) Request {
+	r.form = withFormCriterion(r.form, formCriterion{multipart: true, field: name, rule: partChecksumEquals, value: strings.ToLower(checksum)})
+	return r
+}
+
 // WithBody returns a new request with the method body set to body
 func (r Request) WithBody(body []byte) Request {
 	r.body = body
@@ -98,6 +243,34 @@ func (r Request) WithJSONBody(body any) Request {
 	return r
 }
 
+// WithXMLBody returns a new request that matches only if the body is XML equivalent to body.
+// Whitespace between elements, comments, the order of the attributes and the namespace prefixes are ignored,
+// elements are compared using their namespace and not their prefix.
+// This method panics if body is not valid XML
+func (r Request) WithXMLBody(body string) Request {
+	r.xml = withXMLCriterion(r.xml, xmlCriterion{rule: xmlEquivalent, value: body, document: mustParseXML(body)})
+	return r
+}
+
+// WithXPath returns a new request that matches only if the body is XML and one of the values selected by expr is equal to value.
+// The value of an element is its text without leading and trailing whitespace.
+// Only a subset of XPath is supported: absolute paths made of / and // steps, element names where the namespace prefix is ignored, *,
+// a final @attribute or text() step and the predicates [n], [@attribute], [@attribute='value'], [child], [child='value'] and [text()='value'].
+// This method panics if expr is not valid
+//
+//	NewRequest().WithXPath("/Envelope/Body/Pay/Item[@currency='EUR']/Amount", "10.00")
+func (r Request) WithXPath(expr string, value string) Request {
+	r.xml = withXMLCriterion(r.xml, xmlCriterion{rule: xpathEquals, expr: expr, value: value, path: mustParseXPath(expr)})
+	return r
+}
+
+// WithXPathPresent returns a new request that matches only if the body is XML and expr selects at least one value.
+// See WithXPath for the supported expressions, this method panics if expr is not valid
+func (r Request) WithXPathPresent(expr string) Request {
+	r.xml = withXMLCriterion(r.xml, xmlCriterion{rule: xpathPresent, expr: expr, path: mustParseXPath(expr)})
+	return r
+}
+
 // NewRequest creates a new request designed to be registered to a Registry to get matched against an incoming HTTP request.
 // This function is designed to be used in conjunction with other other receivers.
 // For example
@@ -125,12 +298,18 @@ func NewRequest() Request {
 //		WithBody([]byte("{\"user\": \"John Schmidt\"}"))
 func newRequestWithName(name string) Request {
 	r := Request{
-		name:       name,
-		url:        "",
-		urlAsRegex: *regexp.MustCompile(".+"),
-		method:     "",
-		headers:    make(map[string]string),
-		body:       make([]byte, 0),
+		name:         name,
+		url:          "",
+		urlAsRegex:   *regexp.MustCompile(".+"),
+		method:       "",
+		headers:      []headerCriterion{},
+		cookies:      []cookieCriterion{},
+		form:         []formCriterion{},
+		xml:          []xmlCriterion{},
+		graphql:      []graphqlCriterion{},
+		jsonrpc:      []jsonrpcCriterion{},
+		bodyMatchers: []BodyMatcher{},
+		body:         make([]byte, 0),
 	}
 	return r
 }
@@ -40,3 +40,17 @@ func (s *TestSuite) TestModifyingDefaultRequestDoesNotChangeOriginal() {
 
 	s.False(httpregistry.DefaultRequest.Equal(r))
 }
+
+func (s *TestSuite) TestHeaderCriteriaDoNotDependOnTheOrder() {
+	r1 := httpregistry.NewRequest().WithHeaderPrefix("Authorization", "Bearer ").WithHeader("accept", "text/plain")
+	r2 := httpregistry.NewRequest().WithHeader("Accept", "text/plain").WithHeaderPrefix("Authorization", "Bearer ")
+
+	s.True(r1.Equal(r2))
+}
+
+func (s *TestSuite) TestAddingHeadersDoesNotChangeTheOriginal() {
+	r := httpregistry.NewRequest().WithHeader("Accept", "text/plain")
+	_ = r.WithHeader("Accept", "application/json")
+
+	s.True(r.Equal(httpregistry.NewRequest().WithHeader("Accept", "text/plain")))
+}
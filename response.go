@@ -2,6 +2,9 @@ package httpregistry
 
 import (
 	"net/http"
+	"sort"
+	"strings"
+	"time"
 )
 
 // The list of all status codes is available at
@@ -63,6 +66,7 @@ var (
 	MisdirectedRequestResponse          = newResponseWithName("httpregistry.MisdirectedRequestResponse").WithStatus(421)
 	UpgradeRequiredResponse             = newResponseWithName("httpregistry.UpgradeRequiredResponse").WithStatus(426)
 	ReconditionRequiredResponse         = newResponseWithName("httpregistry.ReconditionRequiredResponse").WithStatus(428)
+	TooManyRequestsResponse             = newResponseWithName("httpregistry.TooManyRequestsResponse").WithStatus(429)
 	RequestHeaderFieldsTooLargeResponse = newResponseWithName("httpregistry.RequestHeaderFieldsTooLargeResponse").WithStatus(431)
 	UnavailableForLegalReasonsResponse  = newResponseWithName("httpregistry.UnavailableForLegalReasonsResponse").WithStatus(451)
 )
@@ -86,19 +90,81 @@ type Response struct {
 	name       string
 	statusCode int
 	body       []byte
-	headers    map[string]string
+	headers    http.Header
+	cookies    []http.Cookie
+	trailers   http.Header
+	// jsonrpc is not nil if the response answers a JSON-RPC call, in this case the body is generated when the response is served
+	jsonrpc *jsonrpcReply
+	// delay is how long the response waits, on the clock of the registry, before it is emitted
+	delay time.Duration
+	// dateHeader is true if the header `Date` is set to the time of the clock of the registry
+	dateHeader bool
+	// expires is not nil if the header `Expires` is set to the time of the clock of the registry plus expires
+	expires *time.Duration
+	// conditional is true if a conditional request satisfied by the `ETag` or `Last-Modified` of the response is answered with a 304
+	conditional bool
 }
 
 // serveResponse emits the response encoded in Response to w
-func (res Response) serveResponse(w http.ResponseWriter, _ *http.Request) {
-	for k, v := range res.headers {
-		w.Header().Add(k, v)
+func (res Response) serveResponse(w http.ResponseWriter, r *http.Request) {
+	clock := clockFromRequest(r)
+	if res.delay > 0 {
+		select {
+		case <-clock.After(res.delay):
+		case <-r.Context().Done():
+			// the client is gone, there is no one to answer to
+			return
+		}
 	}
+	if res.dateHeader {
+		w.Header().Set("Date", clock.Now().UTC().Format(http.TimeFormat))
+	}
+	if res.expires != nil {
+		w.Header().Set("Expires", clock.Now().Add(*res.expires).UTC().Format(http.TimeFormat))
+	}
+
+	for k, values := range res.headers {
+		for _, v := range values {
+			w.Header().Add(k, v)
+		}
+	}
+	for _, cookie := range res.cookies {
+		http.SetCookie(w, &cookie)
+	}
+
+	if res.conditional && isNotModified(r, w.Header()) {
+		w.Header().Del("Content-Type")
+		w.Header().Del("Content-Length")
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	if res.jsonrpc != nil {
+		res.jsonrpc.serve(w, r, res.statusCode)
+		return
+	}
+
+	// trailers must be declared before the header is written, their values are sent after the body
+	if len(res.trailers) > 0 {
+		names := make([]string, 0, len(res.trailers))
+		for name := range res.trailers {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		w.Header().Set("Trailer", strings.Join(names, ", "))
+	}
+
 	w.WriteHeader(res.statusCode)
 	_, err := w.Write(res.body)
 	if err != nil {
 		panic("cannot write body of request")
 	}
+
+	for k, values := range res.trailers {
+		for _, v := range values {
+			w.Header().Add(k, v)
+		}
+	}
 }
 
 // WithName allows to add a name to a Response so that it can be better identified when debugging.
@@ -120,33 +186,88 @@ func (res Response) WithStatus(statusCode int) Response {
 	return res
 }
 
-// WithHeader returns a new response with the header header set to value
+// WithHeader returns a new response with the header header set to value.
+// If the header was already set, its previous values are replaced
 func (res Response) WithHeader(header string, value string) Response {
-	res.headers[header] = value
+	res.headers = res.headers.Clone()
+	res.headers.Set(header, value)
 	return res
 }
 
 // WithJSONHeader returns a new Response with the header `Content-Type` set to `application/json`
 func (res Response) WithJSONHeader() Response {
-	res.headers["Content-Type"] = "application/json"
-	return res
+	return res.WithHeader("Content-Type", "application/json")
 }
 
 // WithHeaders returns a new response with all the headers in headers applied.
 // If multiple headers with the same name are defined only the last one is applied.
 func (res Response) WithHeaders(headers map[string]string) Response {
 	for k, v := range headers {
-		res.headers[k] = v
+		res = res.WithHeader(k, v)
+	}
+	return res
+}
+
+// WithHeaderValues returns a new response where the header header is repeated once for each value in values.
+// If the header was already set, its previous values are replaced.
+// For example
+//
+//	NewResponse().WithHeaderValues("Link", `<https://api.example.com/items?page=2>; rel="next"`, `<https://api.example.com/items?page=5>; rel="last"`)
+func (res Response) WithHeaderValues(header string, values ...string) Response {
+	res.headers = res.headers.Clone()
+	res.headers.Del(header)
+	for _, v := range values {
+		res.headers.Add(header, v)
 	}
 	return res
 }
 
+// WithCookie returns a new response that sets cookie via the Set-Cookie header.
+// Calling WithCookie multiple times sets multiple cookies
+func (res Response) WithCookie(cookie *http.Cookie) Response {
+	cookies := make([]http.Cookie, 0, len(res.cookies)+1)
+	cookies = append(cookies, res.cookies...)
+	res.cookies = append(cookies, *cookie)
+	return res
+}
+
+// WithTrailer returns a new response that declares the trailer name and sends it with value value after the body.
+// Calling WithTrailer multiple times with the same name sends the trailer multiple times
+func (res Response) WithTrailer(name string, value string) Response {
+	res.trailers = res.trailers.Clone()
+	res.trailers.Add(name, value)
+	return res
+}
+
 // WithBody returns a new request with the method body set to body
 func (res Response) WithBody(body []byte) Response {
 	res.body = body
 	return res
 }
 
+// WithDelay returns a new response that waits d before being emitted, for example to test the timeouts of a client.
+// The delay follows the clock of the registry, so with a FakeClock the response is emitted only when the clock is advanced by d.
+// If the client goes away while waiting nothing is emitted
+func (res Response) WithDelay(d time.Duration) Response {
+	res.delay = d
+	return res
+}
+
+// WithDateHeader returns a new response with the header `Date` set to the time of the clock of the registry when the response is emitted
+func (res Response) WithDateHeader() Response {
+	res.dateHeader = true
+	return res
+}
+
+// WithExpires returns a new response with the header `Expires` set to the time of the clock of the registry,
+// when the response is emitted, plus d
+//
+//	NewResponse().WithDateHeader().WithExpires(time.Hour)
+func (res Response) WithExpires(d time.Duration) Response {
+	res.expires = &d
+	return res
+}
+
 // WithJSONBody returns a new response that will return body as body and will have
 // the header `Content-Type` set to `application/json`.
 // This method panics if body cannot be converted to JSON
@@ -156,6 +277,21 @@ func (res Response) WithJSONBody(body any) Response {
 	return res
 }
 
+// WithXMLHeader returns a new Response with the header `Content-Type` set to `application/xml`
+func (res Response) WithXMLHeader() Response {
+	return res.WithHeader("Content-Type", "application/xml")
+}
+
+// WithXMLBody returns a new response that will return the XML encoded version of body as body and will have
+// the header `Content-Type` set to `application/xml`.
+// If body is a string or a slice of bytes it is considered already encoded and it is returned as it is.
+// This method panics if body cannot be converted to XML
+func (res Response) WithXMLBody(body any) Response {
+	res = res.WithXMLHeader()
+	res.body = mustMarshalXML(body)
+	return res
+}
+
 // NewResponse creates a new Response.
 // This function is designed to be used in conjunction with other other receivers.
 // For example
@@ -187,7 +323,9 @@ func newResponseWithName(name string) Response {
 		name:       name,
 		statusCode: 200,
 		body:       make([]byte, 0),
-		headers:    make(map[string]string),
+		headers:    http.Header{},
+		cookies:    []http.Cookie{},
+		trailers:   http.Header{},
 	}
 
 	return r
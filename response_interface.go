@@ -7,6 +7,7 @@ import "net/http"
 //
 //   - httpregistry.Response -> it allows to define (one or more) status code, body and headers
 //   - httpregistry.CustomResponse -> it allows to define the response as a function of (w, r)
+//   - httpregistry.RangeResponse -> it allows to serve a byte payload honouring the Range header
 type mockResponse interface {
 	// serveResponse emits the response encoded in the struct that implements mockResponse to w
 	serveResponse(w http.ResponseWriter, r *http.Request)
@@ -33,6 +33,22 @@ func (s *TestSuite) TestCustomResponseReturnsExpectedHeadersAndBody() {
 			expectedBody:    "{\"a\":10}",
 			expectedHeaders: map[string][]string{"Content-Type": {"application/json"}},
 		},
+		{
+			name: "repeated headers work",
+			response: NewResponse().
+				WithHeader("Link", "<old>").
+				WithHeaderValues("Link", `</items?page=2>; rel="next"`, `</items?page=5>; rel="last"`),
+			expectedBody:    "",
+			expectedHeaders: map[string][]string{"Link": {`</items?page=2>; rel="next"`, `</items?page=5>; rel="last"`}},
+		},
+		{
+			name: "cookies work",
+			response: NewResponse().
+				WithCookie(&http.Cookie{Name: "session", Value: "abc", HttpOnly: true}).
+				WithCookie(&http.Cookie{Name: "theme", Value: "dark", Path: "/"}),
+			expectedBody:    "",
+			expectedHeaders: map[string][]string{"Set-Cookie": {"session=abc; HttpOnly", "theme=dark; Path=/"}},
+		},
 	}
 	for _, tc := range testCases {
 		method := http.MethodGet
@@ -62,3 +78,35 @@ func (s *TestSuite) TestCustomResponseReturnsExpectedHeadersAndBody() {
 		})
 	}
 }
+
+func (s *TestSuite) TestResponseWithTrailers() {
+	response := NewResponse().
+		WithBody([]byte("hello")).
+		WithTrailer("X-Checksum", "abc").
+		WithTrailer("X-Status", "done")
+
+	registry := NewRegistry(s.T())
+	registry.AddResponse(response)
+
+	server := registry.GetServer()
+	defer server.Close()
+
+	res, err := http.Get(server.URL)
+	s.NoError(err)
+
+	body, err := io.ReadAll(res.Body)
+	s.NoError(err)
+	s.Equal("hello", string(body))
+	s.Equal(http.Header{"X-Checksum": {"abc"}, "X-Status": {"done"}}, res.Trailer)
+}
+
+func (s *TestSuite) TestResponseBuildersDoNotChangeTheOriginal() {
+	response := NewResponse().WithHeader("X-Foo", "bar").WithCookie(&http.Cookie{Name: "a", Value: "b"})
+	_ = response.WithHeader("X-Foo", "baz").WithCookie(&http.Cookie{Name: "c", Value: "d"}).WithTrailer("X-Bar", "foo")
+	_ = NotFoundResponse.WithJSONHeader()
+
+	s.Equal(http.Header{"X-Foo": {"bar"}}, response.headers)
+	s.Equal([]http.Cookie{{Name: "a", Value: "b"}}, response.cookies)
+	s.Empty(response.trailers)
+	s.Empty(NotFoundResponse.headers)
+}
@@ -0,0 +1,182 @@
+package httpregistry
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// WithRetryAfter returns a new response with the header `Retry-After` set to d, rounded up to whole seconds
+//
+//	httpregistry.TooManyRequestsResponse.WithRetryAfter(2 * time.Second)
+func (res Response) WithRetryAfter(d time.Duration) Response {
+	return res.WithHeader("Retry-After", strconv.Itoa(int(math.Ceil(d.Seconds()))))
+}
+
+// RetrySequence is a handle to a registration that fails a fixed number of times before succeeding.
+// It is returned by Registry.AddRetrySequence and it records when each attempt happened,
+// so that the retry logic of the code under test can be verified
+type RetrySequence struct {
+	registration *Registration
+	failures     int
+	// retryAfter is the value of the Retry-After header of the failure response, it is empty if the header is not set
+	retryAfter string
+
+	mu       sync.Mutex
+	attempts []time.Time
+}
+
+// retryAttempt is a response of a RetrySequence, it records the moment in which it is served
+type retryAttempt struct {
+	response mockResponse
+	sequence *RetrySequence
+}
+
+// serveResponse records the attempt and emits the wrapped response to w
+func (a retryAttempt) serveResponse(w http.ResponseWriter, r *http.Request) {
+	now := clockFromRequest(r).Now()
+	a.sequence.mu.Lock()
+	a.sequence.attempts = append(a.sequence.attempts, now)
+	a.sequence.mu.Unlock()
+
+	a.response.serveResponse(w, r)
+}
+
+// String returns the name of the wrapped response
+func (a retryAttempt) String() string {
+	return a.response.String()
+}
+
+// AddRetrySequence adds to the registry a registration for request that returns failure the first failures times it is called and then success once.
+// It is designed to test retry logic, the returned RetrySequence records when each attempt happened and it can be used to check
+// the number of attempts and the delays between them. This method panics if failures is negative
+//
+//	reg := httpregistry.NewRegistry(t)
+//	retries := reg.AddRetrySequence(
+//		httpregistry.NewRequest().WithURL("/users"),
+//		2, httpregistry.ServiceUnavailableResponse.WithRetryAfter(time.Second),
+//		httpregistry.OkResponse,
+//	)
+//	...
+//	retries.AssertAttempts(t, 3)
+//	retries.AssertRetryAfterHonoured(t)
+func (reg *Registry) AddRetrySequence(request Request, failures int, failure mockResponse, success mockResponse) *RetrySequence {
+	if failures < 0 {
+		panic(fmt.Sprintf("the number of failures cannot be negative, got %d", failures))
+	}
+
+	sequence := &RetrySequence{failures: failures}
+	if r, ok := failure.(Response); ok {
+		sequence.retryAfter = r.headers.Get("Retry-After")
+	}
+
+	failure = reg.ifNeededSetDefaultNameToMockResponse(failure)
+	responses := make(mockResponses, 0, failures+1)
+	for range failures {
+		responses = append(responses, retryAttempt{response: failure, sequence: sequence})
+	}
+	responses = append(responses, retryAttempt{response: reg.ifNeededSetDefaultNameToMockResponse(success), sequence: sequence})
+
+	sequence.registration = reg.AddRequestWithResponses(request, responses...)
+	return sequence
+}
+
+// Registration returns the handle of the registration that serves the sequence
+func (s *RetrySequence) Registration() *Registration {
+	return s.registration
+}
+
+// String returns the name of the request of the sequence
+func (s *RetrySequence) String() string {
+	return s.registration.String()
+}
+
+// Attempts returns, in chronological order, the moments in which the attempts were served according to the clock of the registry
+func (s *RetrySequence) Attempts() []time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]time.Time{}, s.attempts...)
+}
+
+// Delays returns the time elapsed between each attempt and the previous one, so it contains one element less than Attempts
+func (s *RetrySequence) Delays() []time.Duration {
+	attempts := s.Attempts()
+	delays := []time.Duration{}
+	for i := 1; i < len(attempts); i++ {
+		delays = append(delays, attempts[i].Sub(attempts[i-1]))
+	}
+	return delays
+}
+
+// AssertAttempts checks that the sequence was called exactly n times, if not the test is failed
+func (s *RetrySequence) AssertAttempts(t TestingT, n int) bool {
+	if attempts := len(s.Attempts()); attempts != n {
+		t.Errorf("request %v was attempted %d times, expected %d", s, attempts, n)
+		return false
+	}
+	return true
+}
+
+// AssertMinDelay checks that every attempt happened at least minDelay after the previous one, if not the test is failed
+func (s *RetrySequence) AssertMinDelay(t TestingT, minDelay time.Duration) bool {
+	ok := true
+	for i, delay := range s.Delays() {
+		if delay < minDelay {
+			t.Errorf("attempt %d of request %v happened %v after the previous one, expected at least %v", i+2, s, delay, minDelay)
+			ok = false
+		}
+	}
+	return ok
+}
+
+// AssertMaxDelay checks that every attempt happened at most maxDelay after the previous one, if not the test is failed
+func (s *RetrySequence) AssertMaxDelay(t TestingT, maxDelay time.Duration) bool {
+	ok := true
+	for i, delay := range s.Delays() {
+		if delay > maxDelay {
+			t.Errorf("attempt %d of request %v happened %v after the previous one, expected at most %v", i+2, s, delay, maxDelay)
+			ok = false
+		}
+	}
+	return ok
+}
+
+// AssertRetryAfterHonoured checks that every attempt that follows a failure waited at least as long as requested
+// by the `Retry-After` header of the failure response, if not the test is failed.
+// Both the delay in seconds and the HTTP date formats of the header are supported
+func (s *RetrySequence) AssertRetryAfterHonoured(t TestingT) bool {
+	if s.retryAfter == "" {
+		t.Errorf("the failure response of request %v has no Retry-After header", s)
+		return false
+	}
+
+	ok := true
+	attempts := s.Attempts()
+	for i := 1; i < len(attempts) && i <= s.failures; i++ {
+		wait, err := retryAfterDelay(s.retryAfter, attempts[i-1])
+		if err != nil {
+			t.Errorf("the Retry-After header of request %v is not valid: %v", s, err)
+			return false
+		}
+		if delay := attempts[i].Sub(attempts[i-1]); delay < wait {
+			t.Errorf("attempt %d of request %v happened %v after the previous one, but Retry-After requested to wait %v", i+1, s, delay, wait)
+			ok = false
+		}
+	}
+	return ok
+}
+
+// retryAfterDelay returns how long a client has to wait according to value, the value of a Retry-After header sent at served
+func retryAfterDelay(value string, served time.Time) (time.Duration, error) {
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, nil
+	}
+	date, err := http.ParseTime(value)
+	if err != nil {
+		return 0, fmt.Errorf("%q is neither a number of seconds nor a HTTP date", value)
+	}
+	return date.Sub(served), nil
+}
@@ -0,0 +1,32 @@
+package httpregistry
+
+import (
+	"net/http"
+	"time"
+)
+
+func (s *TestSuite) TestRetryAfterDelay() {
+	served := time.Date(2024, time.January, 1, 12, 0, 0, 0, time.UTC)
+	testCases := []struct {
+		name          string
+		value         string
+		expected      time.Duration
+		expectedError string
+	}{
+		{name: "seconds", value: "120", expected: 2 * time.Minute},
+		{name: "HTTP date", value: served.Add(30 * time.Second).Format(http.TimeFormat), expected: 30 * time.Second},
+		{name: "negative seconds", value: "-1", expectedError: `"-1" is neither a number of seconds nor a HTTP date`},
+		{name: "garbage", value: "soon", expectedError: `"soon" is neither a number of seconds nor a HTTP date`},
+	}
+	for _, tc := range testCases {
+		s.Run(tc.name, func() {
+			delay, err := retryAfterDelay(tc.value, served)
+			if tc.expectedError != "" {
+				s.EqualError(err, tc.expectedError)
+				return
+			}
+			s.NoError(err)
+			s.Equal(tc.expected, delay)
+		})
+	}
+}
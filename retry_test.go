@@ -0,0 +1,90 @@
+package httpregistry_test
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/dfioravanti/httpregistry"
+)
+
+// callUntilSuccess calls url until it returns 200, waiting delay between the attempts, and returns the number of attempts
+func (s *TestSuite) callUntilSuccess(url string, delay time.Duration, maxAttempts int) int {
+	for attempt := 1; attempt <= maxAttempts; attempt++ {
+		res, err := http.Get(url)
+		s.Require().NoError(err)
+		res.Body.Close()
+		if res.StatusCode == http.StatusOK {
+			return attempt
+		}
+		time.Sleep(delay)
+	}
+	return maxAttempts
+}
+
+func (s *TestSuite) TestRetrySequence() {
+	reg := httpregistry.NewRegistry(s.T())
+	retries := reg.AddRetrySequence(
+		httpregistry.NewRequest().WithURL("/users"),
+		2, httpregistry.ServiceUnavailableResponse,
+		httpregistry.OkResponse,
+	)
+	url := reg.GetServer().URL
+
+	s.Equal(3, s.callUntilSuccess(url+"/users", 20*time.Millisecond, 5))
+
+	s.Len(retries.Attempts(), 3)
+	s.Len(retries.Delays(), 2)
+	s.True(retries.AssertAttempts(s.T(), 3))
+	s.True(retries.AssertMinDelay(s.T(), 20*time.Millisecond))
+	s.True(retries.AssertMaxDelay(s.T(), 10*time.Second))
+	s.Equal(3, retries.Registration().NumberOfCalls())
+	reg.CheckAllResponsesAreConsumed()
+}
+
+func (s *TestSuite) TestRetrySequenceAssertionsFail() {
+	reg := httpregistry.NewRegistry(s.T())
+	retries := reg.AddRetrySequence(
+		httpregistry.NewRequest().WithName("users").WithURL("/users"),
+		1, httpregistry.TooManyRequestsResponse.WithRetryAfter(time.Minute),
+		httpregistry.OkResponse,
+	)
+	url := reg.GetServer().URL
+	s.Equal(2, s.callUntilSuccess(url+"/users", 0, 5))
+
+	t := httpregistry.NewMockTestingT()
+	s.False(retries.AssertAttempts(t, 4))
+	s.False(retries.AssertMinDelay(t, time.Minute))
+	s.False(retries.AssertMaxDelay(t, 0))
+	s.False(retries.AssertRetryAfterHonoured(t))
+	s.Len(t.Messages, 4)
+	s.Equal("request users was attempted 2 times, expected 4", t.Messages[0])
+	s.Contains(t.Messages[1], "attempt 2 of request users happened")
+	s.Contains(t.Messages[1], "expected at least 1m0s")
+	s.Contains(t.Messages[3], "but Retry-After requested to wait 1m0s")
+}
+
+func (s *TestSuite) TestRetryAfterHonoured() {
+	reg := httpregistry.NewRegistry(s.T())
+	retries := reg.AddRetrySequence(
+		httpregistry.NewRequest().WithURL("/users"),
+		2, httpregistry.TooManyRequestsResponse.WithRetryAfter(0),
+		httpregistry.OkResponse,
+	)
+	s.Equal(3, s.callUntilSuccess(reg.GetServer().URL+"/users", 0, 5))
+	s.True(retries.AssertRetryAfterHonoured(s.T()))
+
+	t := httpregistry.NewMockTestingT()
+	withoutHeader := reg.AddRetrySequence(httpregistry.NewRequest().WithURL("/orders"), 1, httpregistry.ServiceUnavailableResponse, httpregistry.OkResponse)
+	s.False(withoutHeader.AssertRetryAfterHonoured(t))
+	s.Equal([]string{"the failure response of request mock request #2 has no Retry-After header"}, t.Messages)
+}
+
+func (s *TestSuite) TestWithRetryAfter() {
+	reg := httpregistry.NewRegistry(s.T())
+	reg.AddResponse(httpregistry.TooManyRequestsResponse.WithRetryAfter(1500 * time.Millisecond))
+
+	res, err := http.Get(reg.GetServer().URL)
+	s.NoError(err)
+	s.Equal(http.StatusTooManyRequests, res.StatusCode)
+	s.Equal("2", res.Header.Get("Retry-After"))
+}
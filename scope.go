@@ -0,0 +1,133 @@
+package httpregistry
+
+// cleaner is implemented by the implementations of TestingT that can run a function at the end of the test, like [testing.T]
+type cleaner interface {
+	Cleanup(f func())
+}
+
+// Reset brings the registry back to the state it had when it was created: it removes all the registrations,
+// the journal, the reasons why requests did not match, the ordered calls and the session cookies, and it closes the active scope if any.
+// The configuration of the registry, like the mode, the fallback, the admin API or whether sessions are enabled, is kept
+// and the server returned by GetServer keeps working with the same URL, so a single server can be shared by many test cases
+//
+//	reg := httpregistry.NewRegistry(t)
+//	server := reg.GetServer()
+//	for _, tc := range testCases {
+//		reg.Reset()
+//		reg.AddRequestWithResponse(tc.request, tc.response)
+//		...
+//	}
+func (reg *Registry) Reset() {
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+
+	reg.registrations = []*Registration{}
+	reg.misses = []miss{}
+	reg.journal = nil
+	reg.report = MissReport{}
+	reg.orderedGroups = nil
+	if reg.sessions != nil {
+		reg.sessions = newSessionStore()
+	}
+	reg.scope = nil
+	reg.nameRequestFunction = defaultName("mock request")
+	reg.nameCustomResponseFunction = defaultName("custom mock response")
+	reg.nameResponseFunction = defaultName("mock response")
+}
+
+// Scope creates a child registry whose registrations are layered over the ones of reg.
+// While the scope is open, the requests that reach the server of reg are served by the scope:
+// the registrations of the scope are tried first and, if none matches, the ones of reg are tried in their usual order.
+// The registrations of the scope win even over the registrations of reg with a higher priority, which only orders the registrations of reg.
+// The IDs of the registrations of the scope continue the ones of reg, so they are unique across the two.
+// Failures are reported to t and the journal, the misses and the session cookies of the scope are separate from the ones of reg,
+// but the calls served by the registrations of reg are still recorded on them.
+//
+// If t implements Cleanup, like [testing.T] does, the scope is closed automatically at the end of the test,
+// otherwise it must be closed with Close. Only one scope of a registry can be open at a time and opening a second one panics,
+// so scopes cannot be used by parallel subtests. Scopes can be nested by calling Scope on a scope
+//
+//	reg := httpregistry.NewRegistry(t)
+//	reg.AddRequestWithInfiniteResponse(httpregistry.NewRequest().WithURL("/health"), httpregistry.OkResponse)
+//	server := reg.GetServer()
+//	for _, tc := range testCases {
+//		t.Run(tc.name, func(t *testing.T) {
+//			scope := reg.Scope(t)
+//			scope.AddRequestWithResponse(tc.request, tc.response)
+//			...
+//			scope.CheckAllResponsesAreConsumed()
+//		})
+//	}
+func (reg *Registry) Scope(t TestingT) *Registry {
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+
+	if reg.scope != nil {
+		panic("the registry already has an open scope, close it before opening a new one")
+	}
+
+	scope := &Registry{
+		t:                          t,
+		mu:                         reg.mu,
+		parent:                     reg,
+		mode:                       reg.mode,
+		strategy:                   reg.strategy,
+		clock:                      reg.clock,
+		journalLimit:               reg.journalLimit,
+		fallback:                   reg.fallback,
+		missHandler:                reg.missHandler,
+		nameRequestFunction:        reg.nameRequestFunction,
+		nameCustomResponseFunction: reg.nameCustomResponseFunction,
+		nameResponseFunction:       reg.nameResponseFunction,
+	}
+	if reg.sessions != nil {
+		scope.sessions = newSessionStore()
+	}
+	reg.scope = scope
+
+	if c, ok := t.(cleaner); ok {
+		c.Cleanup(scope.Close)
+	}
+	return scope
+}
+
+// Close closes a scope created with Scope: its registrations and the scopes opened on it are discarded
+// and the requests are served again by the parent registry.
+// Closing a scope twice, or calling Close on a registry that is not a scope, does nothing
+func (reg *Registry) Close() {
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+
+	if reg.parent == nil {
+		return
+	}
+	reg.scope = nil
+	if reg.parent.scope == reg {
+		reg.parent.scope = nil
+	}
+}
+
+// activeScope returns the innermost open scope of reg, or nil if reg has no open scope
+func (reg *Registry) activeScope() *Registry {
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+
+	if reg.scope == nil {
+		return nil
+	}
+	scope := reg.scope
+	for scope.scope != nil {
+		scope = scope.scope
+	}
+	return scope
+}
+
+// layeredRegistrations returns the registrations of reg followed by the ones of its parents, in the order in which they are tried.
+// It must be called while holding the lock
+func (reg *Registry) layeredRegistrations() []*Registration {
+	registrations := reg.registrations
+	for parent := reg.parent; parent != nil; parent = parent.parent {
+		registrations = append(registrations[:len(registrations):len(registrations)], parent.registrations...)
+	}
+	return registrations
+}
@@ -0,0 +1,167 @@
+package httpregistry_test
+
+import (
+	"io"
+	"net/http"
+
+	"github.com/dfioravanti/httpregistry"
+)
+
+// getBody calls url with GET and returns the status code and the body of the response
+func (s *TestSuite) getBody(url string) (int, string) {
+	res, err := http.Get(url)
+	s.Require().NoError(err)
+	defer res.Body.Close()
+	body, err := io.ReadAll(res.Body)
+	s.NoError(err)
+	return res.StatusCode, string(body)
+}
+
+func (s *TestSuite) TestResetKeepsTheServer() {
+	t := httpregistry.NewMockTestingT()
+	reg := httpregistry.NewRegistry(t)
+	reg.AddURL("/users")
+	url := reg.GetServer().URL
+
+	status, _ := s.getBody(url + "/users")
+	s.Equal(http.StatusOK, status)
+
+	reg.Reset()
+	s.Empty(reg.GetJournal(httpregistry.NewJournalQuery()))
+
+	status, _ = s.getBody(url + "/users")
+	s.Equal(http.StatusInternalServerError, status)
+	s.True(t.HasFailed)
+
+	registration := reg.AddURL("/orders")
+	s.Equal("mock request #1", registration.String())
+	status, _ = s.getBody(url + "/orders")
+	s.Equal(http.StatusOK, status)
+	s.Len(reg.GetJournal(httpregistry.NewJournalQuery()), 2)
+}
+
+func (s *TestSuite) TestResetClearsTheSessionCookies() {
+	reg := httpregistry.NewRegistry(s.T())
+	reg.EnableSessions()
+	reg.AddRequestWithResponse(
+		httpregistry.NewRequest().WithURL("/login"),
+		httpregistry.NewResponse().WithCookie(&http.Cookie{Name: "session", Value: "abc"}),
+	)
+	url := reg.GetServer().URL
+
+	status, _ := s.getBody(url + "/login")
+	s.Equal(http.StatusOK, status)
+	s.Len(reg.GetSessionCookies(), 1)
+
+	reg.Reset()
+	s.Empty(reg.GetSessionCookies())
+}
+
+func (s *TestSuite) TestScopesLayerOverTheBaseRegistrations() {
+	reg := httpregistry.NewRegistry(s.T())
+	reg.AddRequestWithInfiniteResponse(httpregistry.NewRequest().WithURL("/health"), httpregistry.NewResponse().WithBody([]byte("base")))
+	reg.AddRequestWithInfiniteResponse(httpregistry.NewRequest().WithURL("/users"), httpregistry.NewResponse().WithBody([]byte("base users")))
+	url := reg.GetServer().URL
+
+	testCases := []struct {
+		name         string
+		usersBody    string
+		expectedBody string
+	}{
+		{name: "John", usersBody: "John", expectedBody: "John"},
+		{name: "Jane", usersBody: "Jane", expectedBody: "Jane"},
+	}
+	for _, tc := range testCases {
+		s.Run(tc.name, func() {
+			scope := reg.Scope(s.T())
+			users := scope.AddRequestWithResponse(httpregistry.NewRequest().WithURL("/users"), httpregistry.NewResponse().WithBody([]byte(tc.usersBody)))
+
+			_, body := s.getBody(url + "/users")
+			s.Equal(tc.expectedBody, body)
+			_, body = s.getBody(url + "/health")
+			s.Equal("base", body)
+
+			s.Equal(1, users.NumberOfCalls())
+			s.Len(scope.GetJournal(httpregistry.NewJournalQuery()), 2)
+			scope.CheckAllResponsesAreConsumed()
+		})
+	}
+
+	// the scopes were closed at the end of the subtests
+	_, body := s.getBody(url + "/users")
+	s.Equal("base users", body)
+	s.Len(reg.GetJournal(httpregistry.NewJournalQuery()), 1)
+}
+
+func (s *TestSuite) TestScopeReportsFailuresToItsTest() {
+	parent := httpregistry.NewMockTestingT()
+	reg := httpregistry.NewRegistry(parent)
+	reg.AddURL("/health")
+	url := reg.GetServer().URL
+
+	child := httpregistry.NewMockTestingT()
+	scope := reg.Scope(child)
+	scope.AddURL("/users")
+
+	status, _ := s.getBody(url + "/orders")
+	s.Equal(http.StatusInternalServerError, status)
+	s.True(child.HasFailed)
+	s.False(parent.HasFailed)
+	s.Contains(scope.Why(), "mock request #2 missed because the path does not match")
+	s.Contains(scope.Why(), "mock request #1 missed because the path does not match")
+
+	scope.Close()
+	status, _ = s.getBody(url + "/users")
+	s.Equal(http.StatusInternalServerError, status)
+	s.True(parent.HasFailed)
+}
+
+func (s *TestSuite) TestScopeRegistrationsHaveTheirOwnIDsAndWinOverPriorities() {
+	reg := httpregistry.NewRegistry(s.T())
+	base := reg.AddRequestWithInfiniteResponse(httpregistry.NewRequest().WithURL("/users"), httpregistry.NewResponse().WithBody([]byte("base")))
+	base.SetPriority(10)
+	url := reg.GetServer().URL
+
+	scope := reg.Scope(s.T())
+	users := scope.AddRequestWithInfiniteResponse(httpregistry.NewRequest().WithURL("/users"), httpregistry.NewResponse().WithBody([]byte("scope")))
+	orders := scope.AddURL("/orders")
+	s.Equal(1, base.ID())
+	s.Equal(2, users.ID())
+	s.Equal(3, orders.ID())
+
+	_, body := s.getBody(url + "/users")
+	s.Equal("scope", body)
+	_, _ = s.getBody(url + "/orders")
+
+	// the IDs keep growing after the scope is closed
+	scope.Close()
+	s.Equal(4, reg.AddURL("/orders").ID())
+	_, body = s.getBody(url + "/users")
+	s.Equal("base", body)
+}
+
+func (s *TestSuite) TestNestedScopes() {
+	reg := httpregistry.NewRegistry(httpregistry.NewMockTestingT())
+	reg.AddRequestWithInfiniteResponse(httpregistry.NewRequest().WithURL("/users"), httpregistry.NewResponse().WithBody([]byte("base")))
+	url := reg.GetServer().URL
+
+	outer := reg.Scope(httpregistry.NewMockTestingT())
+	outer.AddRequestWithInfiniteResponse(httpregistry.NewRequest().WithURL("/users"), httpregistry.NewResponse().WithBody([]byte("outer")))
+	inner := outer.Scope(httpregistry.NewMockTestingT())
+	inner.AddRequestWithInfiniteResponse(httpregistry.NewRequest().WithURL("/users"), httpregistry.NewResponse().WithBody([]byte("inner")))
+
+	_, body := s.getBody(url + "/users")
+	s.Equal("inner", body)
+
+	inner.Close()
+	_, body = s.getBody(url + "/users")
+	s.Equal("outer", body)
+
+	s.PanicsWithValue("the registry already has an open scope, close it before opening a new one", func() {
+		reg.Scope(httpregistry.NewMockTestingT())
+	})
+
+	outer.Close()
+	_, body = s.getBody(url + "/users")
+	s.Equal("base", body)
+}
@@ -0,0 +1,60 @@
+package httpregistry
+
+import (
+	"encoding/xml"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// soapAction returns the SOAP action of r.
+// SOAP 1.1 sends it in the SOAPAction header, usually quoted, while SOAP 1.2 sends it as the action parameter of the Content-Type
+func soapAction(r *http.Request) string {
+	if action := r.Header.Get("SOAPAction"); action != "" {
+		return strings.Trim(action, `"`)
+	}
+	_, params, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil {
+		return ""
+	}
+	return params["action"]
+}
+
+// WithSOAPAction returns a new request that matches only if the SOAP action of the incoming request is action.
+// Both SOAP 1.1, that uses the SOAPAction header, and SOAP 1.2, that uses the action parameter of the Content-Type, are supported
+//
+//	NewRequest().
+//		WithMethod(http.MethodPost).
+//		WithURL("/payments").
+//		WithSOAPAction("urn:Pay").
+//		WithXPath("//Pay/Amount", "10.00")
+func (r Request) WithSOAPAction(action string) Request {
+	r.xml = withXMLCriterion(r.xml, xmlCriterion{rule: soapActionEquals, value: action})
+	return r
+}
+
+// soapFault is the SOAP 1.1 envelope that contains a fault
+type soapFault struct {
+	XMLName xml.Name `xml:"soap:Envelope"`
+	Soap    string   `xml:"xmlns:soap,attr"`
+	Fault   struct {
+		Code   string `xml:"faultcode"`
+		String string `xml:"faultstring"`
+	} `xml:"soap:Body>soap:Fault"`
+}
+
+// WithSOAPFault returns a new response that returns a SOAP 1.1 fault with the given code and message.
+// The status code is set to 500, as required by the SOAP specification, and the header `Content-Type` to `text/xml; charset=utf-8`.
+// For example
+//
+//	NewResponse().WithSOAPFault("soap:Client", "the card was declined")
+func (res Response) WithSOAPFault(code string, message string) Response {
+	fault := soapFault{Soap: "http://schemas.xmlsoap.org/soap/envelope/"}
+	fault.Fault.Code = code
+	fault.Fault.String = message
+
+	res = res.WithStatus(http.StatusInternalServerError)
+	res = res.WithHeader("Content-Type", "text/xml; charset=utf-8")
+	res.body = mustMarshalXML(fault)
+	return res
+}
@@ -0,0 +1,301 @@
+package httpregistry
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+// StubFile is the content of a file that defines stubs, it is the format read by the httpregistry command
+//
+//	{
+//		"stubs": [
+//			{
+//				"name": "list users",
+//				"request": {"method": "GET", "url": "/users"},
+//				"responses": [{"status": 200, "jsonBody": [{"name": "John"}]}],
+//				"infinite": true
+//			}
+//		]
+//	}
+type StubFile struct {
+	Stubs []Stub `json:"stubs"`
+}
+
+// Stub is the serializable definition of a registration: a request and the responses that are returned when it is matched
+type Stub struct {
+	// Name is used as name of the request, if it is empty the name is the method and the URL of the request or "any request" if they are empty too
+	Name    string      `json:"name,omitempty"`
+	Request StubRequest `json:"request"`
+	// Responses are returned in order, one per call. If there are no responses a single empty 200 response is used
+	Responses []StubResponse `json:"responses,omitempty"`
+	// Infinite is true if the response is never consumed, in this case there can be at most one response
+	Infinite bool `json:"infinite,omitempty"`
+	// Priority is the priority of the registration, see Registration.SetPriority
+	Priority int `json:"priority,omitempty"`
+}
+
+// StubRequest is the serializable version of a Request
+type StubRequest struct {
+	Method string `json:"method,omitempty"`
+	// URL is interpreted as a regex, like in Request.WithURL
+	URL     string            `json:"url,omitempty"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    string            `json:"body,omitempty"`
+	// JSONBody is compared as JSON with the body of the request, so whitespace and the order of the fields do not matter.
+	// It cannot be used together with Body
+	JSONBody json.RawMessage `json:"jsonBody,omitempty"`
+}
+
+// StubResponse is the serializable version of a Response
+type StubResponse struct {
+	// Status is the status code of the response, it defaults to 200
+	Status int `json:"status,omitempty"`
+	// Headers are sent once for each value, like in Response.WithHeaderValues
+	Headers map[string][]string `json:"headers,omitempty"`
+	// Cookies are sent via the Set-Cookie header, like in Response.WithCookie
+	Cookies []StubCookie `json:"cookies,omitempty"`
+	// Trailers are declared before the body and sent after it once for each value, like in Response.WithTrailer
+	Trailers map[string][]string `json:"trailers,omitempty"`
+	Body     string              `json:"body,omitempty"`
+	// JSONBody is returned as body with the header `Content-Type` set to `application/json`. It cannot be used together with Body
+	JSONBody json.RawMessage `json:"jsonBody,omitempty"`
+}
+
+// StubCookie is the serializable version of a cookie set by a Response
+type StubCookie struct {
+	Name   string `json:"name"`
+	Value  string `json:"value"`
+	Path   string `json:"path,omitempty"`
+	Domain string `json:"domain,omitempty"`
+	// MaxAge is the Max-Age attribute in seconds, like in http.Cookie a negative value deletes the cookie
+	MaxAge   int  `json:"maxAge,omitempty"`
+	Secure   bool `json:"secure,omitempty"`
+	HTTPOnly bool `json:"httpOnly,omitempty"`
+	// SameSite is one of "lax", "strict" or "none", if it is empty the attribute is not sent
+	SameSite string `json:"sameSite,omitempty"`
+}
+
+// sameSiteModes maps the values of StubCookie.SameSite to the corresponding http.SameSite
+var sameSiteModes = map[string]http.SameSite{
+	"":       http.SameSiteDefaultMode,
+	"lax":    http.SameSiteLaxMode,
+	"strict": http.SameSiteStrictMode,
+	"none":   http.SameSiteNoneMode,
+}
+
+// toCookie converts the stub cookie to a http.Cookie, it returns an error if the cookie is not valid
+func (c StubCookie) toCookie() (*http.Cookie, error) {
+	sameSite, found := sameSiteModes[c.SameSite]
+	if !found {
+		return nil, fmt.Errorf("the sameSite %q is not one of lax, strict or none", c.SameSite)
+	}
+	cookie := &http.Cookie{
+		Name:     c.Name,
+		Value:    c.Value,
+		Path:     c.Path,
+		Domain:   c.Domain,
+		MaxAge:   c.MaxAge,
+		Secure:   c.Secure,
+		HttpOnly: c.HTTPOnly,
+		SameSite: sameSite,
+	}
+	if err := cookie.Valid(); err != nil {
+		return nil, err
+	}
+	return cookie, nil
+}
+
+// ParseStubs parses data, the content of a StubFile, and validates the stubs it defines
+func ParseStubs(data []byte) ([]Stub, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+
+	file := StubFile{}
+	if err := decoder.Decode(&file); err != nil {
+		return nil, fmt.Errorf("the stubs are not valid JSON: %w", err)
+	}
+	for i, stub := range file.Stubs {
+		if err := stub.validate(); err != nil {
+			return nil, fmt.Errorf("stub %d is not valid: %w", i, err)
+		}
+	}
+	if file.Stubs == nil {
+		file.Stubs = []Stub{}
+	}
+	return file.Stubs, nil
+}
+
+// validate returns an error if the stub cannot be converted to a registration
+func (s Stub) validate() error {
+	if s.Infinite && len(s.Responses) > 1 {
+		return errors.New("an infinite stub can have at most one response")
+	}
+	if _, err := regexp.Compile(s.Request.URL); err != nil {
+		return fmt.Errorf("the URL is not a valid regex: %w", err)
+	}
+	if s.Request.Body != "" && len(s.Request.JSONBody) > 0 {
+		return errors.New("the request cannot have both body and jsonBody")
+	}
+	if len(s.Request.JSONBody) > 0 && !json.Valid(s.Request.JSONBody) {
+		return errors.New("the jsonBody of the request is not valid JSON")
+	}
+	for i, response := range s.Responses {
+		if response.Body != "" && len(response.JSONBody) > 0 {
+			return fmt.Errorf("response %d cannot have both body and jsonBody", i)
+		}
+		if len(response.JSONBody) > 0 && !json.Valid(response.JSONBody) {
+			return fmt.Errorf("the jsonBody of response %d is not valid JSON", i)
+		}
+		for j, cookie := range response.Cookies {
+			if _, err := cookie.toCookie(); err != nil {
+				return fmt.Errorf("cookie %d of response %d is not valid: %w", j, i, err)
+			}
+		}
+	}
+	return nil
+}
+
+// name returns the name of the stub, or its method and URL if the stub has no name
+func (s Stub) name() string {
+	if s.Name != "" {
+		return s.Name
+	}
+	if name := strings.TrimSpace(s.Request.Method + " " + s.Request.URL); name != "" {
+		return name
+	}
+	return "any request"
+}
+
+// toRequest converts the stub request to a Request
+func (s StubRequest) toRequest() Request {
+	request := NewRequest()
+	if s.Method != "" {
+		request = request.WithMethod(s.Method)
+	}
+	if s.URL != "" {
+		request = request.WithURL(s.URL)
+	}
+	for header, value := range s.Headers {
+		request = request.WithHeader(header, value)
+	}
+	if s.Body != "" {
+		request = request.WithStringBody(s.Body)
+	}
+	if len(s.JSONBody) > 0 {
+		request = request.WithJSONHeader().WithBodyMatcher(newJSONBodyMatcher(s.JSONBody))
+	}
+	return request
+}
+
+// jsonBodyMatcher is the BodyMatcher of a stub with a JSON body, it compares the decoded bodies
+// so that whitespace and the order of the fields do not matter
+type jsonBodyMatcher struct {
+	expected any
+}
+
+// newJSONBodyMatcher creates a jsonBodyMatcher that expects body, which must be valid JSON
+func newJSONBodyMatcher(body json.RawMessage) jsonBodyMatcher {
+	var expected any
+	if err := json.Unmarshal(body, &expected); err != nil {
+		panic(fmt.Errorf("cannot decode a JSON body that was already validated: %w", err))
+	}
+	return jsonBodyMatcher{expected: expected}
+}
+
+// Name returns the name used in the reasons why a request did not match
+func (m jsonBodyMatcher) Name() string {
+	return "JSON body"
+}
+
+// Expected returns the expected body as compact JSON
+func (m jsonBodyMatcher) Expected() string {
+	return compactJSON(m.expected)
+}
+
+// Match checks that body is JSON equal to the expected one
+func (m jsonBodyMatcher) Match(_ *http.Request, body []byte) (string, bool, string) {
+	var actual any
+	if err := json.Unmarshal(body, &actual); err != nil {
+		return truncate(string(body)), false, "the body is not valid JSON"
+	}
+	if diff := jsonDiff("$", m.expected, actual); len(diff) > 0 {
+		return compactJSON(actual), false, strings.Join(diff, "; ")
+	}
+	return compactJSON(actual), true, ""
+}
+
+// toResponse converts the stub response to a Response
+func (s StubResponse) toResponse() Response {
+	response := NewResponse()
+	if s.Status != 0 {
+		response = response.WithStatus(s.Status)
+	}
+	for header, values := range s.Headers {
+		response = response.WithHeaderValues(header, values...)
+	}
+	for _, c := range s.Cookies {
+		cookie, err := c.toCookie()
+		if err != nil {
+			panic(fmt.Errorf("cannot convert a cookie that was already validated: %w", err))
+		}
+		response = response.WithCookie(cookie)
+	}
+	for name, values := range s.Trailers {
+		for _, value := range values {
+			response = response.WithTrailer(name, value)
+		}
+	}
+	if s.Body != "" {
+		response = response.WithBody([]byte(s.Body))
+	}
+	if len(s.JSONBody) > 0 {
+		response = response.WithJSONHeader().WithBody(s.JSONBody)
+	}
+	return response
+}
+
+// AddStub adds to the registry the registration defined by stub.
+// It returns an error if the stub is not valid
+//
+//	stubs, err := httpregistry.ParseStubs(data)
+//	...
+//	for _, stub := range stubs {
+//		reg.AddStub(stub)
+//	}
+func (reg *Registry) AddStub(stub Stub) (*Registration, error) {
+	if err := stub.validate(); err != nil {
+		return nil, err
+	}
+
+	request := stub.Request.toRequest().WithName(stub.name())
+
+	responses := make(mockResponses, 0, len(stub.Responses))
+	for _, response := range stub.Responses {
+		responses = append(responses, reg.ifNeededSetDefaultNameToMockResponse(response.toResponse()))
+	}
+	if len(responses) == 0 {
+		responses = append(responses, reg.ifNeededSetDefaultNameToMockResponse(NewResponse()))
+	}
+
+	var m match = newConsumableResponsesMatch(request, responses)
+	if stub.Infinite {
+		m = newInfiniteResponsesMatch(request, responses[0])
+	}
+	// the priority is set before the registration can match any request, otherwise a concurrent request could be served with priority 0
+	return reg.registerWith(m, func(registration *Registration) {
+		registration.priority = stub.Priority
+		registration.stub = &stub
+	}), nil
+}
+
+// stubDefinition returns the stub that defined the registration, or nil if the registration was not added with AddStub
+func (r *Registration) stubDefinition() *Stub {
+	r.reg.mu.Lock()
+	defer r.reg.mu.Unlock()
+	return r.stub
+}
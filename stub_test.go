@@ -0,0 +1,225 @@
+package httpregistry_test
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/dfioravanti/httpregistry"
+)
+
+func (s *TestSuite) TestParseStubs() {
+	data := `{
+		"stubs": [
+			{
+				"name": "list users",
+				"request": {"method": "GET", "url": "/users"},
+				"responses": [{"status": 200, "jsonBody": [{"name": "John"}]}],
+				"infinite": true
+			},
+			{
+				"request": {"method": "POST", "url": "/users", "jsonBody": {"name": "John"}},
+				"responses": [{"status": 201}, {"status": 409}]
+			}
+		]
+	}`
+
+	stubs, err := httpregistry.ParseStubs([]byte(data))
+	s.NoError(err)
+	s.Len(stubs, 2)
+	s.Equal("list users", stubs[0].Name)
+	s.True(stubs[0].Infinite)
+	s.Equal(http.MethodPost, stubs[1].Request.Method)
+	s.Len(stubs[1].Responses, 2)
+}
+
+func (s *TestSuite) TestParseStubsFailsOnInvalidStubs() {
+	testCases := []struct {
+		name          string
+		data          string
+		expectedError string
+	}{
+		{
+			name:          "not JSON",
+			data:          `{"stubs": [`,
+			expectedError: "the stubs are not valid JSON",
+		},
+		{
+			name:          "unknown field",
+			data:          `{"stubs": [{"request": {"path": "/users"}}]}`,
+			expectedError: `unknown field "path"`,
+		},
+		{
+			name:          "invalid regex",
+			data:          `{"stubs": [{"request": {"url": "/users/("}}]}`,
+			expectedError: "stub 0 is not valid: the URL is not a valid regex",
+		},
+		{
+			name:          "infinite with many responses",
+			data:          `{"stubs": [{"request": {}, "responses": [{}, {}], "infinite": true}]}`,
+			expectedError: "stub 0 is not valid: an infinite stub can have at most one response",
+		},
+		{
+			name:          "body and JSON body",
+			data:          `{"stubs": [{"request": {}, "responses": [{"body": "a", "jsonBody": {}}]}]}`,
+			expectedError: "stub 0 is not valid: response 0 cannot have both body and jsonBody",
+		},
+	}
+	for _, tc := range testCases {
+		s.Run(tc.name, func() {
+			_, err := httpregistry.ParseStubs([]byte(tc.data))
+			s.ErrorContains(err, tc.expectedError)
+		})
+	}
+}
+
+func (s *TestSuite) TestAddStub() {
+	reg := httpregistry.NewRegistry(s.T())
+	registration, err := reg.AddStub(httpregistry.Stub{
+		Request: httpregistry.StubRequest{
+			Method:   http.MethodPost,
+			URL:      "/users",
+			Headers:  map[string]string{"X-Tenant": "acme"},
+			JSONBody: []byte(`{ "name": "John" }`),
+		},
+		Responses: []httpregistry.StubResponse{
+			{Status: http.StatusCreated, Headers: map[string][]string{"Location": {"/users/1"}}, JSONBody: []byte(`{"id":1}`)},
+			{Status: http.StatusConflict, Body: "already exists"},
+		},
+	})
+	s.NoError(err)
+	s.Equal("POST /users", registration.String())
+
+	url := reg.GetServer().URL
+	call := func() *http.Response {
+		request, err := http.NewRequest(http.MethodPost, url+"/users", strings.NewReader(`{"name":"John"}`))
+		s.NoError(err)
+		request.Header.Set("Content-Type", "application/json")
+		request.Header.Set("X-Tenant", "acme")
+		res, err := http.DefaultClient.Do(request)
+		s.NoError(err)
+		return res
+	}
+
+	res := call()
+	body, err := io.ReadAll(res.Body)
+	s.NoError(err)
+	s.Equal(http.StatusCreated, res.StatusCode)
+	s.Equal("/users/1", res.Header.Get("Location"))
+	s.Equal("application/json", res.Header.Get("Content-Type"))
+	s.JSONEq(`{"id":1}`, string(body))
+
+	res = call()
+	body, err = io.ReadAll(res.Body)
+	s.NoError(err)
+	s.Equal(http.StatusConflict, res.StatusCode)
+	s.Equal("already exists", string(body))
+
+	reg.CheckAllResponsesAreConsumed()
+}
+
+func (s *TestSuite) TestAddStubComparesTheJSONBodyAsJSON() {
+	t := httpregistry.NewMockTestingT()
+	reg := httpregistry.NewRegistry(t)
+	_, err := reg.AddStub(httpregistry.Stub{
+		Request:  httpregistry.StubRequest{URL: "/users", JSONBody: []byte(`{"name": "John", "age": 42}`)},
+		Infinite: true,
+	})
+	s.NoError(err)
+
+	url := reg.GetServer().URL
+	post := func(body string) int {
+		res, err := http.Post(url+"/users", "application/json", strings.NewReader(body))
+		s.NoError(err)
+		return res.StatusCode
+	}
+
+	s.Equal(http.StatusOK, post(`{"name": "John", "age": 42}`))
+	s.Equal(http.StatusOK, post("{\n\t\"age\": 42,\n\t\"name\": \"John\"\n}"))
+	s.False(t.HasFailed)
+
+	s.Equal(http.StatusInternalServerError, post(`{"name": "Jane", "age": 42}`))
+	s.Equal(`/users missed because the JSON body does not match: $.name: expected "John" got "Jane"`, reg.Why())
+
+	s.Equal(http.StatusInternalServerError, post(`name=John`))
+	s.Equal(`/users missed because the JSON body does not match: the body is not valid JSON`, reg.Why())
+}
+
+func (s *TestSuite) TestAddStubWithoutResponsesReturnsOK() {
+	reg := httpregistry.NewRegistry(s.T())
+	registration, err := reg.AddStub(httpregistry.Stub{Infinite: true})
+	s.NoError(err)
+	s.Equal("any request", registration.String())
+
+	url := reg.GetServer().URL
+	for range 2 {
+		res, err := http.Get(url + "/anything")
+		s.NoError(err)
+		s.Equal(http.StatusOK, res.StatusCode)
+	}
+	s.Equal(2, registration.NumberOfCalls())
+}
+
+func (s *TestSuite) TestAddStubFailsOnInvalidStub() {
+	reg := httpregistry.NewRegistry(s.T())
+	registration, err := reg.AddStub(httpregistry.Stub{Request: httpregistry.StubRequest{JSONBody: []byte(`{`)}})
+	s.Nil(registration)
+	s.EqualError(err, "the jsonBody of the request is not valid JSON")
+}
+
+// stubWithEveryResponseField is a stub that uses repeated headers, cookies and trailers
+var stubWithEveryResponseField = httpregistry.Stub{
+	Name:    "list items",
+	Request: httpregistry.StubRequest{Method: http.MethodGet, URL: "/items"},
+	Responses: []httpregistry.StubResponse{
+		{
+			Status: http.StatusPartialContent,
+			Headers: map[string][]string{
+				"Link": {`</items?page=2>; rel="next"`, `</items?page=5>; rel="last"`},
+			},
+			Cookies: []httpregistry.StubCookie{
+				{Name: "session", Value: "abc", Path: "/", MaxAge: 60, HTTPOnly: true, SameSite: "lax"},
+				{Name: "theme", Value: "dark"},
+			},
+			Trailers: map[string][]string{"X-Checksum": {"1234"}},
+			Body:     "items",
+		},
+	},
+}
+
+// checkResponseWithEveryField checks that res is the response defined by stubWithEveryResponseField
+func (s *TestSuite) checkResponseWithEveryField(res *http.Response) {
+	body, err := io.ReadAll(res.Body)
+	s.NoError(err)
+	s.Equal(http.StatusPartialContent, res.StatusCode)
+	s.Equal("items", string(body))
+	s.Equal([]string{`</items?page=2>; rel="next"`, `</items?page=5>; rel="last"`}, res.Header.Values("Link"))
+	s.Equal([]string{"session=abc; Path=/; Max-Age=60; HttpOnly; SameSite=Lax", "theme=dark"}, res.Header.Values("Set-Cookie"))
+	s.Equal("1234", res.Trailer.Get("X-Checksum"))
+}
+
+func (s *TestSuite) TestStubResponseRoundTrip() {
+	data, err := json.Marshal(httpregistry.StubFile{Stubs: []httpregistry.Stub{stubWithEveryResponseField}})
+	s.NoError(err)
+
+	stubs, err := httpregistry.ParseStubs(data)
+	s.NoError(err)
+	s.Equal([]httpregistry.Stub{stubWithEveryResponseField}, stubs)
+
+	reg := httpregistry.NewRegistry(s.T())
+	_, err = reg.AddStub(stubs[0])
+	s.NoError(err)
+
+	res, err := http.Get(reg.GetServer().URL + "/items")
+	s.NoError(err)
+	s.checkResponseWithEveryField(res)
+}
+
+func (s *TestSuite) TestParseStubsFailsOnInvalidCookies() {
+	_, err := httpregistry.ParseStubs([]byte(`{"stubs": [{"request": {}, "responses": [{"cookies": [{"name": "a b", "value": "1"}]}]}]}`))
+	s.ErrorContains(err, "stub 0 is not valid: cookie 0 of response 0 is not valid")
+
+	_, err = httpregistry.ParseStubs([]byte(`{"stubs": [{"request": {}, "responses": [{"cookies": [{"name": "a", "sameSite": "always"}]}]}]}`))
+	s.EqualError(err, `stub 0 is not valid: cookie 0 of response 0 is not valid: the sameSite "always" is not one of lax, strict or none`)
+}
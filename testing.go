@@ -11,10 +11,23 @@ type TestingT interface {
 	Errorf(format string, args ...any)
 }
 
+// logger is implemented by the implementations of TestingT that can also log messages without failing the test, like [testing.T]
+type logger interface {
+	Logf(format string, args ...any)
+}
+
+// logf logs the message on t if t is able to log, otherwise the message is discarded
+func logf(t TestingT, format string, args ...any) {
+	if l, ok := t.(logger); ok {
+		l.Logf(format, args...)
+	}
+}
+
 // MockTestingT mocks the [testing.T] interface and it can be used to assert that test that should fail will fail
 type MockTestingT struct {
 	HasFailed bool
 	Messages  []string
+	Logs      []string
 }
 
 // Fail records that the Fail function was called
@@ -28,6 +41,11 @@ func (f *MockTestingT) Errorf(format string, args ...any) {
 	f.Fail()
 }
 
+// Logf records what log message was emitted, it does not fail the test
+func (f *MockTestingT) Logf(format string, args ...any) {
+	f.Logs = append(f.Logs, fmt.Sprintf(format, args...))
+}
+
 // NewMockTestingT returns a MockTestingT that can be passed as argument of httpregistry.NewRegistry
 // so that is possible to make assertions on the state of the test or on the message that it returns
 func NewMockTestingT() *MockTestingT {
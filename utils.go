@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"regexp"
 )
 
 // cloneHTTPRequest clones a http.Request in full.
@@ -37,6 +38,26 @@ func mustMarshalJSON(v any) []byte {
 	return b
 }
 
+// mustCompileRegex compiles regex and panics with a message that mentions what the regex is used for if it is not valid
+func mustCompileRegex(regex string, usedFor string) *regexp.Regexp {
+	compiled, err := regexp.Compile(regex)
+	if err != nil {
+		panic(fmt.Sprintf("the regex %q of %s is not valid: %v", regex, usedFor, err))
+	}
+	return compiled
+}
+
+// appendLimited appends v to s and, if limit is positive, drops the oldest elements so that the result contains at most limit elements.
+// Once the limit is reached the backing array of s is reused, so the memory used does not grow
+func appendLimited[T any](s []T, v T, limit int) []T {
+	if limit > 0 && len(s) >= limit {
+		copy(s, s[len(s)-limit+1:])
+		clear(s[limit-1:])
+		s = s[:limit-1]
+	}
+	return append(s, v)
+}
+
 // defaultName is used create default names for requests and responses
 func defaultName(baseString string) func() string {
 	counter := 1
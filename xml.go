@@ -0,0 +1,333 @@
+package httpregistry
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// xmlNode is an element of a parsed XML document.
+// It keeps only the information that is relevant when two documents are compared, so whitespace between elements,
+// comments, processing instructions, namespace prefixes and the order of the attributes are discarded
+type xmlNode struct {
+	name xml.Name
+	// attrs are sorted by namespace and name, namespace declarations are not included
+	attrs    []xml.Attr
+	text     string
+	children []*xmlNode
+}
+
+// parseXML parses data into a tree of xmlNode and returns its root element
+func parseXML(data []byte) (*xmlNode, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	var root *xmlNode
+	stack := []*xmlNode{}
+	texts := []*strings.Builder{}
+
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			if root != nil && len(stack) == 0 {
+				return nil, errors.New("the document has more than one root element")
+			}
+			node := &xmlNode{name: t.Name, attrs: canonicalAttrs(t.Attr), children: []*xmlNode{}}
+			if len(stack) > 0 {
+				parent := stack[len(stack)-1]
+				parent.children = append(parent.children, node)
+			} else {
+				root = node
+			}
+			stack = append(stack, node)
+			texts = append(texts, &strings.Builder{})
+		case xml.EndElement:
+			node := stack[len(stack)-1]
+			node.text = strings.TrimSpace(texts[len(texts)-1].String())
+			stack = stack[:len(stack)-1]
+			texts = texts[:len(texts)-1]
+		case xml.CharData:
+			if len(texts) > 0 {
+				texts[len(texts)-1].Write(t)
+			}
+		}
+	}
+
+	if root == nil {
+		return nil, errors.New("the document does not contain any element")
+	}
+	return root, nil
+}
+
+// canonicalAttrs returns attrs without the namespace declarations and sorted by namespace and name
+func canonicalAttrs(attrs []xml.Attr) []xml.Attr {
+	canonical := []xml.Attr{}
+	for _, attr := range attrs {
+		if attr.Name.Space == "xmlns" || (attr.Name.Space == "" && attr.Name.Local == "xmlns") {
+			continue
+		}
+		canonical = append(canonical, attr)
+	}
+	sort.Slice(canonical, func(i, j int) bool {
+		if canonical[i].Name.Space != canonical[j].Name.Space {
+			return canonical[i].Name.Space < canonical[j].Name.Space
+		}
+		return canonical[i].Name.Local < canonical[j].Name.Local
+	})
+	return canonical
+}
+
+// qualifiedName returns the name in the form {namespace}local, or just local if there is no namespace
+func qualifiedName(name xml.Name) string {
+	if name.Space == "" {
+		return name.Local
+	}
+	return fmt.Sprintf("{%s}%s", name.Space, name.Local)
+}
+
+// canonical returns a compact representation of the tree rooted in n, two trees are equivalent if and only if their canonical representations are equal
+func (n *xmlNode) canonical() string {
+	var b strings.Builder
+	n.writeCanonical(&b)
+	return b.String()
+}
+
+// writeCanonical writes the canonical representation of n to b
+func (n *xmlNode) writeCanonical(b *strings.Builder) {
+	b.WriteString("<" + qualifiedName(n.name))
+	for _, attr := range n.attrs {
+		fmt.Fprintf(b, " %s=%q", qualifiedName(attr.Name), attr.Value)
+	}
+	b.WriteString(">")
+	if err := xml.EscapeText(b, []byte(n.text)); err != nil {
+		panic(fmt.Errorf("cannot escape XML text: %w", err))
+	}
+	for _, child := range n.children {
+		child.writeCanonical(b)
+	}
+	b.WriteString("</" + qualifiedName(n.name) + ">")
+}
+
+// xmlDiff returns one line for each difference between two XML trees.
+// Each line starts with the path of the element that differs, for example /Envelope/Body/item[2]
+func xmlDiff(path string, expected *xmlNode, actual *xmlNode) []string {
+	if expected.name != actual.name {
+		return []string{fmt.Sprintf("%s: expected element %s got %s", path, qualifiedName(expected.name), qualifiedName(actual.name))}
+	}
+
+	diffs := []string{}
+	expectedAttrs, actualAttrs := map[xml.Name]string{}, map[xml.Name]string{}
+	names := []xml.Name{}
+	for _, attr := range expected.attrs {
+		expectedAttrs[attr.Name] = attr.Value
+		names = append(names, attr.Name)
+	}
+	for _, attr := range actual.attrs {
+		actualAttrs[attr.Name] = attr.Value
+		if _, found := expectedAttrs[attr.Name]; !found {
+			names = append(names, attr.Name)
+		}
+	}
+	for _, name := range names {
+		attrPath := fmt.Sprintf("%s/@%s", path, qualifiedName(name))
+		expectedValue, isExpected := expectedAttrs[name]
+		actualValue, isActual := actualAttrs[name]
+		switch {
+		case !isActual:
+			diffs = append(diffs, fmt.Sprintf("%s: expected %q but it is missing", attrPath, expectedValue))
+		case !isExpected:
+			diffs = append(diffs, fmt.Sprintf("%s: unexpected %q", attrPath, actualValue))
+		case expectedValue != actualValue:
+			diffs = append(diffs, fmt.Sprintf("%s: expected %q got %q", attrPath, expectedValue, actualValue))
+		}
+	}
+
+	if expected.text != actual.text {
+		diffs = append(diffs, fmt.Sprintf("%s: expected text %q got %q", path, truncate(expected.text), truncate(actual.text)))
+	}
+
+	for i := 0; i < len(expected.children) || i < len(actual.children); i++ {
+		switch {
+		case i >= len(actual.children):
+			child := expected.children[i]
+			diffs = append(diffs, fmt.Sprintf("%s: expected %s but it is missing", childPath(path, expected, i), truncate(child.canonical())))
+		case i >= len(expected.children):
+			child := actual.children[i]
+			diffs = append(diffs, fmt.Sprintf("%s: unexpected %s", childPath(path, actual, i), truncate(child.canonical())))
+		default:
+			diffs = append(diffs, xmlDiff(childPath(path, expected, i), expected.children[i], actual.children[i])...)
+		}
+	}
+	return diffs
+}
+
+// childPath returns the path of the i-th child of parent, the position is added only if parent has multiple children with the same name
+func childPath(path string, parent *xmlNode, i int) string {
+	child := parent.children[i]
+	position, total := 0, 0
+	for j, sibling := range parent.children {
+		if sibling.name == child.name {
+			total++
+			if j <= i {
+				position++
+			}
+		}
+	}
+	if total == 1 {
+		return path + "/" + child.name.Local
+	}
+	return fmt.Sprintf("%s/%s[%d]", path, child.name.Local, position)
+}
+
+// mustParseXML parses data and panics if it is not valid XML
+func mustParseXML(data string) *xmlNode {
+	node, err := parseXML([]byte(data))
+	if err != nil {
+		panic(fmt.Sprintf("body is not valid XML: %s", err))
+	}
+	return node
+}
+
+// mustMarshalXML tries to marshal v into XML, prefixed by the standard XML header, and panics if it cannot.
+// Strings and slices of bytes are considered already encoded and are returned as they are
+func mustMarshalXML(v any) []byte {
+	switch body := v.(type) {
+	case string:
+		return []byte(body)
+	case []byte:
+		return body
+	}
+	b, err := xml.Marshal(v)
+	if err != nil {
+		panic(fmt.Sprintf("body cannot be marshaled to XML: %s", err))
+	}
+	return append([]byte(xml.Header), b...)
+}
+
+// xmlRule defines how an XML body, or the SOAP metadata of the request, is compared with the expected value
+type xmlRule string
+
+// These constants are the rules that can be used to match an XML body, they are also used to describe the expected value in the reports
+const (
+	xmlEquivalent    = xmlRule("equivalent to")
+	xpathEquals      = xmlRule("equal to")
+	xpathPresent     = xmlRule("present")
+	soapActionEquals = xmlRule("SOAP action")
+)
+
+// xmlCriterion is a condition that a registered Request places on the XML body of an incoming request
+type xmlCriterion struct {
+	rule xmlRule
+	// expr is the XPath expression for xpathEquals and xpathPresent
+	expr  string
+	value string
+	// document is the parsed version of value for xmlEquivalent and path is the parsed version of expr for xpathEquals and xpathPresent,
+	// they are parsed once when the criterion is created
+	document *xmlNode
+	path     xpath
+}
+
+// name returns the name of the criterion used in the reports
+func (c xmlCriterion) name() string {
+	switch c.rule {
+	case xmlEquivalent:
+		return "XML body"
+	case soapActionEquals:
+		return "SOAP action"
+	default:
+		return fmt.Sprintf("XPath %q", c.expr)
+	}
+}
+
+// expected returns the human readable version of what the criterion expects
+func (c xmlCriterion) expected() string {
+	switch c.rule {
+	case xmlEquivalent:
+		return truncate(c.document.canonical())
+	case xpathPresent:
+		return string(c.rule)
+	default:
+		return c.value
+	}
+}
+
+// evaluate checks the criterion against the incoming request r whose body was already read into body
+func (c xmlCriterion) evaluate(r *http.Request, body []byte) criterionResult {
+	if c.rule == soapActionEquals {
+		action := soapAction(r)
+		result := newCriterionResult(c.name(), c.expected(), action, action == c.value, soapActionDoesNotMatch)
+		result.missDetail = fmt.Sprintf("the SOAP action is not %q", c.value)
+		return result
+	}
+
+	why := xpathDoesNotMatch
+	if c.rule == xmlEquivalent {
+		why = xmlBodyDoesNotMatch
+	}
+
+	actual, err := parseXML(body)
+	if err != nil {
+		result := newCriterionResult(c.name(), c.expected(), truncate(string(body)), false, why)
+		result.missDetail = fmt.Sprintf("the body is not valid XML: %s", err)
+		return result
+	}
+
+	if c.rule == xmlEquivalent {
+		expected := c.document
+		result := newCriterionResult(c.name(), c.expected(), truncate(actual.canonical()), expected.canonical() == actual.canonical(), why)
+		if !result.matched {
+			result.details = xmlDiff("/"+expected.name.Local, expected, actual)
+			result.missDetail = result.details[0]
+		}
+		return result
+	}
+
+	values := c.path.evaluate(actual)
+	matched := len(values) > 0
+	if c.rule == xpathEquals {
+		matched = false
+		for _, v := range values {
+			matched = matched || v == c.value
+		}
+	}
+	result := newCriterionResult(c.name(), c.expected(), strings.Join(values, ", "), matched, why)
+	switch {
+	case len(values) == 0:
+		result.missDetail = fmt.Sprintf("XPath %q does not select anything", c.expr)
+	default:
+		result.missDetail = fmt.Sprintf("XPath %q is not equal to %q", c.expr, c.value)
+	}
+	return result
+}
+
+// withXMLCriterion returns a copy of criteria with criterion added, the criteria with the same rule and expression are replaced.
+// The criteria are kept sorted so that two requests with the same criteria are equal independently of the order in which they were added
+func withXMLCriterion(criteria []xmlCriterion, criterion xmlCriterion) []xmlCriterion {
+	newCriteria := make([]xmlCriterion, 0, len(criteria)+1)
+	for _, c := range criteria {
+		if c.rule == criterion.rule && c.expr == criterion.expr {
+			continue
+		}
+		newCriteria = append(newCriteria, c)
+	}
+	newCriteria = append(newCriteria, criterion)
+
+	sort.SliceStable(newCriteria, func(i, j int) bool {
+		if newCriteria[i].rule != newCriteria[j].rule {
+			return newCriteria[i].rule < newCriteria[j].rule
+		}
+		return newCriteria[i].expr < newCriteria[j].expr
+	})
+	return newCriteria
+}
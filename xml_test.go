@@ -0,0 +1,194 @@
+package httpregistry_test
+
+import (
+	"encoding/xml"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/dfioravanti/httpregistry"
+)
+
+const payEnvelope = `<?xml version="1.0"?>
+<soap:Envelope xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/">
+	<soap:Body>
+		<Pay xmlns="urn:payments" currency="EUR" card="4242">
+			<Amount>10.00</Amount>
+		</Pay>
+	</soap:Body>
+</soap:Envelope>`
+
+func (s *TestSuite) TestXMLMatching() {
+	testCases := []struct {
+		name        string
+		request     httpregistry.Request
+		shouldMatch bool
+		expectedWhy string
+	}{
+		{
+			name: "equivalent body with different prefixes, attribute order and whitespace",
+			request: httpregistry.NewRequest().WithXMLBody(
+				`<env:Envelope xmlns:env="http://schemas.xmlsoap.org/soap/envelope/"><env:Body>` +
+					`<p:Pay xmlns:p="urn:payments" card="4242" currency="EUR"><p:Amount>10.00</p:Amount></p:Pay>` +
+					`</env:Body></env:Envelope>`,
+			),
+			shouldMatch: true,
+		},
+		{
+			name: "body is not equivalent",
+			request: httpregistry.NewRequest().WithXMLBody(
+				`<soap:Envelope xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/"><soap:Body>` +
+					`<Pay xmlns="urn:payments" currency="EUR" card="4242"><Amount>12.00</Amount></Pay>` +
+					`</soap:Body></soap:Envelope>`,
+			),
+			expectedWhy: `mock request #1 missed because the XML body does not match: /Envelope/Body/Pay/Amount: expected text "12.00" got "10.00"`,
+		},
+		{
+			name:        "XPath",
+			request:     httpregistry.NewRequest().WithXPath("/Envelope/Body/Pay[@currency='EUR']/Amount", "10.00"),
+			shouldMatch: true,
+		},
+		{
+			name:        "XPath with a different value",
+			request:     httpregistry.NewRequest().WithXPath("//Pay/Amount", "12.00"),
+			expectedWhy: `mock request #1 missed because the XPath does not match: XPath "//Pay/Amount" is not equal to "12.00"`,
+		},
+		{
+			name:        "XPath present",
+			request:     httpregistry.NewRequest().WithXPathPresent("//Pay/@card"),
+			shouldMatch: true,
+		},
+		{
+			name:        "XPath that selects nothing",
+			request:     httpregistry.NewRequest().WithXPathPresent("//Refund"),
+			expectedWhy: `mock request #1 missed because the XPath does not match: XPath "//Refund" does not select anything`,
+		},
+		{
+			name:        "SOAP 1.1 action",
+			request:     httpregistry.NewRequest().WithSOAPAction("urn:Pay"),
+			shouldMatch: true,
+		},
+		{
+			name:        "different SOAP action",
+			request:     httpregistry.NewRequest().WithSOAPAction("urn:Refund"),
+			expectedWhy: `mock request #1 missed because the SOAP action does not match: the SOAP action is not "urn:Refund"`,
+		},
+	}
+	for _, tc := range testCases {
+		s.Run(tc.name, func() {
+			mockT := httpregistry.NewMockTestingT()
+			registry := httpregistry.NewRegistry(mockT)
+			registry.AddRequest(tc.request)
+
+			server := registry.GetServer()
+			defer server.Close()
+
+			request, err := http.NewRequest(http.MethodPost, server.URL, strings.NewReader(payEnvelope))
+			s.NoError(err)
+			request.Header.Set("Content-Type", "text/xml; charset=utf-8")
+			request.Header.Set("SOAPAction", `"urn:Pay"`)
+
+			res, err := http.DefaultClient.Do(request)
+			s.NoError(err)
+
+			if tc.shouldMatch {
+				s.Equal(http.StatusOK, res.StatusCode)
+				s.False(mockT.HasFailed)
+				return
+			}
+			s.Equal(http.StatusInternalServerError, res.StatusCode)
+			s.Equal(tc.expectedWhy, registry.Why())
+		})
+	}
+}
+
+func (s *TestSuite) TestSOAP12ActionIsReadFromTheContentType() {
+	mockT := httpregistry.NewMockTestingT()
+	registry := httpregistry.NewRegistry(mockT)
+	registry.AddRequest(httpregistry.NewRequest().WithSOAPAction("urn:Pay"))
+
+	server := registry.GetServer()
+	defer server.Close()
+
+	res, err := http.Post(server.URL, `application/soap+xml; charset=utf-8; action="urn:Pay"`, strings.NewReader(payEnvelope))
+	s.NoError(err)
+	s.Equal(http.StatusOK, res.StatusCode)
+	s.False(mockT.HasFailed)
+}
+
+func (s *TestSuite) TestXMLCriteriaFailOnBodiesThatAreNotXML() {
+	mockT := httpregistry.NewMockTestingT()
+	registry := httpregistry.NewRegistry(mockT)
+	registry.AddRequest(httpregistry.NewRequest().WithXPathPresent("//Pay"))
+
+	server := registry.GetServer()
+	defer server.Close()
+
+	res, err := http.Post(server.URL, "application/json", strings.NewReader(`{"pay": true}`))
+	s.NoError(err)
+	s.Equal(http.StatusInternalServerError, res.StatusCode)
+	s.Contains(registry.Why(), "the XPath does not match: the body is not valid XML")
+}
+
+func (s *TestSuite) TestInvalidXMLCriteriaPanic() {
+	s.Panics(func() { httpregistry.NewRequest().WithXMLBody("<a>") })
+	s.Panics(func() { httpregistry.NewRequest().WithXPath("Envelope", "x") })
+}
+
+func (s *TestSuite) TestXMLResponses() {
+	type user struct {
+		XMLName xml.Name `xml:"user"`
+		Name    string   `xml:"name"`
+	}
+
+	testCases := []struct {
+		name                string
+		response            httpregistry.Response
+		expectedStatus      int
+		expectedContentType string
+		expectedBody        string
+	}{
+		{
+			name:                "struct is encoded",
+			response:            httpregistry.NewResponse().WithXMLBody(user{Name: "John"}),
+			expectedStatus:      http.StatusOK,
+			expectedContentType: "application/xml",
+			expectedBody:        xml.Header + "<user><name>John</name></user>",
+		},
+		{
+			name:                "string is returned as it is",
+			response:            httpregistry.NewResponse().WithXMLBody("<ok/>"),
+			expectedStatus:      http.StatusOK,
+			expectedContentType: "application/xml",
+			expectedBody:        "<ok/>",
+		},
+		{
+			name:                "SOAP fault",
+			response:            httpregistry.NewResponse().WithSOAPFault("soap:Client", "the card was declined"),
+			expectedStatus:      http.StatusInternalServerError,
+			expectedContentType: "text/xml; charset=utf-8",
+			expectedBody: xml.Header +
+				`<soap:Envelope xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/"><soap:Body><soap:Fault>` +
+				`<faultcode>soap:Client</faultcode><faultstring>the card was declined</faultstring>` +
+				`</soap:Fault></soap:Body></soap:Envelope>`,
+		},
+	}
+	for _, tc := range testCases {
+		s.Run(tc.name, func() {
+			registry := httpregistry.NewRegistry(s.T())
+			registry.AddResponse(tc.response)
+
+			server := registry.GetServer()
+			defer server.Close()
+
+			res, err := http.Get(server.URL)
+			s.NoError(err)
+			body, err := io.ReadAll(res.Body)
+			s.NoError(err)
+
+			s.Equal(tc.expectedStatus, res.StatusCode)
+			s.Equal(tc.expectedContentType, res.Header.Get("Content-Type"))
+			s.Equal(tc.expectedBody, string(body))
+		})
+	}
+}
@@ -0,0 +1,324 @@
+package httpregistry
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// xpathStep is a location step of an XPath expression, for example item[@id='1'] in /order//item[@id='1']
+type xpathStep struct {
+	// descendant is true if the step is preceded by // instead of /
+	descendant bool
+	// name is the local name of the selected elements, * selects any element
+	name string
+	// attribute is true if the step selects the attribute name instead of an element
+	attribute bool
+	// text is true if the step is text() and selects the text of the context elements
+	text       bool
+	predicates []xpathPredicate
+}
+
+// xpathPredicate is a filter applied to the elements selected by a step, for example [2], [@id='1'] or [name='John']
+type xpathPredicate struct {
+	// position is the 1-based position of the element among the ones selected by the step, it is 0 if the predicate is not positional
+	position int
+	// attribute is true if the predicate is about an attribute of the element, otherwise it is about a child element or, if name is text(), its text
+	attribute bool
+	name      string
+	// hasValue is false if the predicate only checks that the attribute or the child exists
+	hasValue bool
+	value    string
+}
+
+// xpath is a parsed XPath expression.
+// Only a subset of XPath 1.0 is supported:
+//   - absolute location paths made of child (/) and descendant (//) steps, for example /Envelope/Body or //item
+//   - element names, with an optional namespace prefix that is ignored, and the wildcard *
+//   - a final @name step that selects an attribute and a final text() step that selects the text of the elements
+//   - the predicates [n], [@name], [@name='value'], [name], [name='value'] and [text()='value']
+type xpath struct {
+	steps []xpathStep
+}
+
+// mustParseXPath parses expr and panics if it is not a valid expression of the supported subset of XPath
+func mustParseXPath(expr string) xpath {
+	x, err := parseXPath(expr)
+	if err != nil {
+		panic(fmt.Sprintf("XPath %q is not valid: %s", expr, err))
+	}
+	return x
+}
+
+// parseXPath parses expr into an xpath
+func parseXPath(expr string) (xpath, error) {
+	if !strings.HasPrefix(expr, "/") {
+		return xpath{}, fmt.Errorf("only absolute paths are supported")
+	}
+
+	steps := []xpathStep{}
+	rest := expr
+	for rest != "" {
+		step := xpathStep{}
+		switch {
+		case strings.HasPrefix(rest, "//"):
+			step.descendant = true
+			rest = rest[2:]
+		case strings.HasPrefix(rest, "/"):
+			rest = rest[1:]
+		default:
+			return xpath{}, fmt.Errorf("unexpected %q", rest)
+		}
+
+		end := stepEnd(rest)
+		raw := rest[:end]
+		rest = rest[end:]
+		if err := step.parse(raw); err != nil {
+			return xpath{}, err
+		}
+		if (step.attribute || step.text) && rest != "" {
+			return xpath{}, fmt.Errorf("%q must be the last step", raw)
+		}
+		steps = append(steps, step)
+	}
+	return xpath{steps: steps}, nil
+}
+
+// stepEnd returns the index of the / that terminates the first step of s, ignoring the ones inside predicates and quotes
+func stepEnd(s string) int {
+	depth, quote := 0, rune(0)
+	for i, c := range s {
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		case c == '[':
+			depth++
+		case c == ']':
+			depth--
+		case c == '/' && depth == 0:
+			return i
+		}
+	}
+	return len(s)
+}
+
+// parse fills the step from its textual representation raw, for example item[@id='1']
+func (step *xpathStep) parse(raw string) error {
+	name := raw
+	if i := strings.Index(raw, "["); i >= 0 {
+		name = raw[:i]
+		predicates := raw[i:]
+		for predicates != "" {
+			if !strings.HasPrefix(predicates, "[") {
+				return fmt.Errorf("predicate %q is not valid", predicates)
+			}
+			closing := predicateEnd(predicates)
+			if closing < 0 {
+				return fmt.Errorf("predicate %q is not closed", predicates)
+			}
+			predicate, err := parsePredicate(predicates[1:closing])
+			if err != nil {
+				return err
+			}
+			step.predicates = append(step.predicates, predicate)
+			predicates = predicates[closing+1:]
+		}
+	}
+
+	switch {
+	case name == "":
+		return fmt.Errorf("empty step")
+	case name == "text()":
+		step.text = true
+	case strings.HasPrefix(name, "@"):
+		step.attribute = true
+		step.name = localName(name[1:])
+	default:
+		step.name = localName(name)
+	}
+	if (step.text || step.attribute) && len(step.predicates) > 0 {
+		return fmt.Errorf("predicates are not supported on %q", name)
+	}
+	return nil
+}
+
+// predicateEnd returns the index of the ] that closes the predicate at the beginning of s, ignoring the ones inside quotes.
+// It returns -1 if the predicate is not closed
+func predicateEnd(s string) int {
+	quote := rune(0)
+	for i, c := range s {
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		case c == ']':
+			return i
+		}
+	}
+	return -1
+}
+
+// parsePredicate parses the content of a predicate, without the square brackets
+func parsePredicate(raw string) (xpathPredicate, error) {
+	raw = strings.TrimSpace(raw)
+	if position, err := strconv.Atoi(raw); err == nil {
+		if position < 1 {
+			return xpathPredicate{}, fmt.Errorf("position %d is not valid, positions start from 1", position)
+		}
+		return xpathPredicate{position: position}, nil
+	}
+
+	predicate := xpathPredicate{}
+	name := raw
+	if i := strings.Index(raw, "="); i >= 0 {
+		name = strings.TrimSpace(raw[:i])
+		value := strings.TrimSpace(raw[i+1:])
+		if len(value) < 2 || (value[0] != '\'' && value[0] != '"') || value[len(value)-1] != value[0] {
+			return xpathPredicate{}, fmt.Errorf("value %s in predicate %q must be quoted", value, raw)
+		}
+		predicate.hasValue = true
+		predicate.value = value[1 : len(value)-1]
+	}
+
+	if strings.HasPrefix(name, "@") {
+		predicate.attribute = true
+		name = name[1:]
+	}
+	if name == "" {
+		return xpathPredicate{}, fmt.Errorf("predicate %q is not valid", raw)
+	}
+	if name != "text()" {
+		name = localName(name)
+	}
+	predicate.name = name
+	return predicate, nil
+}
+
+// localName removes the namespace prefix from name, since prefixes in documents are arbitrary the elements are matched by local name only
+func localName(name string) string {
+	if i := strings.Index(name, ":"); i >= 0 {
+		return name[i+1:]
+	}
+	return name
+}
+
+// evaluate returns the values selected by the expression in the document with root root.
+// The value of an element is its text
+func (x xpath) evaluate(root *xmlNode) []string {
+	// the document node is the parent of the root element
+	context := []*xmlNode{{children: []*xmlNode{root}}}
+	for _, step := range x.steps {
+		if step.descendant {
+			context = descendantsOrSelf(context)
+		}
+
+		switch {
+		case step.attribute:
+			values := []string{}
+			for _, node := range context {
+				for _, attr := range node.attrs {
+					if step.name == "*" || attr.Name.Local == step.name {
+						values = append(values, attr.Value)
+					}
+				}
+			}
+			return values
+		case step.text:
+			values := []string{}
+			for _, node := range context {
+				values = append(values, node.text)
+			}
+			return values
+		}
+
+		selected := []*xmlNode{}
+		for _, node := range context {
+			candidates := []*xmlNode{}
+			for _, child := range node.children {
+				if step.name == "*" || child.name.Local == step.name {
+					candidates = append(candidates, child)
+				}
+			}
+			for _, predicate := range step.predicates {
+				candidates = predicate.filter(candidates)
+			}
+			selected = append(selected, candidates...)
+		}
+		context = selected
+	}
+
+	values := make([]string, 0, len(context))
+	for _, node := range context {
+		values = append(values, node.text)
+	}
+	return values
+}
+
+// descendantsOrSelf returns the nodes in nodes and all their descendants, each node is returned once
+func descendantsOrSelf(nodes []*xmlNode) []*xmlNode {
+	seen := map[*xmlNode]bool{}
+	result := []*xmlNode{}
+	var visit func(node *xmlNode)
+	visit = func(node *xmlNode) {
+		if seen[node] {
+			return
+		}
+		seen[node] = true
+		result = append(result, node)
+		for _, child := range node.children {
+			visit(child)
+		}
+	}
+	for _, node := range nodes {
+		visit(node)
+	}
+	return result
+}
+
+// filter returns the nodes that satisfy the predicate
+func (p xpathPredicate) filter(nodes []*xmlNode) []*xmlNode {
+	if p.position > 0 {
+		if p.position > len(nodes) {
+			return []*xmlNode{}
+		}
+		return []*xmlNode{nodes[p.position-1]}
+	}
+
+	filtered := []*xmlNode{}
+	for _, node := range nodes {
+		if p.isSatisfiedBy(node) {
+			filtered = append(filtered, node)
+		}
+	}
+	return filtered
+}
+
+// isSatisfiedBy checks if node satisfies a predicate that is not positional
+func (p xpathPredicate) isSatisfiedBy(node *xmlNode) bool {
+	if p.name == "text()" {
+		return !p.hasValue || node.text == p.value
+	}
+
+	if p.attribute {
+		for _, attr := range node.attrs {
+			if attr.Name.Local == p.name && (!p.hasValue || attr.Value == p.value) {
+				return true
+			}
+		}
+		return false
+	}
+
+	for _, child := range node.children {
+		if child.name.Local == p.name && (!p.hasValue || child.text == p.value) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,104 @@
+package httpregistry
+
+func (s *TestSuite) TestXPathEvaluation() {
+	document := `
+	<soap:Envelope xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/" xmlns:p="urn:payments">
+		<soap:Body>
+			<p:Pay id="42">
+				<p:Item currency="EUR"><p:Amount>10.00</p:Amount></p:Item>
+				<p:Item currency="USD"><p:Amount>12.50</p:Amount></p:Item>
+				<p:Note>  fast  </p:Note>
+			</p:Pay>
+		</soap:Body>
+	</soap:Envelope>`
+
+	testCases := []struct {
+		expr     string
+		expected []string
+	}{
+		{expr: "/Envelope/Body/Pay/Note", expected: []string{"fast"}},
+		{expr: "/soap:Envelope/soap:Body/p:Pay/p:Note/text()", expected: []string{"fast"}},
+		{expr: "//Amount", expected: []string{"10.00", "12.50"}},
+		{expr: "//Item[2]/Amount", expected: []string{"12.50"}},
+		{expr: "//Item[@currency='USD']/Amount", expected: []string{"12.50"}},
+		{expr: `//Item[Amount="10.00"]/@currency`, expected: []string{"EUR"}},
+		{expr: "//Pay/@id", expected: []string{"42"}},
+		{expr: "/Envelope/*/Pay[@id]/Note", expected: []string{"fast"}},
+		{expr: "//Item[@currency='GBP']", expected: []string{}},
+		{expr: "/Body", expected: []string{}},
+	}
+	root, err := parseXML([]byte(document))
+	s.NoError(err)
+	for _, tc := range testCases {
+		s.Run(tc.expr, func() {
+			s.Equal(tc.expected, mustParseXPath(tc.expr).evaluate(root))
+		})
+	}
+}
+
+func (s *TestSuite) TestInvalidXPathsAreRejected() {
+	for _, expr := range []string{"Envelope", "/a[", "/a[0]", "/a/@b/c", "/a[@b=c]", "/a//", "/text()[1]"} {
+		s.Run(expr, func() {
+			_, err := parseXPath(expr)
+			s.Error(err)
+		})
+	}
+}
+
+func (s *TestSuite) TestXMLDiff() {
+	testCases := []struct {
+		name     string
+		expected string
+		actual   string
+		diffs    []string
+	}{
+		{
+			name:     "equivalent documents",
+			expected: `<a xmlns="urn:x" b="1" c="2"><d>text</d></a>`,
+			actual:   "<x:a xmlns:x=\"urn:x\" c=\"2\" b=\"1\">\n  <x:d> text </x:d>\n</x:a>",
+			diffs:    []string{},
+		},
+		{
+			name:     "different attributes",
+			expected: `<a b="1" c="2"/>`,
+			actual:   `<a b="2" d="3"/>`,
+			diffs:    []string{`/a/@b: expected "1" got "2"`, `/a/@c: expected "2" but it is missing`, `/a/@d: unexpected "3"`},
+		},
+		{
+			name:     "different text in repeated elements",
+			expected: `<a><b>1</b><b>2</b></a>`,
+			actual:   `<a><b>1</b><b>3</b><c/></a>`,
+			diffs:    []string{`/a/b[2]: expected text "2" got "3"`, `/a/c: unexpected <c></c>`},
+		},
+		{
+			name:     "different namespace",
+			expected: `<a xmlns="urn:x"/>`,
+			actual:   `<a xmlns="urn:y"/>`,
+			diffs:    []string{`/a: expected element {urn:x}a got {urn:y}a`},
+		},
+	}
+	for _, tc := range testCases {
+		s.Run(tc.name, func() {
+			expected, actual := mustParseXML(tc.expected), mustParseXML(tc.actual)
+			s.Equal(tc.diffs, xmlDiff("/"+expected.name.Local, expected, actual))
+			s.Equal(len(tc.diffs) == 0, expected.canonical() == actual.canonical())
+		})
+	}
+}
+
+func (s *TestSuite) TestXMLCriteriaAreParsedOnce() {
+	request := NewRequest().
+		WithXMLBody(`<order id="1"><item>book</item></order>`).
+		WithXPath("/order/item", "book").
+		WithXPathPresent("/order/@id")
+
+	for _, criterion := range request.xml {
+		switch criterion.rule {
+		case xmlEquivalent:
+			s.Equal(mustParseXML(criterion.value), criterion.document)
+		default:
+			s.Equal(mustParseXPath(criterion.expr), criterion.path)
+		}
+	}
+	s.True(request.Equal(NewRequest().WithXPathPresent("/order/@id").WithXPath("/order/item", "book").WithXMLBody(`<order id="1"><item>book</item></order>`)))
+}